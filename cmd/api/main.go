@@ -6,6 +6,8 @@ import (
 	"net/http"
 	"os/signal"
 	"syscall"
+	// the runtime image has no zoneinfo, BUSINESS_HOURS_TZ is resolved from the embedded copy
+	_ "time/tzdata"
 
 	"avito-internship-task/internal/app"
 	"avito-internship-task/internal/config"
@@ -2,33 +2,61 @@ package integration
 
 import (
 	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
+	"avito-internship-task/internal/absences"
+	"avito-internship-task/internal/codeowners"
+	"avito-internship-task/internal/entity"
 	"avito-internship-task/internal/httpserver"
+	"avito-internship-task/internal/integrations"
 	"avito-internship-task/internal/pullrequests"
+	"avito-internship-task/internal/repositories"
 	"avito-internship-task/internal/teams"
 	"avito-internship-task/internal/users"
+	"avito-internship-task/internal/webhooks"
 	"github.com/stretchr/testify/require"
 )
 
+const githubSecret = "github-secret"
+
 func startTestServer(t *testing.T) (*httptest.Server, func()) {
 	pool := setupPostgres(t)
 
+	codeOwnersService := codeowners.NewService(codeowners.NewRepository(pool))
+	codeOwnersHandler := codeowners.NewHandler(codeOwnersService)
+
+	prRepo := pullrequests.NewRepository(pool)
+	prService := pullrequests.NewService(prRepo, pullrequests.Options{CodeOwners: codeOwnersService})
+	prHandler := pullrequests.NewHandler(prService)
+
 	teamRepo := teams.NewRepository(pool)
-	teamService := teams.NewService(teamRepo)
+	teamService := teams.NewService(teamRepo, prService)
 	teamHandler := teams.NewHandler(teamService)
 
 	userRepo := users.NewRepository(pool)
-	userService := users.NewService(userRepo)
+	userService := users.NewService(userRepo, prService, users.Options{})
 	userHandler := users.NewHandler(userService)
 
-	prRepo := pullrequests.NewRepository(pool)
-	prService := pullrequests.NewService(prRepo)
-	prHandler := pullrequests.NewHandler(prService)
+	absenceRepo := absences.NewRepository(pool)
+	absenceService := absences.NewService(absenceRepo)
+	absenceHandler := absences.NewHandler(absenceService)
+
+	webhookHandler := webhooks.NewHandler(webhooks.NewService(webhooks.NewRepository(pool)))
+
+	integrationService := integrations.NewService(integrations.NewRepository(pool), prService, integrations.Options{
+		GitHubSecret: githubSecret,
+	})
+	integrationHandler := integrations.NewHandler(integrationService)
+
+	repositoryHandler := repositories.NewHandler(repositories.NewService(repositories.NewRepository(pool)))
 
 	mux := http.NewServeMux()
 	mux.Handle("/healthz", httpserver.WithError(func(w http.ResponseWriter, _ *http.Request) error {
@@ -38,6 +66,11 @@ func startTestServer(t *testing.T) (*httptest.Server, func()) {
 	teamHandler.Register(mux)
 	userHandler.Register(mux)
 	prHandler.Register(mux)
+	absenceHandler.Register(mux)
+	webhookHandler.Register(mux)
+	integrationHandler.Register(mux)
+	codeOwnersHandler.Register(mux)
+	repositoryHandler.Register(mux)
 
 	server := httptest.NewServer(httpserver.Logging(mux))
 	cleanup := func() {
@@ -84,6 +117,96 @@ func TestTeamHandlers(t *testing.T) {
 	assertErrorCode(t, resp, "MEMBER_EXISTS")
 }
 
+func TestTeamSettingsHandlers(t *testing.T) {
+	server, cleanup := startTestServer(t)
+	defer cleanup()
+	client := &http.Client{Timeout: 5 * time.Second}
+
+	createTeam(client, server.URL)
+
+	resp := runRequest(t, client, http.MethodGet, server.URL+"/team/settings?team_name=backend", nil, http.StatusOK)
+	var settings struct {
+		Settings entity.TeamSettings `json:"settings"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&settings))
+	resp.Body.Close()
+	require.Equal(t, 2, settings.Settings.ReviewerCount)
+
+	update := map[string]any{"team_name": "backend", "reviewer_count": 1, "excluded_users": []string{"u2"}, "review_capacity": 3}
+	runRequest(t, client, http.MethodPost, server.URL+"/team/settings", update, http.StatusOK).Body.Close()
+	// a partial update keeps the other settings
+	resp = runRequest(t, client, http.MethodPost, server.URL+"/team/settings", map[string]any{"team_name": "backend", "sla_hours": 8}, http.StatusOK)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&settings))
+	resp.Body.Close()
+	require.Equal(t, 1, settings.Settings.ReviewerCount)
+	require.Equal(t, []string{"u2"}, settings.Settings.ExcludedUsers)
+	require.Equal(t, 3, *settings.Settings.ReviewCapacity)
+	require.Equal(t, 8, *settings.Settings.SLAHours)
+
+	resp = runRequest(t, client, http.MethodGet, server.URL+"/team/settings", nil, http.StatusBadRequest)
+	assertErrorCode(t, resp, "BAD_REQUEST")
+
+	createReq := map[string]any{"pull_request_id": "pr1", "pull_request_name": "Add feature", "author_id": "u1"}
+	resp = runRequest(t, client, http.MethodPost, server.URL+"/pullRequest/create", createReq, http.StatusCreated)
+	var created struct {
+		PR struct {
+			Assigned []string `json:"assigned_reviewers"`
+		} `json:"pr"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	resp.Body.Close()
+	require.Empty(t, created.PR.Assigned)
+
+	bad := map[string]any{"team_name": "backend", "strategy": "unknown"}
+	resp = runRequest(t, client, http.MethodPost, server.URL+"/team/settings", bad, http.StatusBadRequest)
+	assertErrorCode(t, resp, "BAD_REQUEST")
+}
+
+func TestTeamDeactivateHandler(t *testing.T) {
+	server, cleanup := startTestServer(t)
+	defer cleanup()
+	client := &http.Client{Timeout: 5 * time.Second}
+
+	members := make([]map[string]any, 0, 200)
+	for i := 0; i < 200; i++ {
+		members = append(members, map[string]any{"user_id": fmt.Sprintf("u%d", i), "username": fmt.Sprintf("User%d", i), "is_active": true})
+	}
+	runRequest(t, client, http.MethodPost, server.URL+"/team/add", map[string]any{"team_name": "big", "members": members}, http.StatusCreated).Body.Close()
+	for i := 0; i < 300; i++ {
+		createReq := map[string]any{
+			"pull_request_id":   fmt.Sprintf("pr%d", i),
+			"pull_request_name": "Change",
+			"author_id":         fmt.Sprintf("u%d", i%200),
+		}
+		runRequest(t, client, http.MethodPost, server.URL+"/pullRequest/create", createReq, http.StatusCreated).Body.Close()
+	}
+
+	ids := make([]string, 0, 100)
+	for i := 0; i < 100; i++ {
+		ids = append(ids, fmt.Sprintf("u%d", i))
+	}
+	resp := runRequest(t, client, http.MethodPost, server.URL+"/team/deactivate", map[string]any{"team_name": "big", "user_ids": ids}, http.StatusOK)
+	var payload struct {
+		Deactivated   []string `json:"deactivated"`
+		Reassignments []struct {
+			OldReviewerID string `json:"old_reviewer_id"`
+			NewReviewerID string `json:"new_reviewer_id"`
+		} `json:"reassignments"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&payload))
+	resp.Body.Close()
+	require.Len(t, payload.Deactivated, 100)
+	require.NotEmpty(t, payload.Reassignments)
+	for _, ra := range payload.Reassignments {
+		require.Contains(t, ids, ra.OldReviewerID)
+		require.NotEmpty(t, ra.NewReviewerID)
+		require.NotContains(t, ids, ra.NewReviewerID)
+	}
+
+	resp = runRequest(t, client, http.MethodPost, server.URL+"/team/deactivate", map[string]any{"team_name": "missing"}, http.StatusNotFound)
+	assertErrorCode(t, resp, "NOT_FOUND")
+}
+
 func TestUserHandlers(t *testing.T) {
 	server, cleanup := startTestServer(t)
 	defer cleanup()
@@ -106,6 +229,11 @@ func TestUserHandlers(t *testing.T) {
 			body:   map[string]any{"user_id": "missing", "is_active": true},
 			status: http.StatusNotFound,
 		},
+		{
+			name:   "deactivate with reassign",
+			body:   map[string]any{"user_id": "u2", "is_active": false, "reassign_reviews": true},
+			status: http.StatusOK,
+		},
 	}
 	for _, tt := range tests {
 		tt := tt
@@ -136,6 +264,18 @@ func TestPullRequestHandlers(t *testing.T) {
 	resp := runRequest(t, client, http.MethodPost, server.URL+"/pullRequest/create", createReq, http.StatusConflict)
 	assertErrorCode(t, resp, "PR_EXISTS")
 
+	reviewReq := map[string]any{"pull_request_id": "pr1", "reviewer_id": "u2", "decision": "APPROVED"}
+	runRequest(t, client, http.MethodPost, server.URL+"/pullRequest/review", reviewReq, http.StatusOK).Body.Close()
+	resp = runRequest(t, client, http.MethodPost, server.URL+"/pullRequest/review", map[string]any{"pull_request_id": "pr1", "reviewer_id": "u1", "decision": "APPROVED"}, http.StatusConflict)
+	assertErrorCode(t, resp, "NOT_ASSIGNED")
+	resp = runRequest(t, client, http.MethodGet, server.URL+"/users/getReview?user_id=u2&pending_only=true", nil, http.StatusOK)
+	var pending struct {
+		PullRequests []any `json:"pull_requests"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&pending))
+	resp.Body.Close()
+	require.Empty(t, pending.PullRequests)
+
 	mergeReq := map[string]any{"pull_request_id": "pr1"}
 	runRequest(t, client, http.MethodPost, server.URL+"/pullRequest/merge", mergeReq, http.StatusOK)
 	runRequest(t, client, http.MethodPost, server.URL+"/pullRequest/merge", mergeReq, http.StatusOK)
@@ -144,12 +284,103 @@ func TestPullRequestHandlers(t *testing.T) {
 	resp = runRequest(t, client, http.MethodPost, server.URL+"/pullRequest/reassign", reassignReq, http.StatusConflict)
 	assertErrorCode(t, resp, "PR_MERGED")
 
+	draftReq := map[string]any{"pull_request_id": "pr2", "pull_request_name": "Draft", "author_id": "u1", "draft": true}
+	runRequest(t, client, http.MethodPost, server.URL+"/pullRequest/create", draftReq, http.StatusCreated).Body.Close()
+	resp = runRequest(t, client, http.MethodPost, server.URL+"/pullRequest/merge", map[string]any{"pull_request_id": "pr2"}, http.StatusConflict)
+	assertErrorCode(t, resp, "PR_DRAFT")
+	runRequest(t, client, http.MethodPost, server.URL+"/pullRequest/ready", map[string]any{"pull_request_id": "pr2"}, http.StatusOK).Body.Close()
+	runRequest(t, client, http.MethodPost, server.URL+"/pullRequest/close", map[string]any{"pull_request_id": "pr2"}, http.StatusOK).Body.Close()
+	resp = runRequest(t, client, http.MethodPost, server.URL+"/pullRequest/ready", map[string]any{"pull_request_id": "pr2"}, http.StatusConflict)
+	assertErrorCode(t, resp, "PR_NOT_DRAFT")
+	runRequest(t, client, http.MethodPost, server.URL+"/pullRequest/reopen", map[string]any{"pull_request_id": "pr2"}, http.StatusOK).Body.Close()
+
+	resp = runRequest(t, client, http.MethodGet, server.URL+"/pullRequest/timeline?pull_request_id=pr1", nil, http.StatusOK)
+	var timeline struct {
+		Events []entity.PREvent `json:"events"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&timeline))
+	resp.Body.Close()
+	types := make([]string, 0, len(timeline.Events))
+	for _, ev := range timeline.Events {
+		types = append(types, ev.Type)
+	}
+	require.Equal(t, []string{entity.EventCreated, entity.EventReviewerAssigned, entity.EventReviewed, entity.EventMerged}, types)
+	resp = runRequest(t, client, http.MethodGet, server.URL+"/pullRequest/timeline?pull_request_id=missing", nil, http.StatusNotFound)
+	assertErrorCode(t, resp, "NOT_FOUND")
+
 	resp, err := client.Get(server.URL + "/pullRequest/stats")
 	require.NoError(t, err)
 	defer resp.Body.Close()
 	require.Equal(t, http.StatusOK, resp.StatusCode)
 }
 
+func TestCreateAtCapacityHandler(t *testing.T) {
+	server, cleanup := startTestServer(t)
+	defer cleanup()
+	client := &http.Client{Timeout: 5 * time.Second}
+
+	createTeam(client, server.URL)
+	runRequest(t, client, http.MethodPost, server.URL+"/users/setCapacity", map[string]any{"user_id": "u2", "review_capacity": 0}, http.StatusOK).Body.Close()
+
+	createReq := map[string]any{"pull_request_id": "pr1", "pull_request_name": "Add feature", "author_id": "u1"}
+	resp := runRequest(t, client, http.MethodPost, server.URL+"/pullRequest/create", createReq, http.StatusConflict)
+	assertErrorCode(t, resp, "ALL_AT_CAPACITY")
+	resp = runRequest(t, client, http.MethodPost, server.URL+"/pullRequest/preview", createReq, http.StatusConflict)
+	assertErrorCode(t, resp, "ALL_AT_CAPACITY")
+	runRequest(t, client, http.MethodGet, server.URL+"/pullRequest/get?pull_request_id=pr1", nil, http.StatusNotFound).Body.Close()
+}
+
+func TestGitHubWebhookHandler(t *testing.T) {
+	server, cleanup := startTestServer(t)
+	defer cleanup()
+	client := &http.Client{Timeout: 5 * time.Second}
+
+	createTeam(client, server.URL)
+	linkReq := map[string]any{"provider": "github", "login": "Alice", "user_id": "u1"}
+	runRequest(t, client, http.MethodPost, server.URL+"/integrations/accounts/link", linkReq, http.StatusOK).Body.Close()
+
+	send := func(deliveryID, secret string, payload map[string]any, status int) map[string]any {
+		body, _ := json.Marshal(payload)
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		req, _ := http.NewRequest(http.MethodPost, server.URL+"/integrations/github/webhook", bytes.NewReader(body))
+		req.Header.Set("X-GitHub-Event", "pull_request")
+		req.Header.Set("X-GitHub-Delivery", deliveryID)
+		req.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, status, resp.StatusCode)
+		var out map[string]any
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+		return out
+	}
+	opened := map[string]any{
+		"action":       "opened",
+		"pull_request": map[string]any{"number": 1, "title": "From GitHub", "user": map[string]any{"login": "alice"}},
+		"repository":   map[string]any{"full_name": "org/app"},
+	}
+	send("d1", "wrong", opened, http.StatusUnauthorized)
+	require.Equal(t, "processed", send("d1", githubSecret, opened, http.StatusOK)["status"])
+	require.Equal(t, "duplicate", send("d1", githubSecret, opened, http.StatusOK)["status"])
+
+	merged := map[string]any{
+		"action":       "closed",
+		"pull_request": map[string]any{"number": 1, "merged": true},
+		"repository":   map[string]any{"full_name": "org/app"},
+	}
+	send("d2", githubSecret, merged, http.StatusOK)
+
+	resp := runRequest(t, client, http.MethodGet, server.URL+"/pullRequest/timeline?pull_request_id=org/app%231", nil, http.StatusOK)
+	var timeline struct {
+		Events []entity.PREvent `json:"events"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&timeline))
+	resp.Body.Close()
+	require.Equal(t, entity.EventCreated, timeline.Events[0].Type)
+	require.Equal(t, entity.EventMerged, timeline.Events[len(timeline.Events)-1].Type)
+}
+
 func createTeam(client *http.Client, baseURL string) {
 	body := map[string]any{
 		"team_name": "backend",
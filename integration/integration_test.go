@@ -2,15 +2,22 @@ package integration
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
 	"testing"
 	"time"
 
+	"avito-internship-task/internal/absences"
 	"avito-internship-task/internal/db"
 	"avito-internship-task/internal/entity"
 	"avito-internship-task/internal/pullrequests"
 	"avito-internship-task/internal/teams"
 	"avito-internship-task/internal/users"
+	"avito-internship-task/internal/webhooks"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/stretchr/testify/require"
 	tc "github.com/testcontainers/testcontainers-go"
@@ -121,7 +128,7 @@ func TestPullRequestsRepositoryIntegration(t *testing.T) {
 		Assigned:        []string{"rev1", "rev2"},
 	}
 
-	require.NoError(t, prRepo.Create(ctx, pr))
+	require.NoError(t, prRepo.Create(ctx, pr, nil))
 
 	stored, err := prRepo.Get(ctx, "pr1")
 	require.NoError(t, err)
@@ -136,3 +143,100 @@ func TestPullRequestsRepositoryIntegration(t *testing.T) {
 	require.Equal(t, "MERGED", merged.Status)
 	require.NotNil(t, merged.MergedAt)
 }
+
+func TestAbsencesExcludeReviewersIntegration(t *testing.T) {
+	pool := setupPostgres(t)
+	ctx := context.Background()
+	teamRepo := teams.NewRepository(pool)
+	require.NoError(t, teamRepo.Create(ctx, entity.Team{
+		TeamName: "backend",
+		Members: []entity.TeamMember{
+			{UserID: "u1", Username: "Alice", IsActive: true},
+			{UserID: "u2", Username: "Bob", IsActive: true},
+			{UserID: "u3", Username: "Carol", IsActive: true},
+		},
+	}))
+
+	absenceRepo := absences.NewRepository(pool)
+	now := time.Now().UTC()
+	_, err := absenceRepo.Create(ctx, entity.Absence{UserID: "u2", StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour)})
+	require.NoError(t, err)
+	_, err = absenceRepo.Create(ctx, entity.Absence{UserID: "u3", StartsAt: now.Add(time.Hour), EndsAt: now.Add(2 * time.Hour)})
+	require.NoError(t, err)
+
+	prRepo := pullrequests.NewRepository(pool)
+	members, err := prRepo.GetActiveTeamMembers(ctx, "backend")
+	require.NoError(t, err)
+	ids := make([]string, 0, len(members))
+	for _, m := range members {
+		ids = append(ids, m.UserID)
+	}
+	require.ElementsMatch(t, []string{"u1", "u3"}, ids)
+}
+
+func TestWebhookDeliveryIntegration(t *testing.T) {
+	pool := setupPostgres(t)
+	ctx := context.Background()
+	teamRepo := teams.NewRepository(pool)
+	require.NoError(t, teamRepo.Create(ctx, entity.Team{
+		TeamName: "backend",
+		Members: []entity.TeamMember{
+			{UserID: "u1", Username: "Alice", IsActive: true},
+			{UserID: "u2", Username: "Bob", IsActive: true},
+		},
+	}))
+
+	var mu sync.Mutex
+	received := make([]entity.OutboxMessage, 0)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get(webhooks.HeaderSignature) != webhooks.Sign("s3cret", body) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var msg entity.OutboxMessage
+		if err := json.Unmarshal(body, &msg); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		received = append(received, msg)
+		mu.Unlock()
+	}))
+	defer receiver.Close()
+
+	webhookRepo := webhooks.NewRepository(pool)
+	webhookService := webhooks.NewService(webhookRepo)
+	wh, err := webhookService.Create(ctx, entity.Webhook{
+		URL:        receiver.URL,
+		Secret:     "s3cret",
+		EventTypes: []string{entity.WebhookPRCreated, entity.WebhookReviewerAssigned},
+	})
+	require.NoError(t, err)
+
+	prService := pullrequests.NewService(pullrequests.NewRepository(pool), pullrequests.Options{})
+	_, err = prService.Create(ctx, entity.PullRequest{PullRequestID: "pr1", PullRequestName: "Hook", AuthorID: "u1"})
+	require.NoError(t, err)
+	_, err = prService.Merge(ctx, "pr1")
+	require.NoError(t, err)
+
+	dispatcher := webhooks.NewDispatcher(webhookRepo, webhooks.DispatcherOptions{})
+	require.NoError(t, dispatcher.Tick(ctx))
+
+	mu.Lock()
+	events := make([]string, 0, len(received))
+	for _, msg := range received {
+		require.Equal(t, "pr1", msg.PullRequestID)
+		events = append(events, msg.Event)
+	}
+	mu.Unlock()
+	require.ElementsMatch(t, []string{entity.WebhookPRCreated, entity.WebhookReviewerAssigned}, events)
+
+	deliveries, err := webhookService.Deliveries(ctx, wh.WebhookID)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 2)
+	for _, d := range deliveries {
+		require.Equal(t, entity.DeliveryDelivered, d.Status)
+		require.Equal(t, 1, d.Attempts)
+	}
+}
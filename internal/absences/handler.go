@@ -0,0 +1,145 @@
+package absences
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"avito-internship-task/internal/entity"
+	"avito-internship-task/internal/httpserver"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) Register(mux *http.ServeMux) {
+	mux.Handle("/absences/add", httpserver.WithError(h.add))
+	mux.Handle("/absences/list", httpserver.WithError(h.list))
+	mux.Handle("/absences/delete", httpserver.WithError(h.delete))
+}
+
+type addRequest struct {
+	UserID   string    `json:"user_id"`
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+	Reason   string    `json:"reason"`
+}
+
+type deleteRequest struct {
+	AbsenceID int64 `json:"absence_id"`
+}
+
+type absenceEnvelope struct {
+	Absence entity.Absence `json:"absence"`
+}
+
+type listResponse struct {
+	UserID   string           `json:"user_id"`
+	Absences []entity.Absence `json:"absences"`
+}
+
+const (
+	codeBadRequest = "BAD_REQUEST"
+	codeNotFound   = "NOT_FOUND"
+)
+
+func (h *Handler) add(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		httpserver.RespondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return nil
+	}
+	var req addRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeAbsenceError(w, http.StatusBadRequest, codeBadRequest, "invalid json")
+		return nil
+	}
+	absence, err := h.service.Create(r.Context(), entity.Absence{
+		UserID:   req.UserID,
+		StartsAt: req.StartsAt,
+		EndsAt:   req.EndsAt,
+		Reason:   req.Reason,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidInput):
+			writeAbsenceError(w, http.StatusBadRequest, codeBadRequest, "user_id, starts_at and ends_at are required, ends_at must be after starts_at")
+			return nil
+		case errors.Is(err, ErrNotFound):
+			writeAbsenceError(w, http.StatusNotFound, codeNotFound, "user not found")
+			return nil
+		default:
+			return err
+		}
+	}
+	httpserver.RespondJSON(w, http.StatusCreated, absenceEnvelope{Absence: absence})
+	return nil
+}
+
+func (h *Handler) list(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodGet {
+		httpserver.RespondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return nil
+	}
+	userID := r.URL.Query().Get("user_id")
+	items, err := h.service.List(r.Context(), userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidInput):
+			writeAbsenceError(w, http.StatusBadRequest, codeBadRequest, "user_id is required")
+			return nil
+		case errors.Is(err, ErrNotFound):
+			writeAbsenceError(w, http.StatusNotFound, codeNotFound, "user not found")
+			return nil
+		default:
+			return err
+		}
+	}
+	httpserver.RespondJSON(w, http.StatusOK, listResponse{UserID: userID, Absences: items})
+	return nil
+}
+
+func (h *Handler) delete(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		httpserver.RespondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return nil
+	}
+	var req deleteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeAbsenceError(w, http.StatusBadRequest, codeBadRequest, "invalid json")
+		return nil
+	}
+	if err := h.service.Delete(r.Context(), req.AbsenceID); err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidInput):
+			writeAbsenceError(w, http.StatusBadRequest, codeBadRequest, "absence_id is required")
+			return nil
+		case errors.Is(err, ErrNotFound):
+			writeAbsenceError(w, http.StatusNotFound, codeNotFound, "absence not found")
+			return nil
+		default:
+			return err
+		}
+	}
+	httpserver.RespondJSON(w, http.StatusOK, map[string]int64{"absence_id": req.AbsenceID})
+	return nil
+}
+
+type errorEnvelope struct {
+	Error struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func writeAbsenceError(w http.ResponseWriter, status int, code, message string) {
+	var e errorEnvelope
+	e.Error.Code = code
+	e.Error.Message = message
+	httpserver.RespondJSON(w, status, e)
+}
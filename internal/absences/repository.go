@@ -0,0 +1,68 @@
+package absences
+
+import (
+	"context"
+
+	"avito-internship-task/internal/entity"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Repository struct {
+	db *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) *Repository {
+	return &Repository{db: db}
+}
+
+func (r *Repository) UserExists(ctx context.Context, userID string) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM users WHERE user_id = $1)`, userID).Scan(&exists)
+	return exists, err
+}
+
+func (r *Repository) Create(ctx context.Context, a entity.Absence) (entity.Absence, error) {
+	row := r.db.QueryRow(ctx, `
+INSERT INTO absences (user_id, starts_at, ends_at, reason)
+VALUES ($1, $2, $3, $4)
+RETURNING absence_id
+`, a.UserID, a.StartsAt, a.EndsAt, a.Reason)
+	if err := row.Scan(&a.AbsenceID); err != nil {
+		return entity.Absence{}, err
+	}
+	return a, nil
+}
+
+func (r *Repository) List(ctx context.Context, userID string) ([]entity.Absence, error) {
+	rows, err := r.db.Query(ctx, `
+SELECT absence_id, user_id, starts_at, ends_at, reason
+FROM absences WHERE user_id = $1
+ORDER BY starts_at
+`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := make([]entity.Absence, 0)
+	for rows.Next() {
+		var a entity.Absence
+		if err := rows.Scan(&a.AbsenceID, &a.UserID, &a.StartsAt, &a.EndsAt, &a.Reason); err != nil {
+			return nil, err
+		}
+		items = append(items, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (r *Repository) Delete(ctx context.Context, id int64) error {
+	var deleted int64
+	return r.db.QueryRow(ctx, `DELETE FROM absences WHERE absence_id = $1 RETURNING absence_id`, id).Scan(&deleted)
+}
+
+func isNotFound(err error) bool {
+	return err != nil && err == pgx.ErrNoRows
+}
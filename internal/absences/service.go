@@ -0,0 +1,78 @@
+package absences
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"avito-internship-task/internal/entity"
+)
+
+var (
+	ErrInvalidInput = errors.New("invalid input")
+	ErrNotFound     = errors.New("not found")
+)
+
+type Service struct {
+	repo Repo
+}
+
+type Repo interface {
+	UserExists(ctx context.Context, userID string) (bool, error)
+	Create(ctx context.Context, a entity.Absence) (entity.Absence, error)
+	List(ctx context.Context, userID string) ([]entity.Absence, error)
+	Delete(ctx context.Context, id int64) error
+}
+
+func NewService(repo Repo) *Service {
+	return &Service{repo: repo}
+}
+
+func (s *Service) Create(ctx context.Context, a entity.Absence) (entity.Absence, error) {
+	a.UserID = strings.TrimSpace(a.UserID)
+	a.Reason = strings.TrimSpace(a.Reason)
+	if a.UserID == "" || a.StartsAt.IsZero() || a.EndsAt.IsZero() || !a.EndsAt.After(a.StartsAt) {
+		return entity.Absence{}, ErrInvalidInput
+	}
+	if err := s.ensureUser(ctx, a.UserID); err != nil {
+		return entity.Absence{}, err
+	}
+	a.StartsAt = a.StartsAt.UTC()
+	a.EndsAt = a.EndsAt.UTC()
+	return s.repo.Create(ctx, a)
+}
+
+func (s *Service) List(ctx context.Context, userID string) ([]entity.Absence, error) {
+	userID = strings.TrimSpace(userID)
+	if userID == "" {
+		return nil, ErrInvalidInput
+	}
+	if err := s.ensureUser(ctx, userID); err != nil {
+		return nil, err
+	}
+	return s.repo.List(ctx, userID)
+}
+
+func (s *Service) Delete(ctx context.Context, id int64) error {
+	if id <= 0 {
+		return ErrInvalidInput
+	}
+	if err := s.repo.Delete(ctx, id); err != nil {
+		if isNotFound(err) {
+			return ErrNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *Service) ensureUser(ctx context.Context, userID string) error {
+	exists, err := s.repo.UserExists(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrNotFound
+	}
+	return nil
+}
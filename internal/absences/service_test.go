@@ -0,0 +1,109 @@
+package absences
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"avito-internship-task/internal/entity"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+)
+
+type absenceRepoStub struct {
+	users    map[string]struct{}
+	absences map[int64]entity.Absence
+	nextID   int64
+}
+
+func newAbsenceRepoStub() *absenceRepoStub {
+	return &absenceRepoStub{
+		users:    make(map[string]struct{}),
+		absences: make(map[int64]entity.Absence),
+	}
+}
+
+func (r *absenceRepoStub) UserExists(ctx context.Context, userID string) (bool, error) {
+	_, ok := r.users[userID]
+	return ok, nil
+}
+
+func (r *absenceRepoStub) Create(ctx context.Context, a entity.Absence) (entity.Absence, error) {
+	r.nextID++
+	a.AbsenceID = r.nextID
+	r.absences[a.AbsenceID] = a
+	return a, nil
+}
+
+func (r *absenceRepoStub) List(ctx context.Context, userID string) ([]entity.Absence, error) {
+	items := make([]entity.Absence, 0)
+	for _, a := range r.absences {
+		if a.UserID == userID {
+			items = append(items, a)
+		}
+	}
+	return items, nil
+}
+
+func (r *absenceRepoStub) Delete(ctx context.Context, id int64) error {
+	if _, ok := r.absences[id]; !ok {
+		return pgx.ErrNoRows
+	}
+	delete(r.absences, id)
+	return nil
+}
+
+func TestCreate(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(14 * 24 * time.Hour)
+
+	tests := []struct {
+		name    string
+		input   entity.Absence
+		wantErr error
+	}{
+		{name: "ok", input: entity.Absence{UserID: "u1", StartsAt: start, EndsAt: end, Reason: "vacation"}},
+		{name: "missing user_id", input: entity.Absence{StartsAt: start, EndsAt: end}, wantErr: ErrInvalidInput},
+		{name: "missing period", input: entity.Absence{UserID: "u1"}, wantErr: ErrInvalidInput},
+		{name: "reversed period", input: entity.Absence{UserID: "u1", StartsAt: end, EndsAt: start}, wantErr: ErrInvalidInput},
+		{name: "unknown user", input: entity.Absence{UserID: "missing", StartsAt: start, EndsAt: end}, wantErr: ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			repo := newAbsenceRepoStub()
+			repo.users["u1"] = struct{}{}
+			svc := NewService(repo)
+			a, err := svc.Create(ctx, tt.input)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.NotZero(t, a.AbsenceID)
+			items, err := svc.List(ctx, "u1")
+			require.NoError(t, err)
+			require.Len(t, items, 1)
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	ctx := context.Background()
+	repo := newAbsenceRepoStub()
+	repo.users["u1"] = struct{}{}
+	svc := NewService(repo)
+	start := time.Now()
+	a, err := svc.Create(ctx, entity.Absence{UserID: "u1", StartsAt: start, EndsAt: start.Add(time.Hour)})
+	require.NoError(t, err)
+
+	require.ErrorIs(t, svc.Delete(ctx, 0), ErrInvalidInput)
+	require.NoError(t, svc.Delete(ctx, a.AbsenceID))
+	require.ErrorIs(t, svc.Delete(ctx, a.AbsenceID), ErrNotFound)
+
+	_, err = svc.List(ctx, "missing")
+	require.ErrorIs(t, err, ErrNotFound)
+}
@@ -2,19 +2,33 @@ package app
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"sync"
+	"time"
 
+	"avito-internship-task/internal/absences"
+	"avito-internship-task/internal/codeowners"
 	"avito-internship-task/internal/config"
 	"avito-internship-task/internal/db"
+	"avito-internship-task/internal/entity"
 	"avito-internship-task/internal/httpserver"
+	"avito-internship-task/internal/integrations"
+	"avito-internship-task/internal/notifications"
 	"avito-internship-task/internal/pullrequests"
+	"avito-internship-task/internal/repositories"
 	"avito-internship-task/internal/teams"
 	"avito-internship-task/internal/users"
+	"avito-internship-task/internal/webhooks"
 )
 
 type App struct {
-	server *http.Server
-	pool   closable
+	server      *http.Server
+	pool        closable
+	background  []func(ctx context.Context)
+	workerCtx   context.Context
+	stopWorkers context.CancelFunc
+	workers     sync.WaitGroup
 }
 
 type closable interface {
@@ -22,22 +36,109 @@ type closable interface {
 }
 
 func New(ctx context.Context, cfg config.Config) (*App, error) {
+	if !pullrequests.IsKnownStrategy(cfg.ReviewerStrategy) {
+		return nil, fmt.Errorf("unknown reviewer strategy %q", cfg.ReviewerStrategy)
+	}
+
 	pool, err := db.Connect(ctx, cfg.DBURL)
 	if err != nil {
 		return nil, err
 	}
 
-	teamRepo := teams.NewRepository(pool)
-	teamService := teams.NewService(teamRepo)
-	teamHandler := teams.NewHandler(teamService)
+	codeOwnersService := codeowners.NewService(codeowners.NewRepository(pool))
+	codeOwnersHandler := codeowners.NewHandler(codeOwnersService)
 
 	userRepo := users.NewRepository(pool)
-	userService := users.NewService(userRepo)
-	userHandler := users.NewHandler(userService)
+	channels := map[string]notifications.Notifier{entity.ChannelLog: notifications.NewLogNotifier(nil)}
+	if cfg.SMTPAddr != "" {
+		channels[entity.ChannelEmail] = notifications.NewSMTPNotifier(notifications.SMTPOptions{
+			Addr:     cfg.SMTPAddr,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.SMTPFrom,
+			Timeout:  cfg.NotifyTimeout,
+		})
+	}
+	if cfg.ChatWebhookURL != "" {
+		channels[entity.ChannelChat] = notifications.NewChatNotifier(notifications.ChatOptions{
+			URL:     cfg.ChatWebhookURL,
+			Format:  cfg.ChatWebhookFormat,
+			Timeout: cfg.NotifyTimeout,
+		})
+	}
+	notifier := notifications.NewService(userRepo, channels)
+	notificationRepo := notifications.NewRepository(pool)
+	// a delivery goes over every channel of the recipient, each bounded by NotifyTimeout
+	sender := notifications.NewSender(notificationRepo, notifier, notifications.SenderOptions{
+		Interval:    cfg.NotifyPollInterval,
+		Timeout:     time.Duration(len(entity.NotificationChannels)) * cfg.NotifyTimeout,
+		MaxAttempts: cfg.NotifyMaxAttempts,
+	})
+	businessHours := pullrequests.BusinessHours{Start: cfg.BusinessHoursStart, End: cfg.BusinessHoursEnd, Location: cfg.BusinessHoursZone}
+	digests := notifications.NewDigests(notificationRepo, notifier, notifications.DigestOptions{
+		Hour:          cfg.DigestHour,
+		Interval:      cfg.DigestCheckInterval,
+		BusinessHours: businessHours,
+	})
 
 	prRepo := pullrequests.NewRepository(pool)
-	prService := pullrequests.NewService(prRepo)
+	prService := pullrequests.NewService(prRepo, pullrequests.Options{
+		Strategy:          cfg.ReviewerStrategy,
+		RequiredApprovals: cfg.RequiredApprovals,
+		CodeOwners:        codeOwnersService,
+	})
 	prHandler := pullrequests.NewHandler(prService)
+	slaWorker := pullrequests.NewSLAWorker(prService, prRepo, pullrequests.SLAOptions{
+		Interval:      cfg.SLACheckInterval,
+		BusinessHours: businessHours,
+	})
+
+	teamRepo := teams.NewRepository(pool)
+	teamService := teams.NewService(teamRepo, prService)
+	teamHandler := teams.NewHandler(teamService)
+
+	userService := users.NewService(userRepo, prService, users.Options{
+		ReassignOnDeactivate: cfg.ReassignOnDeactivate,
+		Digests:              digests,
+	})
+	userHandler := users.NewHandler(userService)
+
+	absenceRepo := absences.NewRepository(pool)
+	absenceService := absences.NewService(absenceRepo)
+	absenceHandler := absences.NewHandler(absenceService)
+
+	webhookRepo := webhooks.NewRepository(pool)
+	webhookService := webhooks.NewService(webhookRepo)
+	webhookHandler := webhooks.NewHandler(webhookService)
+	dispatcher := webhooks.NewDispatcher(webhookRepo, webhooks.DispatcherOptions{
+		Interval:    cfg.WebhookPollInterval,
+		Timeout:     cfg.WebhookTimeout,
+		MaxAttempts: cfg.WebhookMaxAttempts,
+	})
+
+	integrationRepo := integrations.NewRepository(pool)
+	integrationService := integrations.NewService(integrationRepo, prService, integrations.Options{
+		GitHubSecret: cfg.GitHubWebhookSecret,
+		GitLabToken:  cfg.GitLabWebhookToken,
+	})
+	integrationHandler := integrations.NewHandler(integrationService)
+
+	repositoryRepo := repositories.NewRepository(pool)
+	repositoryService := repositories.NewService(repositoryRepo)
+	repositoryHandler := repositories.NewHandler(repositoryService)
+
+	// without a token the syncer still runs to mark the outbox as synced
+	var github integrations.CodeHostClient
+	if cfg.GitHubToken != "" {
+		github = integrations.NewGitHubClient(cfg.GitHubAPIURL, cfg.GitHubToken, cfg.WebhookTimeout)
+	}
+	syncer := integrations.NewSyncer(integrationRepo, github, integrations.SyncOptions{
+		Interval:    cfg.CodeHostSyncInterval,
+		Timeout:     cfg.WebhookTimeout,
+		MaxAttempts: cfg.CodeHostMaxAttempts,
+	})
+
+	background := []func(ctx context.Context){dispatcher.Run, sender.Run, slaWorker.Run, digests.Run, syncer.Run}
 
 	mux := http.NewServeMux()
 	mux.Handle("/healthz", httpserver.WithError(func(w http.ResponseWriter, _ *http.Request) error {
@@ -47,23 +148,42 @@ func New(ctx context.Context, cfg config.Config) (*App, error) {
 	teamHandler.Register(mux)
 	userHandler.Register(mux)
 	prHandler.Register(mux)
+	absenceHandler.Register(mux)
+	webhookHandler.Register(mux)
+	integrationHandler.Register(mux)
+	codeOwnersHandler.Register(mux)
+	repositoryHandler.Register(mux)
 
 	server := &http.Server{
 		Addr:    cfg.HTTPAddr,
 		Handler: httpserver.Logging(mux),
 	}
 
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
 	return &App{
-		server: server,
-		pool:   pool,
+		server:      server,
+		pool:        pool,
+		background:  background,
+		workerCtx:   workerCtx,
+		stopWorkers: stopWorkers,
 	}, nil
 }
 
+// Run starts background workers and serves HTTP until the server is shut down.
 func (a *App) Run() error {
+	for _, run := range a.background {
+		a.workers.Add(1)
+		go func() {
+			defer a.workers.Done()
+			run(a.workerCtx)
+		}()
+	}
 	return a.server.ListenAndServe()
 }
 
 func (a *App) Shutdown(ctx context.Context) error {
+	a.stopWorkers()
+	a.workers.Wait()
 	if a.pool != nil {
 		a.pool.Close()
 	}
@@ -0,0 +1,126 @@
+package codeowners
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"avito-internship-task/internal/entity"
+	"avito-internship-task/internal/httpserver"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) Register(mux *http.ServeMux) {
+	mux.Handle("/codeowners/set", httpserver.WithError(h.set))
+	mux.Handle("/codeowners/get", httpserver.WithError(h.get))
+	mux.Handle("/codeowners/delete", httpserver.WithError(h.delete))
+}
+
+type setRequest struct {
+	Repository string `json:"repository"`
+	Content    string `json:"content"`
+}
+
+type deleteRequest struct {
+	Repository string `json:"repository"`
+}
+
+type codeOwnersEnvelope struct {
+	CodeOwners entity.CodeOwners `json:"codeowners"`
+}
+
+const (
+	codeBadRequest = "BAD_REQUEST"
+	codeNotFound   = "NOT_FOUND"
+)
+
+func (h *Handler) set(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		httpserver.RespondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return nil
+	}
+	var req setRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeCodeOwnersError(w, http.StatusBadRequest, codeBadRequest, "invalid json")
+		return nil
+	}
+	co, err := h.service.Set(r.Context(), req.Repository, req.Content)
+	if err != nil {
+		if errors.Is(err, ErrInvalidInput) {
+			writeCodeOwnersError(w, http.StatusBadRequest, codeBadRequest, "repository and valid CODEOWNERS content are required: "+err.Error())
+			return nil
+		}
+		return err
+	}
+	httpserver.RespondJSON(w, http.StatusOK, codeOwnersEnvelope{CodeOwners: co})
+	return nil
+}
+
+func (h *Handler) get(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodGet {
+		httpserver.RespondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return nil
+	}
+	co, err := h.service.Get(r.Context(), r.URL.Query().Get("repository"))
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidInput):
+			writeCodeOwnersError(w, http.StatusBadRequest, codeBadRequest, "repository is required")
+			return nil
+		case errors.Is(err, ErrNotFound):
+			writeCodeOwnersError(w, http.StatusNotFound, codeNotFound, "no CODEOWNERS stored for repository")
+			return nil
+		default:
+			return err
+		}
+	}
+	httpserver.RespondJSON(w, http.StatusOK, codeOwnersEnvelope{CodeOwners: co})
+	return nil
+}
+
+func (h *Handler) delete(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		httpserver.RespondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return nil
+	}
+	var req deleteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeCodeOwnersError(w, http.StatusBadRequest, codeBadRequest, "invalid json")
+		return nil
+	}
+	if err := h.service.Delete(r.Context(), req.Repository); err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidInput):
+			writeCodeOwnersError(w, http.StatusBadRequest, codeBadRequest, "repository is required")
+			return nil
+		case errors.Is(err, ErrNotFound):
+			writeCodeOwnersError(w, http.StatusNotFound, codeNotFound, "no CODEOWNERS stored for repository")
+			return nil
+		default:
+			return err
+		}
+	}
+	httpserver.RespondJSON(w, http.StatusOK, deleteRequest{Repository: req.Repository})
+	return nil
+}
+
+type errorEnvelope struct {
+	Error struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func writeCodeOwnersError(w http.ResponseWriter, status int, code, message string) {
+	var e errorEnvelope
+	e.Error.Code = code
+	e.Error.Message = message
+	httpserver.RespondJSON(w, status, e)
+}
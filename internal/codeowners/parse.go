@@ -0,0 +1,118 @@
+package codeowners
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Rule is a CODEOWNERS line: a gitignore-style pattern and the owners of matching paths.
+// A rule without owners takes ownership away from earlier rules.
+type Rule struct {
+	Pattern string
+	Owners  []string
+	re      *regexp.Regexp
+}
+
+// Ruleset is a parsed CODEOWNERS file, the last matching rule wins.
+type Ruleset struct {
+	rules []Rule
+}
+
+// Parse reads CODEOWNERS content. Blank lines and comments are skipped,
+// an owner starting with # ends the line.
+func Parse(content string) (Ruleset, error) {
+	var rs Ruleset
+	for n, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		re, err := compile(fields[0])
+		if err != nil {
+			return Ruleset{}, fmt.Errorf("%w: line %d: %v", ErrInvalidInput, n+1, err)
+		}
+		owners := make([]string, 0, len(fields)-1)
+		for _, owner := range fields[1:] {
+			if strings.HasPrefix(owner, "#") {
+				break
+			}
+			owners = append(owners, owner)
+		}
+		rs.rules = append(rs.rules, Rule{Pattern: fields[0], Owners: owners, re: re})
+	}
+	return rs, nil
+}
+
+// Owners returns the owners of path according to the last matching rule.
+func (rs Ruleset) Owners(path string) []string {
+	path = strings.TrimPrefix(path, "/")
+	for i := len(rs.rules) - 1; i >= 0; i-- {
+		if rs.rules[i].re.MatchString(path) {
+			return rs.rules[i].Owners
+		}
+	}
+	return nil
+}
+
+// compile translates a gitignore-style pattern into a regexp over slash-separated paths.
+// A pattern with a slash other than a trailing one is relative to the repository root,
+// otherwise it matches at any depth. A match on a directory covers everything below it,
+// a trailing slash matches directories only. Like in GitHub, "dir/*" covers only the
+// files directly in dir, not the nested ones.
+func compile(pattern string) (*regexp.Regexp, error) {
+	dirOnly := strings.HasSuffix(pattern, "/")
+	p := strings.TrimSuffix(pattern, "/")
+	anchored := strings.Contains(p, "/")
+	p = strings.TrimPrefix(p, "/")
+	if p == "" {
+		return nil, fmt.Errorf("empty pattern %q", pattern)
+	}
+
+	var b strings.Builder
+	if anchored {
+		b.WriteString("^")
+	} else {
+		b.WriteString("^(?:.*/)?")
+	}
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		switch {
+		case strings.HasPrefix(p[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(p[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(p[i+1:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated character class in %q", pattern)
+			}
+			class := p[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(p):
+			i++
+			b.WriteString(regexp.QuoteMeta(string(p[i])))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	switch {
+	case dirOnly:
+		b.WriteString("/.*$")
+	case strings.HasSuffix(p, "/*"):
+		b.WriteString("$")
+	default:
+		b.WriteString("(?:/.*)?$")
+	}
+	return regexp.Compile(b.String())
+}
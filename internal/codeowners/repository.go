@@ -0,0 +1,67 @@
+package codeowners
+
+import (
+	"context"
+
+	"avito-internship-task/internal/entity"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Repository struct {
+	db *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) *Repository {
+	return &Repository{db: db}
+}
+
+func (r *Repository) Set(ctx context.Context, co entity.CodeOwners) (entity.CodeOwners, error) {
+	row := r.db.QueryRow(ctx, `
+INSERT INTO codeowners (repository, content)
+VALUES ($1, $2)
+ON CONFLICT (repository) DO UPDATE SET content = EXCLUDED.content, updated_at = NOW()
+RETURNING updated_at
+`, co.Repository, co.Content)
+	if err := row.Scan(&co.UpdatedAt); err != nil {
+		return entity.CodeOwners{}, err
+	}
+	return co, nil
+}
+
+func (r *Repository) Get(ctx context.Context, repository string) (entity.CodeOwners, error) {
+	var co entity.CodeOwners
+	err := r.db.QueryRow(ctx, `
+SELECT repository, content, updated_at FROM codeowners WHERE repository = $1
+`, repository).Scan(&co.Repository, &co.Content, &co.UpdatedAt)
+	return co, err
+}
+
+func (r *Repository) Delete(ctx context.Context, repository string) error {
+	var deleted string
+	return r.db.QueryRow(ctx, `
+DELETE FROM codeowners WHERE repository = $1
+RETURNING repository
+`, repository).Scan(&deleted)
+}
+
+// ResolveOwners maps owner names to user ids. A name is a user_id or a linked
+// GitHub login, a team is a team name whose members all own the path.
+func (r *Repository) ResolveOwners(ctx context.Context, names, teams []string) ([]string, error) {
+	rows, err := r.db.Query(ctx, `
+SELECT user_id FROM users WHERE user_id = ANY($1)
+UNION
+SELECT user_id FROM code_host_accounts WHERE provider = $3 AND login IN (SELECT lower(n) FROM unnest($1::text[]) n)
+UNION
+SELECT user_id FROM users WHERE team_name = ANY($2)
+ORDER BY user_id
+`, names, teams, entity.ProviderGitHub)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
+
+func isNotFound(err error) bool {
+	return err != nil && err == pgx.ErrNoRows
+}
@@ -0,0 +1,122 @@
+package codeowners
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"avito-internship-task/internal/entity"
+)
+
+var (
+	ErrInvalidInput = errors.New("invalid input")
+	ErrNotFound     = errors.New("not found")
+)
+
+type Repo interface {
+	Set(ctx context.Context, co entity.CodeOwners) (entity.CodeOwners, error)
+	Get(ctx context.Context, repository string) (entity.CodeOwners, error)
+	Delete(ctx context.Context, repository string) error
+	ResolveOwners(ctx context.Context, names, teams []string) ([]string, error)
+}
+
+type Service struct {
+	repo Repo
+}
+
+func NewService(repo Repo) *Service {
+	return &Service{repo: repo}
+}
+
+// Set stores the CODEOWNERS content of a repository, content that does not parse is rejected.
+func (s *Service) Set(ctx context.Context, repository, content string) (entity.CodeOwners, error) {
+	repository = strings.TrimSpace(repository)
+	if repository == "" || strings.TrimSpace(content) == "" {
+		return entity.CodeOwners{}, ErrInvalidInput
+	}
+	if _, err := Parse(content); err != nil {
+		return entity.CodeOwners{}, err
+	}
+	return s.repo.Set(ctx, entity.CodeOwners{Repository: repository, Content: content})
+}
+
+func (s *Service) Get(ctx context.Context, repository string) (entity.CodeOwners, error) {
+	repository = strings.TrimSpace(repository)
+	if repository == "" {
+		return entity.CodeOwners{}, ErrInvalidInput
+	}
+	co, err := s.repo.Get(ctx, repository)
+	if err != nil {
+		if isNotFound(err) {
+			return entity.CodeOwners{}, ErrNotFound
+		}
+		return entity.CodeOwners{}, err
+	}
+	return co, nil
+}
+
+func (s *Service) Delete(ctx context.Context, repository string) error {
+	repository = strings.TrimSpace(repository)
+	if repository == "" {
+		return ErrInvalidInput
+	}
+	if err := s.repo.Delete(ctx, repository); err != nil {
+		if isNotFound(err) {
+			return ErrNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// Owners returns the user ids owning any of files. content takes precedence over
+// the CODEOWNERS stored for repository, a repository without one has no owners.
+// Owners are written as @user_id, @github-login or @org/team-name; e-mail owners
+// cannot be mapped to users and are ignored.
+func (s *Service) Owners(ctx context.Context, repository, content string, files []string) ([]string, error) {
+	if len(files) == 0 {
+		return nil, nil
+	}
+	repository = strings.TrimSpace(repository)
+	if strings.TrimSpace(content) == "" {
+		if repository == "" {
+			return nil, nil
+		}
+		co, err := s.repo.Get(ctx, repository)
+		if err != nil {
+			if isNotFound(err) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		content = co.Content
+	}
+	rs, err := Parse(content)
+	if err != nil {
+		return nil, err
+	}
+
+	var names, teams []string
+	seen := make(map[string]struct{})
+	for _, file := range files {
+		for _, owner := range rs.Owners(strings.TrimSpace(file)) {
+			if _, ok := seen[owner]; ok {
+				continue
+			}
+			seen[owner] = struct{}{}
+			name, ok := strings.CutPrefix(owner, "@")
+			if !ok {
+				continue
+			}
+			if _, team, ok := strings.Cut(name, "/"); ok {
+				teams = append(teams, team)
+				continue
+			}
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 && len(teams) == 0 {
+		return nil, nil
+	}
+	return s.repo.ResolveOwners(ctx, names, teams)
+}
@@ -0,0 +1,114 @@
+package codeowners
+
+import (
+	"context"
+	"testing"
+
+	"avito-internship-task/internal/entity"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+)
+
+type codeOwnersRepoStub struct {
+	stored map[string]entity.CodeOwners
+	names  []string
+	teams  []string
+}
+
+func (r *codeOwnersRepoStub) Set(ctx context.Context, co entity.CodeOwners) (entity.CodeOwners, error) {
+	r.stored[co.Repository] = co
+	return co, nil
+}
+
+func (r *codeOwnersRepoStub) Get(ctx context.Context, repository string) (entity.CodeOwners, error) {
+	co, ok := r.stored[repository]
+	if !ok {
+		return entity.CodeOwners{}, pgx.ErrNoRows
+	}
+	return co, nil
+}
+
+func (r *codeOwnersRepoStub) Delete(ctx context.Context, repository string) error {
+	if _, ok := r.stored[repository]; !ok {
+		return pgx.ErrNoRows
+	}
+	delete(r.stored, repository)
+	return nil
+}
+
+func (r *codeOwnersRepoStub) ResolveOwners(ctx context.Context, names, teams []string) ([]string, error) {
+	r.names, r.teams = names, teams
+	return append(append([]string{}, names...), teams...), nil
+}
+
+func TestParseOwners(t *testing.T) {
+	rs, err := Parse(`
+# default owners
+*                 @lead
+*.go              @gopher   # inline comment
+/docs/            @writer
+build/            @infra
+apps/**/config    @ops
+internal/db/*.sql @dba
+/scripts/*        @ops
+/vendor/
+`)
+	require.NoError(t, err)
+
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{path: "README.md", want: []string{"@lead"}},
+		{path: "main.go", want: []string{"@gopher"}},
+		{path: "internal/app/app.go", want: []string{"@gopher"}},
+		{path: "docs/api.md", want: []string{"@writer"}},
+		{path: "guide/docs/api.md", want: []string{"@lead"}},
+		{path: "build/Dockerfile", want: []string{"@infra"}},
+		{path: "tools/build/run.sh", want: []string{"@infra"}},
+		{path: "apps/config/app.yml", want: []string{"@ops"}},
+		{path: "apps/web/prod/config", want: []string{"@ops"}},
+		{path: "internal/db/0001.sql", want: []string{"@dba"}},
+		{path: "internal/db/migrations/0001.sql", want: []string{"@lead"}},
+		{path: "scripts/deploy.sh", want: []string{"@ops"}},
+		{path: "scripts/ci/lint.sh", want: []string{"@lead"}},
+		{path: "vendor/lib/lib.go", want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			require.Equal(t, tt.want, rs.Owners(tt.path))
+		})
+	}
+
+	_, err = Parse("src/[abc @dev")
+	require.ErrorIs(t, err, ErrInvalidInput)
+}
+
+func TestOwners(t *testing.T) {
+	ctx := context.Background()
+	repo := &codeOwnersRepoStub{stored: map[string]entity.CodeOwners{}}
+	svc := NewService(repo)
+
+	_, err := svc.Set(ctx, "acme/api", "* @org/backend\n/docs/ @Alice docs@example.com")
+	require.NoError(t, err)
+	_, err = svc.Set(ctx, "acme/api", "[oops @x")
+	require.ErrorIs(t, err, ErrInvalidInput)
+
+	owners, err := svc.Owners(ctx, "acme/api", "", []string{"docs/a.md", "main.go", "docs/b.md"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"Alice", "backend"}, owners)
+	require.Equal(t, []string{"Alice"}, repo.names)
+	require.Equal(t, []string{"backend"}, repo.teams)
+
+	owners, err = svc.Owners(ctx, "acme/api", "*.go @bob", []string{"main.go"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"bob"}, owners)
+
+	owners, err = svc.Owners(ctx, "acme/web", "", []string{"main.go"})
+	require.NoError(t, err)
+	require.Empty(t, owners)
+
+	require.NoError(t, svc.Delete(ctx, "acme/api"))
+	_, err = svc.Get(ctx, "acme/api")
+	require.ErrorIs(t, err, ErrNotFound)
+}
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS team_settings (
+    team_name TEXT PRIMARY KEY REFERENCES teams(name) ON DELETE CASCADE,
+    reviewer_count INT NOT NULL DEFAULT 2 CHECK (reviewer_count >= 0),
+    strategy TEXT NOT NULL DEFAULT '',
+    excluded_users TEXT[] NOT NULL DEFAULT '{}',
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,3 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS review_capacity INT CHECK (review_capacity >= 0);
+
+ALTER TABLE team_settings ADD COLUMN IF NOT EXISTS review_capacity INT CHECK (review_capacity >= 0);
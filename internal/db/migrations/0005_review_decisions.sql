@@ -0,0 +1,4 @@
+ALTER TABLE pr_reviewers ADD COLUMN IF NOT EXISTS review_state TEXT NOT NULL DEFAULT 'PENDING'
+    CHECK (review_state IN ('PENDING', 'APPROVED', 'CHANGES_REQUESTED', 'COMMENTED'));
+
+ALTER TABLE pr_reviewers ADD COLUMN IF NOT EXISTS reviewed_at TIMESTAMPTZ;
@@ -0,0 +1 @@
+ALTER TABLE team_settings ADD COLUMN IF NOT EXISTS required_approvals INT CHECK (required_approvals >= 0);
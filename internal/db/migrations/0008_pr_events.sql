@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS pr_events (
+    event_id BIGSERIAL PRIMARY KEY,
+    pull_request_id TEXT NOT NULL REFERENCES pull_requests(pull_request_id) ON DELETE CASCADE,
+    event_type TEXT NOT NULL,
+    actor_id TEXT,
+    reviewer_id TEXT,
+    old_reviewer_id TEXT,
+    from_status TEXT,
+    to_status TEXT,
+    reason TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_pr_events_pr ON pr_events (pull_request_id, event_id);
+
+CREATE OR REPLACE FUNCTION pr_events_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'pr_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS pr_events_no_update ON pr_events;
+DROP TRIGGER IF EXISTS pr_events_no_change ON pr_events;
+CREATE TRIGGER pr_events_no_change BEFORE UPDATE OR DELETE ON pr_events
+    FOR EACH ROW EXECUTE FUNCTION pr_events_append_only();
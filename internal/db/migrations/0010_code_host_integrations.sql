@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS code_host_accounts (
+    provider TEXT NOT NULL,
+    login TEXT NOT NULL,
+    user_id TEXT NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    PRIMARY KEY (provider, login)
+);
+
+CREATE INDEX IF NOT EXISTS idx_code_host_accounts_user ON code_host_accounts (user_id);
+
+CREATE TABLE IF NOT EXISTS inbound_deliveries (
+    provider TEXT NOT NULL,
+    delivery_id TEXT NOT NULL,
+    event TEXT NOT NULL,
+    received_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (provider, delivery_id)
+);
@@ -0,0 +1,21 @@
+-- rows written before the sync existed are backfilled as synced, new rows start unsynced
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS code_host_synced_at TIMESTAMPTZ DEFAULT NOW();
+ALTER TABLE outbox ALTER COLUMN code_host_synced_at DROP DEFAULT;
+
+CREATE INDEX IF NOT EXISTS idx_outbox_code_host_pending ON outbox (outbox_id) WHERE code_host_synced_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS code_host_calls (
+    call_id BIGSERIAL PRIMARY KEY,
+    provider TEXT NOT NULL,
+    pull_request_id TEXT NOT NULL,
+    action TEXT NOT NULL CHECK (action IN ('request', 'remove')),
+    reviewer_id TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'DONE', 'FAILED', 'SKIPPED')),
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    done_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_code_host_calls_pending ON code_host_calls (pull_request_id, call_id) WHERE status = 'PENDING';
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS codeowners (
+    repository TEXT PRIMARY KEY,
+    content TEXT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS code_owners TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS require_code_owner BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS repositories (
+    repository_id TEXT PRIMARY KEY,
+    owner_team TEXT NOT NULL REFERENCES teams(name),
+    secondary_teams TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_repositories_owner_team ON repositories (owner_team);
+
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS repository_id TEXT REFERENCES repositories(repository_id) ON DELETE SET NULL;
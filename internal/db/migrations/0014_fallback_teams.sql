@@ -0,0 +1,7 @@
+ALTER TABLE team_settings ADD COLUMN IF NOT EXISTS fallback_teams TEXT[] NOT NULL DEFAULT '{}';
+
+ALTER TABLE pr_reviewers ADD COLUMN IF NOT EXISTS team_name TEXT;
+
+UPDATE pr_reviewers r SET team_name = u.team_name
+FROM users u
+WHERE r.team_name IS NULL AND u.user_id = r.reviewer_id;
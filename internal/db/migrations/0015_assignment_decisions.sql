@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS assignment_decisions (
+    decision_id BIGSERIAL PRIMARY KEY,
+    pull_request_id TEXT NOT NULL REFERENCES pull_requests(pull_request_id) ON DELETE CASCADE,
+    decision JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_assignment_decisions_pr ON assignment_decisions (pull_request_id, decision_id);
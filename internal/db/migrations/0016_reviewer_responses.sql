@@ -0,0 +1,10 @@
+ALTER TABLE pr_reviewers ADD COLUMN IF NOT EXISTS response TEXT NOT NULL DEFAULT 'PENDING';
+ALTER TABLE pr_reviewers ADD COLUMN IF NOT EXISTS responded_at TIMESTAMPTZ;
+
+CREATE TABLE IF NOT EXISTS pr_declines (
+    pull_request_id TEXT NOT NULL REFERENCES pull_requests(pull_request_id) ON DELETE CASCADE,
+    reviewer_id TEXT NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    reason TEXT NOT NULL,
+    declined_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (pull_request_id, reviewer_id)
+);
@@ -0,0 +1,19 @@
+ALTER TABLE pr_reviewers ADD COLUMN IF NOT EXISTS assigned_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+
+ALTER TABLE team_settings ADD COLUMN IF NOT EXISTS sla_hours INT CHECK (sla_hours > 0);
+ALTER TABLE team_settings ADD COLUMN IF NOT EXISTS sla_policy TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS sla_escalations (
+    escalation_id BIGSERIAL PRIMARY KEY,
+    pull_request_id TEXT NOT NULL REFERENCES pull_requests(pull_request_id) ON DELETE CASCADE,
+    reviewer_id TEXT NOT NULL,
+    team_name TEXT NOT NULL,
+    policy TEXT NOT NULL,
+    assigned_at TIMESTAMPTZ NOT NULL,
+    new_reviewer_id TEXT,
+    error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (pull_request_id, reviewer_id, assigned_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_pr_reviewers_pending ON pr_reviewers (assigned_at) WHERE review_state = 'PENDING';
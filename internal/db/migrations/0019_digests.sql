@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS digest_deliveries (
+    user_id TEXT NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    sent_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, day)
+);
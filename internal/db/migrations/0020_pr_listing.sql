@@ -0,0 +1,6 @@
+CREATE INDEX IF NOT EXISTS idx_pull_requests_created ON pull_requests (created_at, pull_request_id);
+CREATE INDEX IF NOT EXISTS idx_pull_requests_merged ON pull_requests (merged_at, pull_request_id) WHERE merged_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_pull_requests_status_created ON pull_requests (status, created_at, pull_request_id);
+CREATE INDEX IF NOT EXISTS idx_pull_requests_author_created ON pull_requests (author_id, created_at, pull_request_id);
+CREATE INDEX IF NOT EXISTS idx_pr_reviewers_reviewer ON pr_reviewers (reviewer_id, pull_request_id);
+CREATE INDEX IF NOT EXISTS idx_users_team ON users (team_name);
@@ -0,0 +1,22 @@
+-- rows written before notifications moved to the outbox were notified inline, new rows start unnotified
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS notified_at TIMESTAMPTZ DEFAULT NOW();
+ALTER TABLE outbox ALTER COLUMN notified_at DROP DEFAULT;
+
+CREATE INDEX IF NOT EXISTS idx_outbox_notify_pending ON outbox (outbox_id) WHERE notified_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS notification_deliveries (
+    delivery_id BIGSERIAL PRIMARY KEY,
+    outbox_id BIGINT NOT NULL REFERENCES outbox(outbox_id) ON DELETE CASCADE,
+    recipient_id TEXT NOT NULL,
+    event_type TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    sent_channels TEXT[] NOT NULL DEFAULT '{}',
+    status TEXT NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'DELIVERED', 'FAILED')),
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    delivered_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_deliveries_due ON notification_deliveries (next_attempt_at) WHERE status = 'PENDING';
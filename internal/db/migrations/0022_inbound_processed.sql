@@ -0,0 +1,3 @@
+-- deliveries claimed before processed_at existed were applied right away, new claims start unprocessed
+ALTER TABLE inbound_deliveries ADD COLUMN IF NOT EXISTS processed_at TIMESTAMPTZ DEFAULT NOW();
+ALTER TABLE inbound_deliveries ALTER COLUMN processed_at DROP DEFAULT;
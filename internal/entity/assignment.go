@@ -0,0 +1,51 @@
+package entity
+
+import "time"
+
+// Triggers of a reviewer selection.
+const (
+	DecisionCreate     = "create"
+	DecisionReady      = "ready"
+	DecisionReopen     = "reopen"
+	DecisionReassign   = "reassign"
+	DecisionPreview    = "preview"
+	DecisionDecline    = "decline"
+	DecisionSLA        = "sla"
+	DecisionDeactivate = "deactivate"
+)
+
+// Reasons a team member was left out of the candidate pool.
+const (
+	ExcludedInactive   = "inactive"
+	ExcludedAuthor     = "author"
+	ExcludedAtCapacity = "at_capacity"
+	ExcludedAbsent     = "absent"
+	ExcludedBySettings = "excluded_by_settings"
+	ExcludedAssigned   = "already_assigned"
+	ExcludedDeclined   = "declined"
+)
+
+// AssignmentDecision records how reviewers of a PR were selected: the teams asked in
+// order, the candidates left after exclusions and the seed the strategy was run with,
+// so the same pick can be reproduced. ReplacedReviewerID is set for reassignments.
+type AssignmentDecision struct {
+	DecisionID         int64               `json:"decision_id"`
+	PullRequestID      string              `json:"pull_request_id"`
+	Trigger            string              `json:"trigger"`
+	Strategy           string              `json:"strategy"`
+	Seed               int64               `json:"seed"`
+	ReviewerCount      int                 `json:"reviewer_count"`
+	Teams              []string            `json:"teams"`
+	Candidates         []string            `json:"candidates"`
+	Excluded           []ExcludedCandidate `json:"excluded"`
+	Selected           []string            `json:"selected"`
+	ReplacedReviewerID string              `json:"replaced_reviewer_id,omitempty"`
+	CreatedAt          time.Time           `json:"created_at"`
+}
+
+// ExcludedCandidate is a team member that could not be picked and why.
+type ExcludedCandidate struct {
+	UserID string `json:"user_id"`
+	Team   string `json:"team"`
+	Reason string `json:"reason"`
+}
@@ -0,0 +1,16 @@
+package entity
+
+const (
+	ProviderGitHub = "github"
+	ProviderGitLab = "gitlab"
+)
+
+// CodeHostProviders lists the code hosts the service integrates with.
+var CodeHostProviders = []string{ProviderGitHub, ProviderGitLab}
+
+// CodeHostAccount links a login on a code host to a service user.
+type CodeHostAccount struct {
+	Provider string `json:"provider"`
+	Login    string `json:"login"`
+	UserID   string `json:"user_id"`
+}
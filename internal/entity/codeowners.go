@@ -0,0 +1,10 @@
+package entity
+
+import "time"
+
+// CodeOwners is the CODEOWNERS file stored for a repository.
+type CodeOwners struct {
+	Repository string    `json:"repository"`
+	Content    string    `json:"content"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
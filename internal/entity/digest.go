@@ -0,0 +1,30 @@
+package entity
+
+import "time"
+
+// Digest is the morning summary of a user: reviews they have to do, reviews of their own
+// PRs others have to do and those of both past the SLA. Text and HTML are its rendered forms.
+type Digest struct {
+	UserID         string       `json:"user_id"`
+	GeneratedAt    time.Time    `json:"generated_at"`
+	PendingReviews []DigestItem `json:"pending_reviews"`
+	AwaitingReview []DigestItem `json:"awaiting_review"`
+	PastSLA        []DigestItem `json:"past_sla"`
+	Text           string       `json:"text"`
+	HTML           string       `json:"html"`
+}
+
+// DigestItem is a pending review of an OPEN PR.
+type DigestItem struct {
+	PullRequestID   string    `json:"pull_request_id"`
+	PullRequestName string    `json:"pull_request_name"`
+	AuthorID        string    `json:"author_id"`
+	ReviewerID      string    `json:"reviewer_id"`
+	AssignedAt      time.Time `json:"assigned_at"`
+	PastSLA         bool      `json:"past_sla"`
+}
+
+// IsEmpty reports whether the digest has nothing to tell.
+func (d Digest) IsEmpty() bool {
+	return len(d.PendingReviews) == 0 && len(d.AwaitingReview) == 0
+}
@@ -0,0 +1,58 @@
+package entity
+
+// Events users can be notified about.
+const (
+	NotifyAssigned   = "assigned"
+	NotifyReassigned = "reassigned"
+	NotifyMerged     = "merged"
+	NotifyDigest     = "digest"
+	// NotifySLABreached tells a late reviewer and the PR author that the review is past the team SLA.
+	NotifySLABreached = "sla_breached"
+)
+
+// Channels notifications are delivered over.
+const (
+	ChannelEmail = "email"
+	ChannelChat  = "chat"
+	ChannelLog   = "log"
+)
+
+var (
+	NotificationEvents   = []string{NotifyAssigned, NotifyReassigned, NotifyMerged, NotifyDigest, NotifySLABreached}
+	NotificationChannels = []string{ChannelEmail, ChannelChat, ChannelLog}
+	// DefaultNotificationChannels are used for users without stored preferences.
+	DefaultNotificationChannels = []string{ChannelLog}
+)
+
+// Notification tells Recipient about a change of a PR they take part in. ReviewerID is the
+// assigned reviewer, OldReviewerID the one replaced, Reason explains a change not made
+// by the usual selection, e.g. "manual" or "sla". Digest is set for digest notifications only.
+type Notification struct {
+	Event           string    `json:"event"`
+	Recipient       Recipient `json:"recipient"`
+	PullRequestID   string    `json:"pull_request_id"`
+	PullRequestName string    `json:"pull_request_name"`
+	AuthorID        string    `json:"author_id"`
+	ReviewerID      string    `json:"reviewer_id,omitempty"`
+	OldReviewerID   string    `json:"old_reviewer_id,omitempty"`
+	Reason          string    `json:"reason,omitempty"`
+	Digest          *Digest   `json:"digest,omitempty"`
+}
+
+// Recipient is who a notification is for and how to reach them, contacts are filled
+// from the notification preferences of the user.
+type Recipient struct {
+	UserID     string `json:"user_id"`
+	Email      string `json:"email,omitempty"`
+	ChatHandle string `json:"chat_handle,omitempty"`
+}
+
+// NotificationPreferences select the channels and events a user is notified about,
+// empty Events means all of them.
+type NotificationPreferences struct {
+	UserID     string   `json:"user_id"`
+	Email      string   `json:"email"`
+	ChatHandle string   `json:"chat_handle"`
+	Channels   []string `json:"channels"`
+	Events     []string `json:"events"`
+}
@@ -9,11 +9,91 @@ type PullRequestShort struct {
 	Status          string `json:"status"`
 }
 
+// PullRequest is a PR with its reviewers. CodeOwners are the users owning the
+// changed files, RequireCodeOwner asks for at least one of them among the reviewers.
 type PullRequest struct {
-	PullRequestID   string     `json:"pull_request_id"`
-	PullRequestName string     `json:"pull_request_name"`
-	AuthorID        string     `json:"author_id"`
-	Status          string     `json:"status"`
-	Assigned        []string   `json:"assigned_reviewers"`
-	MergedAt        *time.Time `json:"mergedAt,omitempty"`
+	PullRequestID    string          `json:"pull_request_id"`
+	PullRequestName  string          `json:"pull_request_name"`
+	AuthorID         string          `json:"author_id"`
+	RepositoryID     string          `json:"repository_id,omitempty"`
+	Status           string          `json:"status"`
+	Assigned         []string        `json:"assigned_reviewers"`
+	Reviews          []ReviewerState `json:"reviewer_states"`
+	CodeOwners       []string        `json:"code_owners,omitempty"`
+	RequireCodeOwner bool            `json:"require_code_owner,omitempty"`
+	CreatedAt        *time.Time      `json:"createdAt,omitempty"`
+	MergedAt         *time.Time      `json:"mergedAt,omitempty"`
+	ClosedAt         *time.Time      `json:"closedAt,omitempty"`
+}
+
+const (
+	StatusDraft  = "DRAFT"
+	StatusOpen   = "OPEN"
+	StatusMerged = "MERGED"
+	StatusClosed = "CLOSED"
+)
+
+var PRStatuses = []string{StatusDraft, StatusOpen, StatusMerged, StatusClosed}
+
+const (
+	ReviewPending          = "PENDING"
+	ReviewApproved         = "APPROVED"
+	ReviewChangesRequested = "CHANGES_REQUESTED"
+	ReviewCommented        = "COMMENTED"
+)
+
+// Answers of a reviewer to the assignment itself.
+const (
+	ResponsePending  = "PENDING"
+	ResponseAccepted = "ACCEPTED"
+	ResponseDeclined = "DECLINED"
+)
+
+// ReviewerState is the latest review decision of an assigned reviewer.
+// Team is the team the reviewer was drawn from when assigned, Response tells
+// whether the reviewer has accepted the assignment yet.
+type ReviewerState struct {
+	ReviewerID  string     `json:"reviewer_id"`
+	State       string     `json:"state"`
+	ReviewedAt  *time.Time `json:"reviewed_at,omitempty"`
+	CodeOwner   bool       `json:"code_owner,omitempty"`
+	Team        string     `json:"team,omitempty"`
+	Response    string     `json:"response"`
+	RespondedAt *time.Time `json:"responded_at,omitempty"`
+	AssignedAt  *time.Time `json:"assigned_at,omitempty"`
+}
+
+// Reassignment describes a reviewer replaced on a PR. Empty NewReviewerID means
+// the reviewer was removed because no replacement was available, Error tells why.
+type Reassignment struct {
+	PullRequestID string `json:"pull_request_id"`
+	OldReviewerID string `json:"old_reviewer_id"`
+	NewReviewerID string `json:"new_reviewer_id,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
+
+const (
+	EventCreated          = "CREATED"
+	EventReviewerAssigned = "REVIEWER_ASSIGNED"
+	EventReviewerReplaced = "REVIEWER_REASSIGNED"
+	EventReviewerRemoved  = "REVIEWER_REMOVED"
+	EventReviewed         = "REVIEWED"
+	EventReviewerAccepted = "REVIEWER_ACCEPTED"
+	EventSLABreached      = "SLA_BREACHED"
+	EventStatusChanged    = "STATUS_CHANGED"
+	EventMerged           = "MERGED"
+)
+
+// PREvent is an entry of the PR timeline. Only the fields relevant to Type are set.
+type PREvent struct {
+	EventID       int64     `json:"event_id"`
+	PullRequestID string    `json:"pull_request_id"`
+	Type          string    `json:"type"`
+	ActorID       string    `json:"actor_id,omitempty"`
+	ReviewerID    string    `json:"reviewer_id,omitempty"`
+	OldReviewerID string    `json:"old_reviewer_id,omitempty"`
+	FromStatus    string    `json:"from_status,omitempty"`
+	ToStatus      string    `json:"to_status,omitempty"`
+	Reason        string    `json:"reason,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
 }
@@ -0,0 +1,12 @@
+package entity
+
+import "time"
+
+// Repository is a code repository owned by a team. Reviewers of its PRs come from
+// OwnerTeam, SecondaryTeams fill the remaining slots in the listed order.
+type Repository struct {
+	RepositoryID   string    `json:"repository_id"`
+	OwnerTeam      string    `json:"owner_team"`
+	SecondaryTeams []string  `json:"secondary_teams"`
+	CreatedAt      time.Time `json:"created_at"`
+}
@@ -0,0 +1,24 @@
+package entity
+
+import "time"
+
+// What happens when a reviewer does not start a review within the team SLA.
+const (
+	SLANotify      = "notify"
+	SLAAddReviewer = "add_reviewer"
+	SLAReassign    = "reassign"
+)
+
+// Escalation is a recorded SLA breach of a review assignment. NewReviewerID is the reviewer
+// added or put in place by the policy, Error explains why the policy could not be applied.
+type Escalation struct {
+	EscalationID  int64     `json:"escalation_id"`
+	PullRequestID string    `json:"pull_request_id"`
+	ReviewerID    string    `json:"reviewer_id"`
+	TeamName      string    `json:"team_name"`
+	Policy        string    `json:"policy"`
+	AssignedAt    time.Time `json:"assigned_at"`
+	NewReviewerID string    `json:"new_reviewer_id,omitempty"`
+	Error         string    `json:"error,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
@@ -10,3 +10,33 @@ type Team struct {
 	TeamName string       `json:"team_name"`
 	Members  []TeamMember `json:"members"`
 }
+
+const DefaultReviewerCount = 2
+
+// TeamSettings holds the reviewer assignment policy of a team.
+// Empty Strategy means the deployment default is used, nil ReviewCapacity means
+// members without a personal limit may take any number of OPEN reviews and nil
+// RequiredApprovals falls back to the deployment merge policy. FallbackTeams are
+// asked in order for reviewers when the team itself has too few candidates.
+// SLAHours is the number of business hours members have to start a review, nil
+// disables SLA tracking; SLAPolicy is applied on breach and defaults to notify.
+type TeamSettings struct {
+	TeamName          string   `json:"team_name"`
+	ReviewerCount     int      `json:"reviewer_count"`
+	Strategy          string   `json:"strategy"`
+	ExcludedUsers     []string `json:"excluded_users"`
+	ReviewCapacity    *int     `json:"review_capacity"`
+	RequiredApprovals *int     `json:"required_approvals"`
+	FallbackTeams     []string `json:"fallback_teams"`
+	SLAHours          *int     `json:"sla_hours"`
+	SLAPolicy         string   `json:"sla_policy"`
+}
+
+// IsKnownSLAPolicy reports whether policy can be set in team settings, empty means the default.
+func IsKnownSLAPolicy(policy string) bool {
+	switch policy {
+	case "", SLANotify, SLAAddReviewer, SLAReassign:
+		return true
+	}
+	return false
+}
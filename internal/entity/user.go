@@ -1,8 +1,17 @@
 package entity
 
+// User is a team member. ReviewCapacity limits concurrent OPEN reviews,
+// nil falls back to the team default.
 type User struct {
-	UserID   string `json:"user_id"`
-	Username string `json:"username"`
-	TeamName string `json:"team_name"`
-	IsActive bool   `json:"is_active"`
+	UserID         string `json:"user_id"`
+	Username       string `json:"username"`
+	TeamName       string `json:"team_name"`
+	IsActive       bool   `json:"is_active"`
+	ReviewCapacity *int   `json:"review_capacity,omitempty"`
+}
+
+// ReviewLoad is the current number of OPEN reviews of a user and the effective limit (nil is unlimited).
+type ReviewLoad struct {
+	OpenReviews    int  `json:"open_reviews"`
+	ReviewCapacity *int `json:"review_capacity"`
 }
@@ -0,0 +1,57 @@
+package entity
+
+import "time"
+
+const (
+	WebhookPRCreated          = "pr.created"
+	WebhookReviewerAssigned   = "reviewer.assigned"
+	WebhookReviewerReassigned = "reviewer.reassigned"
+	WebhookReviewerRemoved    = "reviewer.removed"
+	WebhookPRMerged           = "pr.merged"
+	WebhookReviewSLABreached  = "review.sla_breached"
+)
+
+// WebhookEventTypes lists the event types a webhook can subscribe to.
+var WebhookEventTypes = []string{WebhookPRCreated, WebhookReviewerAssigned, WebhookReviewerReassigned, WebhookReviewerRemoved, WebhookPRMerged, WebhookReviewSLABreached}
+
+const (
+	DeliveryPending   = "PENDING"
+	DeliveryDelivered = "DELIVERED"
+	DeliveryFailed    = "FAILED"
+)
+
+// Webhook is a subscription of an external URL to service events.
+// Secret is only returned when the webhook is created.
+type Webhook struct {
+	WebhookID  int64     `json:"webhook_id"`
+	URL        string    `json:"url"`
+	Secret     string    `json:"secret,omitempty"`
+	EventTypes []string  `json:"event_types"`
+	IsActive   bool      `json:"is_active"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type WebhookDelivery struct {
+	DeliveryID     int64      `json:"delivery_id"`
+	WebhookID      int64      `json:"webhook_id"`
+	EventType      string     `json:"event_type"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	LastStatusCode *int       `json:"last_status_code,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+}
+
+// OutboxMessage is the body sent to webhook receivers.
+type OutboxMessage struct {
+	Event         string    `json:"event"`
+	OccurredAt    time.Time `json:"occurred_at"`
+	PullRequestID string    `json:"pull_request_id"`
+	ActorID       string    `json:"actor_id,omitempty"`
+	ReviewerID    string    `json:"reviewer_id,omitempty"`
+	OldReviewerID string    `json:"old_reviewer_id,omitempty"`
+	Status        string    `json:"status,omitempty"`
+	Reason        string    `json:"reason,omitempty"`
+}
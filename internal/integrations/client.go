@@ -0,0 +1,120 @@
+package integrations
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CodeHostClient mirrors reviewer assignments on the code host a PR lives on.
+type CodeHostClient interface {
+	RequestReviewers(ctx context.Context, pr PullRequestRef, logins []string) error
+	RemoveRequestedReviewers(ctx context.Context, pr PullRequestRef, logins []string) error
+}
+
+// PullRequestRef addresses a PR on the code host.
+type PullRequestRef struct {
+	Owner  string
+	Repo   string
+	Number int
+}
+
+// ParseGitHubPullRequestID is the inverse of GitHubPullRequestID.
+func ParseGitHubPullRequestID(id string) (PullRequestRef, bool) {
+	repo, num, ok := strings.Cut(id, "#")
+	if !ok {
+		return PullRequestRef{}, false
+	}
+	owner, name, ok := strings.Cut(repo, "/")
+	if !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+		return PullRequestRef{}, false
+	}
+	number, err := strconv.Atoi(num)
+	if err != nil || number <= 0 {
+		return PullRequestRef{}, false
+	}
+	return PullRequestRef{Owner: owner, Repo: name, Number: number}, true
+}
+
+// PermanentError is a code host rejection that will not succeed on retry.
+type PermanentError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *PermanentError) Error() string {
+	return fmt.Sprintf("code host rejected the call with status %d: %s", e.StatusCode, e.Message)
+}
+
+func isPermanent(err error) bool {
+	var permanent *PermanentError
+	return errors.As(err, &permanent)
+}
+
+// GitHubClient calls the GitHub REST API. BaseURL is https://api.github.com
+// or the /api/v3 root of a GitHub Enterprise server.
+type GitHubClient struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+func NewGitHubClient(baseURL, token string, timeout time.Duration) *GitHubClient {
+	return &GitHubClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		http:    &http.Client{Timeout: timeout},
+	}
+}
+
+func (c *GitHubClient) RequestReviewers(ctx context.Context, pr PullRequestRef, logins []string) error {
+	return c.requestedReviewers(ctx, http.MethodPost, pr, logins)
+}
+
+func (c *GitHubClient) RemoveRequestedReviewers(ctx context.Context, pr PullRequestRef, logins []string) error {
+	return c.requestedReviewers(ctx, http.MethodDelete, pr, logins)
+}
+
+func (c *GitHubClient) requestedReviewers(ctx context.Context, method string, pr PullRequestRef, logins []string) error {
+	body, err := json.Marshal(map[string][]string{"reviewers": logins})
+	if err != nil {
+		return err
+	}
+	endpoint := fmt.Sprintf("%s/repos/%s/%s/pulls/%d/requested_reviewers",
+		c.baseURL, url.PathEscape(pr.Owner), url.PathEscape(pr.Repo), pr.Number)
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	// rate limits and timeouts are worth retrying, other client errors are not
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 &&
+		resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusRequestTimeout &&
+		resp.StatusCode != http.StatusForbidden {
+		return &PermanentError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(msg))}
+	}
+	return fmt.Errorf("github returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+}
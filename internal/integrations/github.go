@@ -0,0 +1,115 @@
+package integrations
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"avito-internship-task/internal/entity"
+)
+
+// GitHubDelivery is a raw webhook request from GitHub.
+type GitHubDelivery struct {
+	ID        string
+	Event     string
+	Signature string
+	Body      []byte
+}
+
+type githubPullRequestEvent struct {
+	Action      string `json:"action"`
+	PullRequest struct {
+		Number int    `json:"number"`
+		Title  string `json:"title"`
+		Draft  bool   `json:"draft"`
+		Merged bool   `json:"merged"`
+		User   struct {
+			Login string `json:"login"`
+		} `json:"user"`
+	} `json:"pull_request"`
+	Repository struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+}
+
+// GitHubPullRequestID is the pull_request_id used for a GitHub PR, e.g. "org/repo#42".
+func GitHubPullRequestID(repo string, number int) string {
+	return fmt.Sprintf("%s#%d", repo, number)
+}
+
+// HandleGitHub verifies a GitHub delivery and applies pull_request events:
+// opened creates the PR, closed merges or closes it, ready_for_review and
+// reopened move it back to OPEN. Other events are ignored.
+func (s *Service) HandleGitHub(ctx context.Context, d GitHubDelivery) (Result, error) {
+	if s.opts.GitHubSecret == "" {
+		return Result{}, ErrNotConfigured
+	}
+	if !validGitHubSignature(s.opts.GitHubSecret, d.Body, d.Signature) {
+		return Result{}, ErrBadSignature
+	}
+	d.ID = strings.TrimSpace(d.ID)
+	if d.ID == "" {
+		return Result{}, ErrInvalidInput
+	}
+	if d.Event != "pull_request" {
+		return Result{Status: ResultIgnored, Reason: "event " + d.Event}, nil
+	}
+	var ev githubPullRequestEvent
+	if err := json.Unmarshal(d.Body, &ev); err != nil {
+		return Result{}, ErrInvalidInput
+	}
+	if ev.Repository.FullName == "" || ev.PullRequest.Number <= 0 {
+		return Result{}, ErrInvalidInput
+	}
+
+	action, ok := githubAction(ev)
+	if !ok {
+		return Result{Status: ResultIgnored, PullRequestID: action.PullRequestID, Reason: "action " + ev.Action}, nil
+	}
+	return s.ingest(ctx, entity.ProviderGitHub, d.ID, d.Event+"."+ev.Action, func(ctx context.Context) (Result, error) {
+		return s.apply(ctx, entity.ProviderGitHub, action)
+	})
+}
+
+// githubAction translates a pull_request event, ok is false for actions that are not handled.
+func githubAction(ev githubPullRequestEvent) (prAction, bool) {
+	a := prAction{PullRequestID: GitHubPullRequestID(ev.Repository.FullName, ev.PullRequest.Number)}
+	switch ev.Action {
+	case "opened":
+		a.Kind = actionOpen
+		a.Title = ev.PullRequest.Title
+		a.Login = ev.PullRequest.User.Login
+		a.Draft = ev.PullRequest.Draft
+	case "ready_for_review":
+		a.Kind = actionReady
+	case "reopened":
+		a.Kind = actionReopen
+	case "closed":
+		a.Kind = actionClose
+		if ev.PullRequest.Merged {
+			a.Kind = actionMerge
+		}
+	default:
+		return a, false
+	}
+	return a, true
+}
+
+// validGitHubSignature checks X-Hub-Signature-256: "sha256=" and the hex HMAC-SHA256 of the body.
+func validGitHubSignature(secret string, body []byte, header string) bool {
+	sig, ok := strings.CutPrefix(header, "sha256=")
+	if !ok {
+		return false
+	}
+	got, err := hex.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
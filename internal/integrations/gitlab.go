@@ -0,0 +1,109 @@
+package integrations
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"avito-internship-task/internal/entity"
+)
+
+const gitlabMergeRequestEvent = "Merge Request Hook"
+
+// GitLabDelivery is a raw webhook request from GitLab.
+type GitLabDelivery struct {
+	ID    string
+	Event string
+	Token string
+	Body  []byte
+}
+
+type gitlabMergeRequestHook struct {
+	ObjectKind string `json:"object_kind"`
+	User       struct {
+		Username string `json:"username"`
+	} `json:"user"`
+	Project struct {
+		PathWithNamespace string `json:"path_with_namespace"`
+	} `json:"project"`
+	ObjectAttributes struct {
+		IID            int    `json:"iid"`
+		Title          string `json:"title"`
+		Action         string `json:"action"`
+		Draft          bool   `json:"draft"`
+		WorkInProgress bool   `json:"work_in_progress"`
+	} `json:"object_attributes"`
+	Changes struct {
+		Draft *struct {
+			Previous bool `json:"previous"`
+			Current  bool `json:"current"`
+		} `json:"draft"`
+	} `json:"changes"`
+}
+
+// GitLabPullRequestID is the pull_request_id used for a GitLab MR, e.g. "group/app!42".
+func GitLabPullRequestID(project string, iid int) string {
+	return fmt.Sprintf("%s!%d", project, iid)
+}
+
+// HandleGitLab checks the X-Gitlab-Token secret and applies Merge Request Hook actions:
+// open, reopen, merge, close, and update when it takes a draft out of draft.
+// The user who opened the MR is taken as its author.
+func (s *Service) HandleGitLab(ctx context.Context, d GitLabDelivery) (Result, error) {
+	if s.opts.GitLabToken == "" {
+		return Result{}, ErrNotConfigured
+	}
+	if subtle.ConstantTimeCompare([]byte(d.Token), []byte(s.opts.GitLabToken)) != 1 {
+		return Result{}, ErrBadSignature
+	}
+	if d.Event != gitlabMergeRequestEvent {
+		return Result{Status: ResultIgnored, Reason: "event " + d.Event}, nil
+	}
+	var hook gitlabMergeRequestHook
+	if err := json.Unmarshal(d.Body, &hook); err != nil {
+		return Result{}, ErrInvalidInput
+	}
+	if hook.ObjectKind != "merge_request" || hook.Project.PathWithNamespace == "" || hook.ObjectAttributes.IID <= 0 {
+		return Result{}, ErrInvalidInput
+	}
+
+	action, ok := gitlabAction(hook)
+	if !ok {
+		return Result{Status: ResultIgnored, PullRequestID: action.PullRequestID, Reason: "action " + hook.ObjectAttributes.Action}, nil
+	}
+	event := "merge_request." + hook.ObjectAttributes.Action
+	return s.ingest(ctx, entity.ProviderGitLab, strings.TrimSpace(d.ID), event, func(ctx context.Context) (Result, error) {
+		return s.apply(ctx, entity.ProviderGitLab, action)
+	})
+}
+
+// gitlabAction translates a Merge Request Hook, ok is false for actions that are not handled.
+// An update is only handled when it takes the MR out of draft.
+func gitlabAction(hook gitlabMergeRequestHook) (prAction, bool) {
+	attrs := hook.ObjectAttributes
+	a := prAction{PullRequestID: GitLabPullRequestID(hook.Project.PathWithNamespace, attrs.IID)}
+	switch attrs.Action {
+	case "open":
+		a.Kind = actionOpen
+		a.Title = attrs.Title
+		a.Login = hook.User.Username
+		a.Draft = attrs.Draft || attrs.WorkInProgress
+	case "update":
+		draft := hook.Changes.Draft
+		if draft == nil || !draft.Previous || draft.Current {
+			return a, false
+		}
+		a.Kind = actionReady
+	case "reopen":
+		a.Kind = actionReopen
+	case "merge":
+		a.Kind = actionMerge
+	case "close":
+		a.Kind = actionClose
+	default:
+		return a, false
+	}
+	return a, true
+}
@@ -0,0 +1,208 @@
+package integrations
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"avito-internship-task/internal/entity"
+	"avito-internship-task/internal/httpserver"
+	"avito-internship-task/internal/pullrequests"
+)
+
+// maxPayloadSize matches the largest webhook payload GitHub sends, GitLab payloads are smaller.
+const maxPayloadSize = 25 << 20
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) Register(mux *http.ServeMux) {
+	mux.Handle("/integrations/github/webhook", httpserver.WithError(h.github))
+	mux.Handle("/integrations/gitlab/webhook", httpserver.WithError(h.gitlab))
+	mux.Handle("/integrations/accounts/link", httpserver.WithError(h.link))
+	mux.Handle("/integrations/accounts/list", httpserver.WithError(h.list))
+	mux.Handle("/integrations/accounts/unlink", httpserver.WithError(h.unlink))
+}
+
+type unlinkRequest struct {
+	Provider string `json:"provider"`
+	Login    string `json:"login"`
+}
+
+type accountEnvelope struct {
+	Account entity.CodeHostAccount `json:"account"`
+}
+
+type accountsResponse struct {
+	Provider string                   `json:"provider"`
+	Accounts []entity.CodeHostAccount `json:"accounts"`
+}
+
+const (
+	codeBadRequest     = "BAD_REQUEST"
+	codeNotFound       = "NOT_FOUND"
+	codeNotConfigured  = "NOT_CONFIGURED"
+	codeBadSignature   = "INVALID_SIGNATURE"
+	codeUnknownAuthor  = "UNKNOWN_AUTHOR"
+	codeStatusConflict = "STATUS_CONFLICT"
+	codeInProgress     = "IN_PROGRESS"
+)
+
+func (h *Handler) github(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		httpserver.RespondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return nil
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPayloadSize))
+	if err != nil {
+		writeIntegrationError(w, http.StatusBadRequest, codeBadRequest, "cannot read body")
+		return nil
+	}
+	res, err := h.service.HandleGitHub(r.Context(), GitHubDelivery{
+		ID:        r.Header.Get("X-GitHub-Delivery"),
+		Event:     r.Header.Get("X-GitHub-Event"),
+		Signature: r.Header.Get("X-Hub-Signature-256"),
+		Body:      body,
+	})
+	if err != nil {
+		return writeIngestError(w, err)
+	}
+	httpserver.RespondJSON(w, http.StatusOK, res)
+	return nil
+}
+
+func (h *Handler) gitlab(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		httpserver.RespondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return nil
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPayloadSize))
+	if err != nil {
+		writeIntegrationError(w, http.StatusBadRequest, codeBadRequest, "cannot read body")
+		return nil
+	}
+	res, err := h.service.HandleGitLab(r.Context(), GitLabDelivery{
+		ID:    r.Header.Get("X-Gitlab-Event-UUID"),
+		Event: r.Header.Get("X-Gitlab-Event"),
+		Token: r.Header.Get("X-Gitlab-Token"),
+		Body:  body,
+	})
+	if err != nil {
+		return writeIngestError(w, err)
+	}
+	httpserver.RespondJSON(w, http.StatusOK, res)
+	return nil
+}
+
+// writeIngestError maps webhook processing errors, unknown errors are returned to the caller.
+func writeIngestError(w http.ResponseWriter, err error) error {
+	switch {
+	case errors.Is(err, ErrNotConfigured):
+		writeIntegrationError(w, http.StatusServiceUnavailable, codeNotConfigured, "webhook secret is not configured")
+	case errors.Is(err, ErrBadSignature):
+		writeIntegrationError(w, http.StatusUnauthorized, codeBadSignature, "signature or token does not match")
+	case errors.Is(err, ErrInvalidInput):
+		writeIntegrationError(w, http.StatusBadRequest, codeBadRequest, "a valid pull request payload is required")
+	case errors.Is(err, ErrUnknownAuthor):
+		writeIntegrationError(w, http.StatusUnprocessableEntity, codeUnknownAuthor, "author login is not linked to an active user")
+	case errors.Is(err, ErrInProgress):
+		writeIntegrationError(w, http.StatusConflict, codeInProgress, "delivery is being processed, retry later")
+	case errors.Is(err, pullrequests.ErrMerged), errors.Is(err, pullrequests.ErrClosed),
+		errors.Is(err, pullrequests.ErrDraft), errors.Is(err, pullrequests.ErrStatusChanged):
+		writeIntegrationError(w, http.StatusConflict, codeStatusConflict, err.Error())
+	default:
+		return err
+	}
+	return nil
+}
+
+func (h *Handler) link(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		httpserver.RespondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return nil
+	}
+	var req entity.CodeHostAccount
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeIntegrationError(w, http.StatusBadRequest, codeBadRequest, "invalid json")
+		return nil
+	}
+	acc, err := h.service.LinkAccount(r.Context(), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidInput):
+			writeIntegrationError(w, http.StatusBadRequest, codeBadRequest, "known provider, login and user_id are required")
+			return nil
+		case errors.Is(err, ErrNotFound):
+			writeIntegrationError(w, http.StatusNotFound, codeNotFound, "user not found")
+			return nil
+		default:
+			return err
+		}
+	}
+	httpserver.RespondJSON(w, http.StatusOK, accountEnvelope{Account: acc})
+	return nil
+}
+
+func (h *Handler) list(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodGet {
+		httpserver.RespondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return nil
+	}
+	provider := r.URL.Query().Get("provider")
+	items, err := h.service.Accounts(r.Context(), provider)
+	if err != nil {
+		if errors.Is(err, ErrInvalidInput) {
+			writeIntegrationError(w, http.StatusBadRequest, codeBadRequest, "known provider is required")
+			return nil
+		}
+		return err
+	}
+	httpserver.RespondJSON(w, http.StatusOK, accountsResponse{Provider: provider, Accounts: items})
+	return nil
+}
+
+func (h *Handler) unlink(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		httpserver.RespondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return nil
+	}
+	var req unlinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeIntegrationError(w, http.StatusBadRequest, codeBadRequest, "invalid json")
+		return nil
+	}
+	if err := h.service.UnlinkAccount(r.Context(), req.Provider, req.Login); err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidInput):
+			writeIntegrationError(w, http.StatusBadRequest, codeBadRequest, "known provider and login are required")
+			return nil
+		case errors.Is(err, ErrNotFound):
+			writeIntegrationError(w, http.StatusNotFound, codeNotFound, "account not found")
+			return nil
+		default:
+			return err
+		}
+	}
+	httpserver.RespondJSON(w, http.StatusOK, unlinkRequest{Provider: req.Provider, Login: normalizeLogin(req.Login)})
+	return nil
+}
+
+type errorEnvelope struct {
+	Error struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func writeIntegrationError(w http.ResponseWriter, status int, code, message string) {
+	var e errorEnvelope
+	e.Error.Code = code
+	e.Error.Message = message
+	httpserver.RespondJSON(w, status, e)
+}
@@ -0,0 +1,246 @@
+package integrations
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"avito-internship-task/internal/entity"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Repository struct {
+	db *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) *Repository {
+	return &Repository{db: db}
+}
+
+func (r *Repository) UserExists(ctx context.Context, userID string) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM users WHERE user_id = $1)`, userID).Scan(&exists)
+	return exists, err
+}
+
+// LinkAccount creates or replaces the user a code host login belongs to.
+func (r *Repository) LinkAccount(ctx context.Context, acc entity.CodeHostAccount) error {
+	_, err := r.db.Exec(ctx, `
+INSERT INTO code_host_accounts (provider, login, user_id)
+VALUES ($1, $2, $3)
+ON CONFLICT (provider, login) DO UPDATE SET user_id = EXCLUDED.user_id
+`, acc.Provider, acc.Login, acc.UserID)
+	return err
+}
+
+func (r *Repository) UnlinkAccount(ctx context.Context, provider, login string) error {
+	var deleted string
+	return r.db.QueryRow(ctx, `
+DELETE FROM code_host_accounts WHERE provider = $1 AND login = $2
+RETURNING login
+`, provider, login).Scan(&deleted)
+}
+
+func (r *Repository) Accounts(ctx context.Context, provider string) ([]entity.CodeHostAccount, error) {
+	rows, err := r.db.Query(ctx, `
+SELECT provider, login, user_id
+FROM code_host_accounts WHERE provider = $1
+ORDER BY login
+`, provider)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := make([]entity.CodeHostAccount, 0)
+	for rows.Next() {
+		var acc entity.CodeHostAccount
+		if err := rows.Scan(&acc.Provider, &acc.Login, &acc.UserID); err != nil {
+			return nil, err
+		}
+		items = append(items, acc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (r *Repository) UserIDByLogin(ctx context.Context, provider, login string) (string, error) {
+	var userID string
+	err := r.db.QueryRow(ctx, `
+SELECT user_id FROM code_host_accounts WHERE provider = $1 AND login = $2
+`, provider, login).Scan(&userID)
+	return userID, err
+}
+
+// ClaimDelivery records an inbound delivery id. A delivery that was claimed but not processed
+// can be claimed again once its claim is older than lease, so a crashed attempt is retried.
+func (r *Repository) ClaimDelivery(ctx context.Context, provider, deliveryID, event string, lease time.Duration) (DeliveryClaim, error) {
+	var id string
+	err := r.db.QueryRow(ctx, `
+INSERT INTO inbound_deliveries (provider, delivery_id, event)
+VALUES ($1, $2, $3)
+ON CONFLICT (provider, delivery_id) DO UPDATE SET event = EXCLUDED.event, received_at = NOW()
+WHERE inbound_deliveries.processed_at IS NULL
+  AND inbound_deliveries.received_at <= NOW() - make_interval(secs => $4)
+RETURNING delivery_id
+`, provider, deliveryID, event, lease.Seconds()).Scan(&id)
+	if err == nil {
+		return ClaimAcquired, nil
+	}
+	if !isNotFound(err) {
+		return 0, err
+	}
+	var processed bool
+	if err := r.db.QueryRow(ctx, `
+SELECT processed_at IS NOT NULL FROM inbound_deliveries WHERE provider = $1 AND delivery_id = $2
+`, provider, deliveryID).Scan(&processed); err != nil {
+		if isNotFound(err) {
+			// released in between, the code host retries
+			return ClaimInProgress, nil
+		}
+		return 0, err
+	}
+	if processed {
+		return ClaimProcessed, nil
+	}
+	return ClaimInProgress, nil
+}
+
+// CompleteDelivery marks a claimed delivery as applied, redeliveries are duplicates from then on.
+func (r *Repository) CompleteDelivery(ctx context.Context, provider, deliveryID string) error {
+	_, err := r.db.Exec(ctx, `
+UPDATE inbound_deliveries SET processed_at = NOW() WHERE provider = $1 AND delivery_id = $2
+`, provider, deliveryID)
+	return err
+}
+
+// ReleaseDelivery forgets an unprocessed delivery so a redelivery of a failed event is processed again.
+func (r *Repository) ReleaseDelivery(ctx context.Context, provider, deliveryID string) error {
+	_, err := r.db.Exec(ctx, `
+DELETE FROM inbound_deliveries WHERE provider = $1 AND delivery_id = $2 AND processed_at IS NULL
+`, provider, deliveryID)
+	return err
+}
+
+// QueueCalls turns outbox messages not yet seen by the code host sync into calls planned by plan,
+// in the same transaction that marks the messages as synced. It returns the number of messages processed.
+func (r *Repository) QueueCalls(ctx context.Context, limit int, plan func(entity.OutboxMessage) []CallRequest) (int, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `
+SELECT outbox_id, payload::text FROM outbox
+WHERE code_host_synced_at IS NULL
+ORDER BY outbox_id
+LIMIT $1
+FOR UPDATE SKIP LOCKED
+`, limit)
+	if err != nil {
+		return 0, err
+	}
+	ids := make([]int64, 0)
+	messages := make([]entity.OutboxMessage, 0)
+	for rows.Next() {
+		var id int64
+		var payload string
+		if err := rows.Scan(&id, &payload); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		var msg entity.OutboxMessage
+		if err := json.Unmarshal([]byte(payload), &msg); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+		messages = append(messages, msg)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	batch := &pgx.Batch{}
+	for _, msg := range messages {
+		for _, call := range plan(msg) {
+			batch.Queue(`
+INSERT INTO code_host_calls (provider, pull_request_id, action, reviewer_id)
+VALUES ($1, $2, $3, $4)
+`, call.Provider, call.PullRequestID, call.Action, call.ReviewerID)
+		}
+	}
+	batch.Queue(`UPDATE outbox SET code_host_synced_at = NOW() WHERE outbox_id = ANY($1)`, ids)
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return len(ids), nil
+}
+
+// ClaimDueCalls picks pending calls that are due at now and leases them until now+lease.
+// A call is only picked once every earlier call for the same PR is finished, so a
+// reassignment never overtakes the assignment it undoes.
+func (r *Repository) ClaimDueCalls(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]Call, error) {
+	rows, err := r.db.Query(ctx, `
+UPDATE code_host_calls c
+SET next_attempt_at = $2
+WHERE c.call_id IN (
+    SELECT x.call_id FROM code_host_calls x
+    WHERE x.status = 'PENDING' AND x.next_attempt_at <= $1
+      AND NOT EXISTS (
+          SELECT 1 FROM code_host_calls p
+          WHERE p.pull_request_id = x.pull_request_id AND p.status = 'PENDING' AND p.call_id < x.call_id
+      )
+    ORDER BY x.call_id
+    LIMIT $3
+    FOR UPDATE OF x SKIP LOCKED
+)
+RETURNING c.call_id, c.provider, c.pull_request_id, c.action, c.reviewer_id, c.attempts,
+    COALESCE((
+        SELECT a.login FROM code_host_accounts a
+        WHERE a.provider = c.provider AND a.user_id = c.reviewer_id
+        ORDER BY a.login
+        LIMIT 1
+    ), '')
+`, now, now.Add(lease), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := make([]Call, 0)
+	for rows.Next() {
+		var c Call
+		if err := rows.Scan(&c.CallID, &c.Provider, &c.PullRequestID, &c.Action, &c.ReviewerID, &c.Attempts, &c.Login); err != nil {
+			return nil, err
+		}
+		items = append(items, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (r *Repository) RecordCall(ctx context.Context, res CallResult) error {
+	_, err := r.db.Exec(ctx, `
+UPDATE code_host_calls
+SET attempts = $2, status = $3, last_error = NULLIF($4, ''), next_attempt_at = $5, done_at = $6
+WHERE call_id = $1
+`, res.CallID, res.Attempts, res.Status, res.Error, res.NextAttemptAt, res.DoneAt)
+	return err
+}
+
+func isNotFound(err error) bool {
+	return err != nil && err == pgx.ErrNoRows
+}
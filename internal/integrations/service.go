@@ -0,0 +1,247 @@
+package integrations
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"avito-internship-task/internal/entity"
+	"avito-internship-task/internal/pullrequests"
+)
+
+var (
+	ErrInvalidInput  = errors.New("invalid input")
+	ErrNotFound      = errors.New("not found")
+	ErrNotConfigured = errors.New("integration is not configured")
+	ErrBadSignature  = errors.New("bad signature")
+	ErrUnknownAuthor = errors.New("pull request author is not a known active user")
+	ErrInProgress    = errors.New("delivery is being processed")
+)
+
+const (
+	ResultProcessed = "processed"
+	ResultDuplicate = "duplicate"
+	ResultIgnored   = "ignored"
+)
+
+// DeliveryClaim is the outcome of claiming an inbound delivery id.
+type DeliveryClaim int
+
+const (
+	ClaimAcquired DeliveryClaim = iota + 1
+	// ClaimProcessed means the delivery was applied before.
+	ClaimProcessed
+	// ClaimInProgress means another attempt holds a claim younger than the lease.
+	ClaimInProgress
+)
+
+// deliveryLease is how long a claim of an unprocessed delivery blocks redeliveries,
+// it outlives any request, so an older claim belongs to an attempt that died.
+const deliveryLease = time.Minute
+
+// Result tells the code host what was done with a delivery.
+type Result struct {
+	Status        string `json:"status"`
+	PullRequestID string `json:"pull_request_id,omitempty"`
+	Reason        string `json:"reason,omitempty"`
+}
+
+// PullRequests is the part of pullrequests.Service driven by code host events.
+type PullRequests interface {
+	Create(ctx context.Context, pr entity.PullRequest) (entity.PullRequest, error)
+	MarkReady(ctx context.Context, id string) (entity.PullRequest, error)
+	Reopen(ctx context.Context, id string) (entity.PullRequest, error)
+	Close(ctx context.Context, id string) (entity.PullRequest, error)
+	MarkMerged(ctx context.Context, id string) (entity.PullRequest, error)
+}
+
+type Repo interface {
+	UserExists(ctx context.Context, userID string) (bool, error)
+	LinkAccount(ctx context.Context, acc entity.CodeHostAccount) error
+	UnlinkAccount(ctx context.Context, provider, login string) error
+	Accounts(ctx context.Context, provider string) ([]entity.CodeHostAccount, error)
+	UserIDByLogin(ctx context.Context, provider, login string) (string, error)
+	ClaimDelivery(ctx context.Context, provider, deliveryID, event string, lease time.Duration) (DeliveryClaim, error)
+	CompleteDelivery(ctx context.Context, provider, deliveryID string) error
+	ReleaseDelivery(ctx context.Context, provider, deliveryID string) error
+}
+
+type Options struct {
+	GitHubSecret string
+	GitLabToken  string
+}
+
+type Service struct {
+	repo Repo
+	prs  PullRequests
+	opts Options
+}
+
+func NewService(repo Repo, prs PullRequests, opts Options) *Service {
+	return &Service{repo: repo, prs: prs, opts: opts}
+}
+
+func (s *Service) LinkAccount(ctx context.Context, acc entity.CodeHostAccount) (entity.CodeHostAccount, error) {
+	acc.Provider = strings.TrimSpace(acc.Provider)
+	acc.Login = normalizeLogin(acc.Login)
+	acc.UserID = strings.TrimSpace(acc.UserID)
+	if !knownProvider(acc.Provider) || acc.Login == "" || acc.UserID == "" {
+		return entity.CodeHostAccount{}, ErrInvalidInput
+	}
+	exists, err := s.repo.UserExists(ctx, acc.UserID)
+	if err != nil {
+		return entity.CodeHostAccount{}, err
+	}
+	if !exists {
+		return entity.CodeHostAccount{}, ErrNotFound
+	}
+	if err := s.repo.LinkAccount(ctx, acc); err != nil {
+		return entity.CodeHostAccount{}, err
+	}
+	return acc, nil
+}
+
+func (s *Service) UnlinkAccount(ctx context.Context, provider, login string) error {
+	provider = strings.TrimSpace(provider)
+	login = normalizeLogin(login)
+	if !knownProvider(provider) || login == "" {
+		return ErrInvalidInput
+	}
+	if err := s.repo.UnlinkAccount(ctx, provider, login); err != nil {
+		if isNotFound(err) {
+			return ErrNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *Service) Accounts(ctx context.Context, provider string) ([]entity.CodeHostAccount, error) {
+	provider = strings.TrimSpace(provider)
+	if !knownProvider(provider) {
+		return nil, ErrInvalidInput
+	}
+	return s.repo.Accounts(ctx, provider)
+}
+
+// ingest runs apply once per delivery id: the delivery is claimed first and marked as processed
+// only after apply succeeds. A failed delivery is released, so the code host can redeliver it
+// after the cause is fixed, and a claim left by an attempt that died expires after deliveryLease.
+// A redelivery arriving while another attempt holds the claim gets ErrInProgress and is retried.
+// Deliveries without an id are applied every time.
+func (s *Service) ingest(ctx context.Context, provider, deliveryID, event string, apply func(ctx context.Context) (Result, error)) (Result, error) {
+	if deliveryID == "" {
+		return apply(ctx)
+	}
+	claim, err := s.repo.ClaimDelivery(ctx, provider, deliveryID, event, deliveryLease)
+	if err != nil {
+		return Result{}, err
+	}
+	switch claim {
+	case ClaimProcessed:
+		return Result{Status: ResultDuplicate}, nil
+	case ClaimInProgress:
+		return Result{}, ErrInProgress
+	}
+	res, err := apply(ctx)
+	if err != nil {
+		if releaseErr := s.repo.ReleaseDelivery(context.WithoutCancel(ctx), provider, deliveryID); releaseErr != nil {
+			return Result{}, errors.Join(err, releaseErr)
+		}
+		return Result{}, err
+	}
+	// applying the event again after a lost completion is harmless, the service reports it as ignored
+	if err := s.repo.CompleteDelivery(context.WithoutCancel(ctx), provider, deliveryID); err != nil {
+		return Result{}, err
+	}
+	return res, nil
+}
+
+const (
+	actionOpen   = "open"
+	actionReady  = "ready"
+	actionReopen = "reopen"
+	actionMerge  = "merge"
+	actionClose  = "close"
+)
+
+// prAction is a code host event translated to a pull request operation.
+// Login and Title are only used when opening.
+type prAction struct {
+	Kind          string
+	PullRequestID string
+	Title         string
+	Login         string
+	Draft         bool
+}
+
+// apply runs a translated action against pullrequests.Service. Outcomes that mean
+// the service is already in the requested state are reported as ignored.
+func (s *Service) apply(ctx context.Context, provider string, a prAction) (Result, error) {
+	ignored := func(reason string) (Result, error) {
+		return Result{Status: ResultIgnored, PullRequestID: a.PullRequestID, Reason: reason}, nil
+	}
+	var err error
+	switch a.Kind {
+	case actionOpen:
+		var authorID string
+		authorID, err = s.authorID(ctx, provider, a.Login)
+		if err != nil {
+			return Result{}, err
+		}
+		pr := entity.PullRequest{PullRequestID: a.PullRequestID, PullRequestName: a.Title, AuthorID: authorID}
+		if a.Draft {
+			pr.Status = entity.StatusDraft
+		}
+		_, err = s.prs.Create(ctx, pr)
+		if errors.Is(err, pullrequests.ErrNotFound) {
+			return Result{}, ErrUnknownAuthor
+		}
+		if errors.Is(err, pullrequests.ErrExists) {
+			return ignored("pull request already exists")
+		}
+	case actionReady:
+		_, err = s.prs.MarkReady(ctx, a.PullRequestID)
+		if errors.Is(err, pullrequests.ErrNotDraft) {
+			return ignored("pull request is not a draft")
+		}
+	case actionReopen:
+		_, err = s.prs.Reopen(ctx, a.PullRequestID)
+		if errors.Is(err, pullrequests.ErrNotClosed) {
+			return ignored("pull request is not closed")
+		}
+	case actionMerge:
+		_, err = s.prs.MarkMerged(ctx, a.PullRequestID)
+	case actionClose:
+		_, err = s.prs.Close(ctx, a.PullRequestID)
+	}
+	if errors.Is(err, pullrequests.ErrNotFound) {
+		return ignored("unknown pull request")
+	}
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{Status: ResultProcessed, PullRequestID: a.PullRequestID}, nil
+}
+
+// authorID resolves the service user behind a code host login.
+func (s *Service) authorID(ctx context.Context, provider, login string) (string, error) {
+	userID, err := s.repo.UserIDByLogin(ctx, provider, normalizeLogin(login))
+	if err != nil {
+		if isNotFound(err) {
+			return "", ErrUnknownAuthor
+		}
+		return "", err
+	}
+	return userID, nil
+}
+
+func normalizeLogin(login string) string {
+	return strings.ToLower(strings.TrimSpace(login))
+}
+
+func knownProvider(provider string) bool {
+	return slices.Contains(entity.CodeHostProviders, provider)
+}
@@ -0,0 +1,347 @@
+package integrations
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"testing"
+	"time"
+
+	"avito-internship-task/internal/entity"
+	"avito-internship-task/internal/pullrequests"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+)
+
+type integrationRepoStub struct {
+	users      map[string]struct{}
+	accounts   map[string]string
+	deliveries map[string]*inboundDelivery
+	// completeErr fails CompleteDelivery, like a connection lost after apply
+	completeErr error
+}
+
+type inboundDelivery struct {
+	processed bool
+	// expired marks a claim older than the lease
+	expired bool
+}
+
+func newIntegrationRepoStub() *integrationRepoStub {
+	return &integrationRepoStub{
+		users:      make(map[string]struct{}),
+		accounts:   make(map[string]string),
+		deliveries: make(map[string]*inboundDelivery),
+	}
+}
+
+func (r *integrationRepoStub) UserExists(ctx context.Context, userID string) (bool, error) {
+	_, ok := r.users[userID]
+	return ok, nil
+}
+
+func (r *integrationRepoStub) LinkAccount(ctx context.Context, acc entity.CodeHostAccount) error {
+	r.accounts[acc.Provider+"/"+acc.Login] = acc.UserID
+	return nil
+}
+
+func (r *integrationRepoStub) UnlinkAccount(ctx context.Context, provider, login string) error {
+	if _, ok := r.accounts[provider+"/"+login]; !ok {
+		return pgx.ErrNoRows
+	}
+	delete(r.accounts, provider+"/"+login)
+	return nil
+}
+
+func (r *integrationRepoStub) Accounts(ctx context.Context, provider string) ([]entity.CodeHostAccount, error) {
+	return []entity.CodeHostAccount{}, nil
+}
+
+func (r *integrationRepoStub) UserIDByLogin(ctx context.Context, provider, login string) (string, error) {
+	userID, ok := r.accounts[provider+"/"+login]
+	if !ok {
+		return "", pgx.ErrNoRows
+	}
+	return userID, nil
+}
+
+func (r *integrationRepoStub) ClaimDelivery(ctx context.Context, provider, deliveryID, event string, lease time.Duration) (DeliveryClaim, error) {
+	key := provider + "/" + deliveryID
+	if d, ok := r.deliveries[key]; ok {
+		switch {
+		case d.processed:
+			return ClaimProcessed, nil
+		case !d.expired:
+			return ClaimInProgress, nil
+		}
+	}
+	r.deliveries[key] = &inboundDelivery{}
+	return ClaimAcquired, nil
+}
+
+func (r *integrationRepoStub) CompleteDelivery(ctx context.Context, provider, deliveryID string) error {
+	if r.completeErr != nil {
+		return r.completeErr
+	}
+	r.deliveries[provider+"/"+deliveryID].processed = true
+	return nil
+}
+
+func (r *integrationRepoStub) ReleaseDelivery(ctx context.Context, provider, deliveryID string) error {
+	if d, ok := r.deliveries[provider+"/"+deliveryID]; ok && !d.processed {
+		delete(r.deliveries, provider+"/"+deliveryID)
+	}
+	return nil
+}
+
+type prServiceStub struct {
+	prs   map[string]entity.PullRequest
+	calls []string
+}
+
+func newPRServiceStub() *prServiceStub {
+	return &prServiceStub{prs: make(map[string]entity.PullRequest)}
+}
+
+func (s *prServiceStub) Create(ctx context.Context, pr entity.PullRequest) (entity.PullRequest, error) {
+	s.calls = append(s.calls, "create")
+	if _, ok := s.prs[pr.PullRequestID]; ok {
+		return entity.PullRequest{}, pullrequests.ErrExists
+	}
+	if pr.Status == "" {
+		pr.Status = entity.StatusOpen
+	}
+	s.prs[pr.PullRequestID] = pr
+	return pr, nil
+}
+
+func (s *prServiceStub) setStatus(call, id, status string) (entity.PullRequest, error) {
+	s.calls = append(s.calls, call)
+	pr, ok := s.prs[id]
+	if !ok {
+		return entity.PullRequest{}, pullrequests.ErrNotFound
+	}
+	pr.Status = status
+	s.prs[id] = pr
+	return pr, nil
+}
+
+func (s *prServiceStub) MarkReady(ctx context.Context, id string) (entity.PullRequest, error) {
+	return s.setStatus("ready", id, entity.StatusOpen)
+}
+
+func (s *prServiceStub) Reopen(ctx context.Context, id string) (entity.PullRequest, error) {
+	return s.setStatus("reopen", id, entity.StatusOpen)
+}
+
+func (s *prServiceStub) Close(ctx context.Context, id string) (entity.PullRequest, error) {
+	return s.setStatus("close", id, entity.StatusClosed)
+}
+
+func (s *prServiceStub) MarkMerged(ctx context.Context, id string) (entity.PullRequest, error) {
+	return s.setStatus("merge", id, entity.StatusMerged)
+}
+
+func sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func githubDelivery(id, body string) GitHubDelivery {
+	return GitHubDelivery{ID: id, Event: "pull_request", Signature: sign("secret", []byte(body)), Body: []byte(body)}
+}
+
+const (
+	openedBody       = `{"action":"opened","pull_request":{"number":7,"title":"Add search","user":{"login":"Alice"}},"repository":{"full_name":"org/app"}}`
+	mergedBody       = `{"action":"closed","pull_request":{"number":7,"merged":true},"repository":{"full_name":"org/app"}}`
+	closedBody       = `{"action":"closed","pull_request":{"number":8,"merged":false},"repository":{"full_name":"org/app"}}`
+	unknownAuthorPR  = `{"action":"opened","pull_request":{"number":9,"title":"X","user":{"login":"mallory"}},"repository":{"full_name":"org/app"}}`
+	draftOpenedBody  = `{"action":"opened","pull_request":{"number":8,"title":"WIP","draft":true,"user":{"login":"alice"}},"repository":{"full_name":"org/app"}}`
+	labeledEventBody = `{"action":"labeled","pull_request":{"number":7},"repository":{"full_name":"org/app"}}`
+)
+
+func TestHandleGitHub(t *testing.T) {
+	ctx := context.Background()
+	repo := newIntegrationRepoStub()
+	repo.users["u1"] = struct{}{}
+	prs := newPRServiceStub()
+	svc := NewService(repo, prs, Options{GitHubSecret: "secret"})
+
+	_, err := svc.LinkAccount(ctx, entity.CodeHostAccount{Provider: entity.ProviderGitHub, Login: " alice ", UserID: "u1"})
+	require.NoError(t, err)
+
+	res, err := svc.HandleGitHub(ctx, githubDelivery("d1", openedBody))
+	require.NoError(t, err)
+	require.Equal(t, Result{Status: ResultProcessed, PullRequestID: "org/app#7"}, res)
+	require.Equal(t, "u1", prs.prs["org/app#7"].AuthorID)
+	require.Equal(t, "Add search", prs.prs["org/app#7"].PullRequestName)
+
+	res, err = svc.HandleGitHub(ctx, githubDelivery("d1", openedBody))
+	require.NoError(t, err)
+	require.Equal(t, ResultDuplicate, res.Status)
+	require.Equal(t, []string{"create"}, prs.calls)
+
+	res, err = svc.HandleGitHub(ctx, githubDelivery("d2", mergedBody))
+	require.NoError(t, err)
+	require.Equal(t, ResultProcessed, res.Status)
+	require.Equal(t, entity.StatusMerged, prs.prs["org/app#7"].Status)
+
+	_, err = svc.HandleGitHub(ctx, githubDelivery("d3", draftOpenedBody))
+	require.NoError(t, err)
+	require.Equal(t, entity.StatusDraft, prs.prs["org/app#8"].Status)
+	_, err = svc.HandleGitHub(ctx, githubDelivery("d4", closedBody))
+	require.NoError(t, err)
+	require.Equal(t, entity.StatusClosed, prs.prs["org/app#8"].Status)
+
+	res, err = svc.HandleGitHub(ctx, githubDelivery("d5", labeledEventBody))
+	require.NoError(t, err)
+	require.Equal(t, ResultIgnored, res.Status)
+
+	ping := GitHubDelivery{ID: "d6", Event: "ping", Signature: sign("secret", []byte(`{}`)), Body: []byte(`{}`)}
+	res, err = svc.HandleGitHub(ctx, ping)
+	require.NoError(t, err)
+	require.Equal(t, ResultIgnored, res.Status)
+}
+
+func TestHandleGitHubErrors(t *testing.T) {
+	ctx := context.Background()
+	repo := newIntegrationRepoStub()
+	prs := newPRServiceStub()
+	svc := NewService(repo, prs, Options{GitHubSecret: "secret"})
+
+	bad := githubDelivery("d1", openedBody)
+	bad.Signature = sign("other", bad.Body)
+	_, err := svc.HandleGitHub(ctx, bad)
+	require.ErrorIs(t, err, ErrBadSignature)
+
+	noPrefix := githubDelivery("d1", openedBody)
+	noPrefix.Signature = noPrefix.Signature[len("sha256="):]
+	_, err = svc.HandleGitHub(ctx, noPrefix)
+	require.ErrorIs(t, err, ErrBadSignature)
+
+	_, err = svc.HandleGitHub(ctx, githubDelivery("", openedBody))
+	require.ErrorIs(t, err, ErrInvalidInput)
+	_, err = svc.HandleGitHub(ctx, githubDelivery("d1", `{"action":"opened"}`))
+	require.ErrorIs(t, err, ErrInvalidInput)
+
+	// a failed delivery is released and succeeds once the login is linked
+	_, err = svc.HandleGitHub(ctx, githubDelivery("d2", unknownAuthorPR))
+	require.ErrorIs(t, err, ErrUnknownAuthor)
+	repo.accounts[entity.ProviderGitHub+"/mallory"] = "u9"
+	res, err := svc.HandleGitHub(ctx, githubDelivery("d2", unknownAuthorPR))
+	require.NoError(t, err)
+	require.Equal(t, ResultProcessed, res.Status)
+
+	_, err = NewService(repo, prs, Options{}).HandleGitHub(ctx, githubDelivery("d3", openedBody))
+	require.ErrorIs(t, err, ErrNotConfigured)
+}
+
+func TestIngestUnprocessedClaims(t *testing.T) {
+	ctx := context.Background()
+	repo := newIntegrationRepoStub()
+	repo.accounts[entity.ProviderGitHub+"/alice"] = "u1"
+	prs := newPRServiceStub()
+	svc := NewService(repo, prs, Options{GitHubSecret: "secret"})
+
+	// another attempt holds the claim, the code host has to retry instead of getting a duplicate
+	repo.deliveries[entity.ProviderGitHub+"/d1"] = &inboundDelivery{}
+	_, err := svc.HandleGitHub(ctx, githubDelivery("d1", openedBody))
+	require.ErrorIs(t, err, ErrInProgress)
+	require.Empty(t, prs.calls)
+
+	// the attempt died without releasing the claim, once it expires the event is applied
+	repo.deliveries[entity.ProviderGitHub+"/d1"].expired = true
+	res, err := svc.HandleGitHub(ctx, githubDelivery("d1", openedBody))
+	require.NoError(t, err)
+	require.Equal(t, ResultProcessed, res.Status)
+	require.True(t, repo.deliveries[entity.ProviderGitHub+"/d1"].processed)
+
+	// the completion is lost: the claim stays unprocessed and a later redelivery is applied again
+	repo.completeErr = errors.New("connection reset")
+	_, err = svc.HandleGitHub(ctx, githubDelivery("d2", mergedBody))
+	require.ErrorContains(t, err, "connection reset")
+	require.False(t, repo.deliveries[entity.ProviderGitHub+"/d2"].processed)
+	repo.completeErr = nil
+	repo.deliveries[entity.ProviderGitHub+"/d2"].expired = true
+	res, err = svc.HandleGitHub(ctx, githubDelivery("d2", mergedBody))
+	require.NoError(t, err)
+	require.Equal(t, ResultProcessed, res.Status)
+	require.Equal(t, []string{"create", "merge", "merge"}, prs.calls)
+}
+
+func gitlabDelivery(id, body string) GitLabDelivery {
+	return GitLabDelivery{ID: id, Event: "Merge Request Hook", Token: "token", Body: []byte(body)}
+}
+
+func TestHandleGitLab(t *testing.T) {
+	ctx := context.Background()
+	repo := newIntegrationRepoStub()
+	repo.accounts[entity.ProviderGitLab+"/bob"] = "u2"
+	prs := newPRServiceStub()
+	svc := NewService(repo, prs, Options{GitLabToken: "token"})
+
+	hook := func(action, attrs, changes string) string {
+		return `{"object_kind":"merge_request","user":{"username":"Bob"},"project":{"path_with_namespace":"group/app"},` +
+			`"object_attributes":{"iid":3,"title":"Fix","action":"` + action + `"` + attrs + `},"changes":{` + changes + `}}`
+	}
+
+	res, err := svc.HandleGitLab(ctx, gitlabDelivery("e1", hook("open", `,"draft":true`, "")))
+	require.NoError(t, err)
+	require.Equal(t, Result{Status: ResultProcessed, PullRequestID: "group/app!3"}, res)
+	require.Equal(t, "u2", prs.prs["group/app!3"].AuthorID)
+	require.Equal(t, entity.StatusDraft, prs.prs["group/app!3"].Status)
+
+	res, err = svc.HandleGitLab(ctx, gitlabDelivery("e1", hook("open", `,"draft":true`, "")))
+	require.NoError(t, err)
+	require.Equal(t, ResultDuplicate, res.Status)
+
+	// a title edit is not a lifecycle change
+	res, err = svc.HandleGitLab(ctx, gitlabDelivery("e2", hook("update", "", `"title":{"previous":"WIP","current":"Fix"}`)))
+	require.NoError(t, err)
+	require.Equal(t, ResultIgnored, res.Status)
+
+	res, err = svc.HandleGitLab(ctx, gitlabDelivery("e3", hook("update", "", `"draft":{"previous":true,"current":false}`)))
+	require.NoError(t, err)
+	require.Equal(t, ResultProcessed, res.Status)
+	require.Equal(t, entity.StatusOpen, prs.prs["group/app!3"].Status)
+
+	// deliveries without an event uuid are not deduplicated
+	for _, action := range []string{"close", "reopen", "merge"} {
+		_, err = svc.HandleGitLab(ctx, gitlabDelivery("", hook(action, "", "")))
+		require.NoError(t, err, action)
+	}
+	require.Equal(t, []string{"create", "ready", "close", "reopen", "merge"}, prs.calls)
+	require.Equal(t, entity.StatusMerged, prs.prs["group/app!3"].Status)
+
+	bad := gitlabDelivery("e4", hook("open", "", ""))
+	bad.Token = "wrong"
+	_, err = svc.HandleGitLab(ctx, bad)
+	require.ErrorIs(t, err, ErrBadSignature)
+	_, err = svc.HandleGitLab(ctx, gitlabDelivery("e5", `{"object_kind":"push"}`))
+	require.ErrorIs(t, err, ErrInvalidInput)
+	res, err = svc.HandleGitLab(ctx, GitLabDelivery{Event: "Push Hook", Token: "token", Body: []byte(`{}`)})
+	require.NoError(t, err)
+	require.Equal(t, ResultIgnored, res.Status)
+}
+
+func TestLinkAccount(t *testing.T) {
+	ctx := context.Background()
+	repo := newIntegrationRepoStub()
+	repo.users["u1"] = struct{}{}
+	svc := NewService(repo, newPRServiceStub(), Options{})
+
+	acc, err := svc.LinkAccount(ctx, entity.CodeHostAccount{Provider: "github", Login: "Alice", UserID: "u1"})
+	require.NoError(t, err)
+	require.Equal(t, "alice", acc.Login)
+
+	_, err = svc.LinkAccount(ctx, entity.CodeHostAccount{Provider: "bitbucket", Login: "alice", UserID: "u1"})
+	require.ErrorIs(t, err, ErrInvalidInput)
+	_, err = svc.LinkAccount(ctx, entity.CodeHostAccount{Provider: "github", Login: "bob", UserID: "missing"})
+	require.ErrorIs(t, err, ErrNotFound)
+
+	require.NoError(t, svc.UnlinkAccount(ctx, "github", "ALICE"))
+	require.ErrorIs(t, svc.UnlinkAccount(ctx, "github", "alice"), ErrNotFound)
+}
@@ -0,0 +1,241 @@
+package integrations
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"avito-internship-task/internal/entity"
+)
+
+const (
+	CallRequestReviewer = "request"
+	CallRemoveReviewer  = "remove"
+)
+
+const (
+	CallPending = "PENDING"
+	CallDone    = "DONE"
+	CallFailed  = "FAILED"
+	CallSkipped = "SKIPPED"
+)
+
+// CallRequest is a code host call planned from an outbox message.
+type CallRequest struct {
+	Provider      string
+	PullRequestID string
+	Action        string
+	ReviewerID    string
+}
+
+// Call is a claimed code host call, Login is empty when the reviewer has no linked account.
+type Call struct {
+	CallID        int64
+	Provider      string
+	PullRequestID string
+	Action        string
+	ReviewerID    string
+	Login         string
+	Attempts      int
+}
+
+// CallResult is the outcome of a single call attempt.
+type CallResult struct {
+	CallID        int64
+	Attempts      int
+	Status        string
+	Error         string
+	NextAttemptAt time.Time
+	DoneAt        *time.Time
+}
+
+// SyncRepo is the storage side of the syncer.
+type SyncRepo interface {
+	QueueCalls(ctx context.Context, limit int, plan func(entity.OutboxMessage) []CallRequest) (int, error)
+	ClaimDueCalls(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]Call, error)
+	RecordCall(ctx context.Context, res CallResult) error
+}
+
+type SyncOptions struct {
+	Interval    time.Duration
+	Timeout     time.Duration
+	MaxAttempts int
+	BatchSize   int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+}
+
+// Syncer mirrors reviewer assignments of GitHub PRs to GitHub requested reviewers.
+// Calls are queued from the outbox and retried with exponential backoff until
+// MaxAttempts is reached; rejections that cannot succeed fail immediately.
+// Without a client it only marks outbox messages as synced, so connecting a code
+// host later does not replay the assignments made before.
+type Syncer struct {
+	repo   SyncRepo
+	github CodeHostClient
+	opts   SyncOptions
+	now    func() time.Time
+}
+
+func NewSyncer(repo SyncRepo, github CodeHostClient, opts SyncOptions) *Syncer {
+	if opts.Interval <= 0 {
+		opts.Interval = time.Second
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 8
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+	if opts.BaseBackoff <= 0 {
+		opts.BaseBackoff = time.Second
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = time.Hour
+	}
+	return &Syncer{
+		repo:   repo,
+		github: github,
+		opts:   opts,
+		now:    func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// Run syncs every Interval until ctx is cancelled.
+func (s *Syncer) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.opts.Interval)
+	defer ticker.Stop()
+	for {
+		if err := s.Tick(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("code host sync: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Tick runs one sync round: queue calls for new outbox messages and make up to BatchSize due ones.
+// Calls are claimed one at a time, so each lease only has to cover a single request.
+func (s *Syncer) Tick(ctx context.Context) error {
+	if s.github == nil {
+		if _, err := s.repo.QueueCalls(ctx, s.opts.BatchSize, planNoCalls); err != nil {
+			return fmt.Errorf("skip outbox: %w", err)
+		}
+		return nil
+	}
+	if _, err := s.repo.QueueCalls(ctx, s.opts.BatchSize, planCalls); err != nil {
+		return fmt.Errorf("queue calls: %w", err)
+	}
+	for i := 0; i < s.opts.BatchSize; i++ {
+		// the lease outlives a request timeout so a slow code host is not called twice
+		calls, err := s.repo.ClaimDueCalls(ctx, s.now(), 2*s.opts.Timeout, 1)
+		if err != nil {
+			return fmt.Errorf("claim calls: %w", err)
+		}
+		if len(calls) == 0 {
+			return nil
+		}
+		call := calls[0]
+		res := s.call(ctx, call)
+		if err := ctx.Err(); err != nil {
+			// shutting down: the call did not finish, the lease expires and it is retried
+			return err
+		}
+		if err := s.repo.RecordCall(ctx, res); err != nil {
+			return fmt.Errorf("record call %d: %w", call.CallID, err)
+		}
+	}
+	return nil
+}
+
+func (s *Syncer) call(ctx context.Context, call Call) CallResult {
+	res := CallResult{CallID: call.CallID, Attempts: call.Attempts}
+	ref, ok := ParseGitHubPullRequestID(call.PullRequestID)
+	if !ok || call.Provider != entity.ProviderGitHub {
+		res.Status = CallSkipped
+		res.Error = "not a github pull request"
+		res.NextAttemptAt = s.now()
+		return res
+	}
+	if call.Login == "" {
+		res.Status = CallSkipped
+		res.Error = "reviewer has no linked github account"
+		res.NextAttemptAt = s.now()
+		return res
+	}
+
+	res.Attempts++
+	callCtx, cancel := context.WithTimeout(ctx, s.opts.Timeout)
+	defer cancel()
+	var err error
+	if call.Action == CallRemoveReviewer {
+		err = s.github.RemoveRequestedReviewers(callCtx, ref, []string{call.Login})
+	} else {
+		err = s.github.RequestReviewers(callCtx, ref, []string{call.Login})
+	}
+
+	now := s.now()
+	if err == nil {
+		res.Status = CallDone
+		res.NextAttemptAt = now
+		res.DoneAt = &now
+		return res
+	}
+	res.Error = err.Error()
+	if isPermanent(err) || res.Attempts >= s.opts.MaxAttempts {
+		res.Status = CallFailed
+		res.NextAttemptAt = now
+		return res
+	}
+	res.Status = CallPending
+	res.NextAttemptAt = now.Add(s.backoff(res.Attempts))
+	return res
+}
+
+// backoff returns the delay before the next attempt: BaseBackoff doubled per failed attempt, capped at MaxBackoff.
+func (s *Syncer) backoff(attempts int) time.Duration {
+	delay := s.opts.BaseBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= s.opts.MaxBackoff {
+			return s.opts.MaxBackoff
+		}
+	}
+	return delay
+}
+
+// planCalls maps reviewer changes on GitHub PRs to code host calls; a
+// reassignment withdraws the old request before making the new one.
+func planCalls(msg entity.OutboxMessage) []CallRequest {
+	if _, ok := ParseGitHubPullRequestID(msg.PullRequestID); !ok {
+		return nil
+	}
+	call := func(action, reviewerID string) CallRequest {
+		return CallRequest{
+			Provider:      entity.ProviderGitHub,
+			PullRequestID: msg.PullRequestID,
+			Action:        action,
+			ReviewerID:    reviewerID,
+		}
+	}
+	switch msg.Event {
+	case entity.WebhookReviewerAssigned:
+		return []CallRequest{call(CallRequestReviewer, msg.ReviewerID)}
+	case entity.WebhookReviewerReassigned:
+		return []CallRequest{call(CallRemoveReviewer, msg.OldReviewerID), call(CallRequestReviewer, msg.ReviewerID)}
+	case entity.WebhookReviewerRemoved:
+		return []CallRequest{call(CallRemoveReviewer, msg.OldReviewerID)}
+	}
+	return nil
+}
+
+func planNoCalls(entity.OutboxMessage) []CallRequest {
+	return nil
+}
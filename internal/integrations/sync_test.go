@@ -0,0 +1,241 @@
+package integrations
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"avito-internship-task/internal/entity"
+	"github.com/stretchr/testify/require"
+)
+
+type syncRepoStub struct {
+	messages []entity.OutboxMessage
+	queued   []CallRequest
+	calls    []Call
+	results  []CallResult
+	claims   int
+}
+
+func (r *syncRepoStub) QueueCalls(ctx context.Context, limit int, plan func(entity.OutboxMessage) []CallRequest) (int, error) {
+	for _, msg := range r.messages {
+		r.queued = append(r.queued, plan(msg)...)
+	}
+	n := len(r.messages)
+	r.messages = nil
+	return n, nil
+}
+
+func (r *syncRepoStub) ClaimDueCalls(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]Call, error) {
+	r.claims++
+	claimed := r.calls[:min(limit, len(r.calls))]
+	r.calls = r.calls[len(claimed):]
+	return claimed, nil
+}
+
+func (r *syncRepoStub) RecordCall(ctx context.Context, res CallResult) error {
+	r.results = append(r.results, res)
+	return nil
+}
+
+type githubRequest struct {
+	Method    string
+	Path      string
+	Auth      string
+	Reviewers []string
+}
+
+// fakeGitHub answers requested_reviewers calls with the given statuses in turn, then 201.
+func fakeGitHub(t *testing.T, statuses ...int) (*httptest.Server, *[]githubRequest) {
+	t.Helper()
+	var requests []githubRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Reviewers []string `json:"reviewers"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		requests = append(requests, githubRequest{
+			Method:    r.Method,
+			Path:      r.URL.Path,
+			Auth:      r.Header.Get("Authorization"),
+			Reviewers: body.Reviewers,
+		})
+		status := http.StatusCreated
+		if len(statuses) > 0 {
+			status, statuses = statuses[0], statuses[1:]
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"message":"test"}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func TestParseGitHubPullRequestID(t *testing.T) {
+	ref, ok := ParseGitHubPullRequestID(GitHubPullRequestID("acme/api", 42))
+	require.True(t, ok)
+	require.Equal(t, PullRequestRef{Owner: "acme", Repo: "api", Number: 42}, ref)
+
+	for _, id := range []string{"pr-1", "acme/api#x", "acme#1", "acme/api#0", "group/sub/app#1", "group/app!3"} {
+		_, ok := ParseGitHubPullRequestID(id)
+		require.False(t, ok, id)
+	}
+}
+
+func TestGitHubClient(t *testing.T) {
+	srv, requests := fakeGitHub(t, http.StatusCreated, http.StatusOK, http.StatusUnprocessableEntity, http.StatusBadGateway)
+	client := NewGitHubClient(srv.URL+"/", "token", time.Second)
+	ref := PullRequestRef{Owner: "acme", Repo: "api", Number: 7}
+	ctx := context.Background()
+
+	require.NoError(t, client.RequestReviewers(ctx, ref, []string{"alice"}))
+	require.NoError(t, client.RemoveRequestedReviewers(ctx, ref, []string{"bob"}))
+	err := client.RequestReviewers(ctx, ref, []string{"carol"})
+	require.Error(t, err)
+	require.True(t, isPermanent(err))
+	err = client.RequestReviewers(ctx, ref, []string{"carol"})
+	require.Error(t, err)
+	require.False(t, isPermanent(err))
+
+	require.Len(t, *requests, 4)
+	first, second := (*requests)[0], (*requests)[1]
+	require.Equal(t, http.MethodPost, first.Method)
+	require.Equal(t, "/repos/acme/api/pulls/7/requested_reviewers", first.Path)
+	require.Equal(t, "Bearer token", first.Auth)
+	require.Equal(t, []string{"alice"}, first.Reviewers)
+	require.Equal(t, http.MethodDelete, second.Method)
+	require.Equal(t, []string{"bob"}, second.Reviewers)
+}
+
+func TestPlanCalls(t *testing.T) {
+	prID := GitHubPullRequestID("acme/api", 1)
+	cases := []struct {
+		name string
+		msg  entity.OutboxMessage
+		want []CallRequest
+	}{
+		{
+			name: "assigned",
+			msg:  entity.OutboxMessage{Event: entity.WebhookReviewerAssigned, PullRequestID: prID, ReviewerID: "u2"},
+			want: []CallRequest{{Provider: entity.ProviderGitHub, PullRequestID: prID, Action: CallRequestReviewer, ReviewerID: "u2"}},
+		},
+		{
+			name: "reassigned",
+			msg:  entity.OutboxMessage{Event: entity.WebhookReviewerReassigned, PullRequestID: prID, ReviewerID: "u3", OldReviewerID: "u2"},
+			want: []CallRequest{
+				{Provider: entity.ProviderGitHub, PullRequestID: prID, Action: CallRemoveReviewer, ReviewerID: "u2"},
+				{Provider: entity.ProviderGitHub, PullRequestID: prID, Action: CallRequestReviewer, ReviewerID: "u3"},
+			},
+		},
+		{
+			name: "removed",
+			msg:  entity.OutboxMessage{Event: entity.WebhookReviewerRemoved, PullRequestID: prID, OldReviewerID: "u2"},
+			want: []CallRequest{{Provider: entity.ProviderGitHub, PullRequestID: prID, Action: CallRemoveReviewer, ReviewerID: "u2"}},
+		},
+		{
+			name: "other topic",
+			msg:  entity.OutboxMessage{Event: entity.WebhookPRMerged, PullRequestID: prID},
+		},
+		{
+			name: "not a github pr",
+			msg:  entity.OutboxMessage{Event: entity.WebhookReviewerAssigned, PullRequestID: "pr-1", ReviewerID: "u2"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, planCalls(tc.msg))
+		})
+	}
+}
+
+func TestSyncerTick(t *testing.T) {
+	srv, requests := fakeGitHub(t, http.StatusServiceUnavailable, http.StatusNotFound)
+	now := time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC)
+	prID := GitHubPullRequestID("acme/api", 1)
+	repo := &syncRepoStub{
+		messages: []entity.OutboxMessage{{Event: entity.WebhookReviewerAssigned, PullRequestID: prID, ReviewerID: "u2"}},
+		calls: []Call{
+			{CallID: 1, Provider: entity.ProviderGitHub, PullRequestID: prID, Action: CallRequestReviewer, ReviewerID: "u2", Login: "bob"},
+			{CallID: 2, Provider: entity.ProviderGitHub, PullRequestID: prID, Action: CallRemoveReviewer, ReviewerID: "u3", Login: "carol", Attempts: 2},
+			{CallID: 3, Provider: entity.ProviderGitHub, PullRequestID: prID, Action: CallRequestReviewer, ReviewerID: "u4"},
+			{CallID: 4, Provider: entity.ProviderGitHub, PullRequestID: prID, Action: CallRequestReviewer, ReviewerID: "u5", Login: "dave"},
+		},
+	}
+	s := NewSyncer(repo, NewGitHubClient(srv.URL, "token", time.Second), SyncOptions{BaseBackoff: time.Second})
+	s.now = func() time.Time { return now }
+
+	require.NoError(t, s.Tick(context.Background()))
+
+	require.Len(t, repo.queued, 1)
+	require.Len(t, *requests, 3)
+	require.Len(t, repo.results, 4)
+
+	retried := repo.results[0]
+	require.Equal(t, CallPending, retried.Status)
+	require.Equal(t, 1, retried.Attempts)
+	require.Equal(t, now.Add(time.Second), retried.NextAttemptAt)
+
+	rejected := repo.results[1]
+	require.Equal(t, CallFailed, rejected.Status)
+	require.Equal(t, 3, rejected.Attempts)
+
+	unlinked := repo.results[2]
+	require.Equal(t, CallSkipped, unlinked.Status)
+	require.Equal(t, 0, unlinked.Attempts)
+
+	done := repo.results[3]
+	require.Equal(t, CallDone, done.Status)
+	require.NotNil(t, done.DoneAt)
+}
+
+func TestSyncerBatch(t *testing.T) {
+	srv, requests := fakeGitHub(t)
+	prID := GitHubPullRequestID("acme/api", 1)
+	repo := &syncRepoStub{}
+	for id := int64(1); id <= 3; id++ {
+		repo.calls = append(repo.calls, Call{CallID: id, Provider: entity.ProviderGitHub, PullRequestID: prID, Action: CallRequestReviewer, Login: "bob"})
+	}
+	s := NewSyncer(repo, NewGitHubClient(srv.URL, "token", time.Second), SyncOptions{BatchSize: 2})
+
+	require.NoError(t, s.Tick(context.Background()))
+	require.Equal(t, 2, repo.claims)
+	require.Len(t, *requests, 2)
+	require.Len(t, repo.results, 2)
+	require.Len(t, repo.calls, 1)
+}
+
+func TestSyncerStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cancel()
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	prID := GitHubPullRequestID("acme/api", 1)
+	repo := &syncRepoStub{calls: []Call{{CallID: 1, Provider: entity.ProviderGitHub, PullRequestID: prID, Action: CallRequestReviewer, Login: "bob"}}}
+	s := NewSyncer(repo, NewGitHubClient(srv.URL, "token", time.Second), SyncOptions{})
+
+	require.ErrorIs(t, s.Tick(ctx), context.Canceled)
+	require.Empty(t, repo.results)
+}
+
+func TestSyncerWithoutClient(t *testing.T) {
+	prID := GitHubPullRequestID("acme/api", 1)
+	repo := &syncRepoStub{
+		messages: []entity.OutboxMessage{{Event: entity.WebhookReviewerAssigned, PullRequestID: prID, ReviewerID: "u2"}},
+		calls:    []Call{{CallID: 1, Provider: entity.ProviderGitHub, PullRequestID: prID, Action: CallRequestReviewer, Login: "bob"}},
+	}
+	s := NewSyncer(repo, nil, SyncOptions{})
+
+	require.NoError(t, s.Tick(context.Background()))
+	require.Empty(t, repo.messages)
+	require.Empty(t, repo.queued)
+	require.Zero(t, repo.claims)
+	require.Empty(t, repo.results)
+}
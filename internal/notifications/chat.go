@@ -0,0 +1,80 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"avito-internship-task/internal/entity"
+)
+
+// Formats of chat incoming webhooks, they differ in how users are mentioned.
+const (
+	ChatSlack      = "slack"
+	ChatMattermost = "mattermost"
+)
+
+type ChatOptions struct {
+	// URL is the incoming webhook of the channel notifications are posted to.
+	URL     string
+	Format  string
+	Timeout time.Duration
+}
+
+// ChatNotifier posts notifications to a Slack or Mattermost incoming webhook,
+// mentioning the recipient by chat handle or, without one, naming them by user id.
+type ChatNotifier struct {
+	opts   ChatOptions
+	client *http.Client
+}
+
+type chatMessage struct {
+	Text string `json:"text"`
+}
+
+func NewChatNotifier(opts ChatOptions) *ChatNotifier {
+	if opts.Format != ChatMattermost {
+		opts.Format = ChatSlack
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Second
+	}
+	return &ChatNotifier{opts: opts, client: &http.Client{Timeout: opts.Timeout}}
+}
+
+func (n *ChatNotifier) Notify(ctx context.Context, notification entity.Notification) error {
+	_, text := render(notification)
+	body, err := json.Marshal(chatMessage{Text: n.mention(notification.Recipient) + ": " + text})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.opts.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("chat webhook responded with status %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+func (n *ChatNotifier) mention(to entity.Recipient) string {
+	if to.ChatHandle == "" {
+		return to.UserID
+	}
+	if n.opts.Format == ChatMattermost {
+		return "@" + to.ChatHandle
+	}
+	return "<@" + to.ChatHandle + ">"
+}
@@ -0,0 +1,169 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"log"
+	"text/template"
+	"time"
+
+	"avito-internship-task/internal/entity"
+	"avito-internship-task/internal/pullrequests"
+)
+
+//go:embed templates/digest.txt templates/digest.html
+var templates embed.FS
+
+var (
+	digestText = template.Must(template.ParseFS(templates, "templates/digest.txt"))
+	digestHTML = htmltemplate.Must(htmltemplate.ParseFS(templates, "templates/digest.html"))
+)
+
+// DigestEntry is a pending review of an OPEN PR with the SLA of the reviewer's team, nil without one.
+type DigestEntry struct {
+	entity.DigestItem
+	SLAHours *int
+}
+
+// DigestRepo is the storage side of digests.
+type DigestRepo interface {
+	// DigestEntries returns pending reviews on OPEN PRs the user reviews or authored, oldest first.
+	DigestEntries(ctx context.Context, userID string) ([]DigestEntry, error)
+	// DigestRecipients lists active users with something to report who got no digest for day yet.
+	DigestRecipients(ctx context.Context, day time.Time) ([]string, error)
+	// ClaimDigest records the digest of day for the user, false means it was already sent.
+	ClaimDigest(ctx context.Context, userID string, day time.Time) (bool, error)
+}
+
+type DigestOptions struct {
+	// Hour of the day digests are sent at, in the time zone of BusinessHours.
+	Hour     int
+	Interval time.Duration
+	// BusinessHours decide which reviews are past their SLA, as in the SLA worker,
+	// and the time zone digests are dated and rendered in.
+	BusinessHours pullrequests.BusinessHours
+}
+
+// Digests builds the daily review digest of users and sends it through their notification channels.
+type Digests struct {
+	repo     DigestRepo
+	notifier Notifier
+	opts     DigestOptions
+	now      func() time.Time
+}
+
+func NewDigests(repo DigestRepo, notifier Notifier, opts DigestOptions) *Digests {
+	if opts.Hour < 0 || opts.Hour > 23 {
+		opts.Hour = 9
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = time.Minute
+	}
+	if !opts.BusinessHours.Valid() {
+		opts.BusinessHours.Start, opts.BusinessHours.End = pullrequests.DefaultBusinessHours.Start, pullrequests.DefaultBusinessHours.End
+	}
+	return &Digests{
+		repo:     repo,
+		notifier: notifier,
+		opts:     opts,
+		now:      func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// Build collects and renders the digest of a user as of now.
+func (d *Digests) Build(ctx context.Context, userID string) (entity.Digest, error) {
+	loc := d.opts.BusinessHours.Loc()
+	now := d.now().In(loc)
+	entries, err := d.repo.DigestEntries(ctx, userID)
+	if err != nil {
+		return entity.Digest{}, err
+	}
+	digest := entity.Digest{
+		UserID:         userID,
+		GeneratedAt:    now,
+		PendingReviews: []entity.DigestItem{},
+		AwaitingReview: []entity.DigestItem{},
+		PastSLA:        []entity.DigestItem{},
+	}
+	for _, entry := range entries {
+		item := entry.DigestItem
+		item.AssignedAt = item.AssignedAt.In(loc)
+		item.PastSLA = entry.SLAHours != nil &&
+			d.opts.BusinessHours.Between(item.AssignedAt, now) >= time.Duration(*entry.SLAHours)*time.Hour
+		if item.ReviewerID == userID {
+			digest.PendingReviews = append(digest.PendingReviews, item)
+		} else {
+			digest.AwaitingReview = append(digest.AwaitingReview, item)
+		}
+		if item.PastSLA {
+			digest.PastSLA = append(digest.PastSLA, item)
+		}
+	}
+
+	var text, html bytes.Buffer
+	if err := digestText.Execute(&text, digest); err != nil {
+		return entity.Digest{}, fmt.Errorf("render text digest: %w", err)
+	}
+	if err := digestHTML.Execute(&html, digest); err != nil {
+		return entity.Digest{}, fmt.Errorf("render html digest: %w", err)
+	}
+	digest.Text, digest.HTML = text.String(), html.String()
+	return digest, nil
+}
+
+// Run sends due digests every Interval until ctx is cancelled.
+func (d *Digests) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.opts.Interval)
+	defer ticker.Stop()
+	for {
+		if err := d.Tick(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("digests: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Tick sends today's digests once Hour has passed. Every user gets at most one digest a day,
+// a digest that fails to be delivered is logged and not sent again.
+func (d *Digests) Tick(ctx context.Context) error {
+	now := d.now().In(d.opts.BusinessHours.Loc())
+	if now.Hour() < d.opts.Hour {
+		return nil
+	}
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	recipients, err := d.repo.DigestRecipients(ctx, day)
+	if err != nil {
+		return fmt.Errorf("list recipients: %w", err)
+	}
+	for _, userID := range recipients {
+		claimed, err := d.repo.ClaimDigest(ctx, userID, day)
+		if err != nil {
+			return fmt.Errorf("claim digest of %s: %w", userID, err)
+		}
+		if !claimed {
+			continue
+		}
+		digest, err := d.Build(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("build digest of %s: %w", userID, err)
+		}
+		if digest.IsEmpty() {
+			continue
+		}
+		if err := d.notifier.Notify(ctx, entity.Notification{
+			Event:     entity.NotifyDigest,
+			Recipient: entity.Recipient{UserID: userID},
+			Digest:    &digest,
+		}); err != nil {
+			log.Printf("digests: send to %s: %v", userID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package notifications
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"avito-internship-task/internal/entity"
+	"github.com/stretchr/testify/require"
+)
+
+type digestRepoStub struct {
+	entries map[string][]DigestEntry
+	sent    map[string]bool
+}
+
+func (r *digestRepoStub) DigestEntries(ctx context.Context, userID string) ([]DigestEntry, error) {
+	return r.entries[userID], nil
+}
+
+func (r *digestRepoStub) DigestRecipients(ctx context.Context, day time.Time) ([]string, error) {
+	result := make([]string, 0)
+	for _, id := range []string{"u1", "u2", "u3"} {
+		if len(r.entries[id]) > 0 && !r.sent[id+day.Format("2006-01-02")] {
+			result = append(result, id)
+		}
+	}
+	return result, nil
+}
+
+func (r *digestRepoStub) ClaimDigest(ctx context.Context, userID string, day time.Time) (bool, error) {
+	key := userID + day.Format("2006-01-02")
+	if r.sent[key] {
+		return false, nil
+	}
+	r.sent[key] = true
+	return true, nil
+}
+
+func TestDigests(t *testing.T) {
+	ctx := context.Background()
+	sla := 8
+	monday := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+	review := entity.DigestItem{PullRequestID: "pr1", PullRequestName: "Add search", AuthorID: "u2", ReviewerID: "u1", AssignedAt: time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)}
+	own := entity.DigestItem{PullRequestID: "pr2", PullRequestName: "Fix <login>", AuthorID: "u1", ReviewerID: "u3", AssignedAt: monday.Add(-time.Hour)}
+	repo := &digestRepoStub{
+		entries: map[string][]DigestEntry{
+			"u1": {{DigestItem: review, SLAHours: &sla}, {DigestItem: own, SLAHours: &sla}},
+			"u3": {{DigestItem: own, SLAHours: &sla}},
+		},
+		sent: map[string]bool{},
+	}
+	notifier := &notifierStub{}
+	digests := NewDigests(repo, notifier, DigestOptions{Hour: 9})
+	now := monday
+	digests.now = func() time.Time { return now }
+
+	digest, err := digests.Build(ctx, "u1")
+	require.NoError(t, err)
+	review.PastSLA = true
+	require.Equal(t, []entity.DigestItem{review}, digest.PendingReviews)
+	require.Equal(t, []entity.DigestItem{own}, digest.AwaitingReview)
+	require.Equal(t, []entity.DigestItem{review}, digest.PastSLA)
+	require.Equal(t, `Review digest for u1, 2026-10-19
+
+Waiting for your review (1):
+- Add search (pr1) by u2, assigned 2026-10-16 10:00, past SLA
+
+Your pull requests waiting on others (1):
+- Fix <login> (pr2) waits for u3 since 2026-10-19 07:00
+
+Past SLA (1):
+- Add search (pr1), reviewer u1, assigned 2026-10-16 10:00
+`, digest.Text)
+	require.Contains(t, digest.HTML, "<li><b>Fix &lt;login&gt;</b> (pr2) waits for u3 since 2026-10-19 07:00</li>")
+
+	empty, err := digests.Build(ctx, "u2")
+	require.NoError(t, err)
+	require.True(t, empty.IsEmpty())
+	require.Contains(t, empty.Text, "Waiting for your review (0):\n- nothing\n")
+
+	// nothing is sent before the digest hour, then once a day
+	require.NoError(t, digests.Tick(ctx))
+	require.Empty(t, notifier.sent)
+	now = monday.Add(2 * time.Hour)
+	require.NoError(t, digests.Tick(ctx))
+	require.NoError(t, digests.Tick(ctx))
+	require.Len(t, notifier.sent, 2)
+	require.Equal(t, entity.NotifyDigest, notifier.sent[0].Event)
+	require.Equal(t, "u1", notifier.sent[0].Recipient.UserID)
+	require.Len(t, notifier.sent[0].Digest.PendingReviews, 1)
+	require.Equal(t, "u3", notifier.sent[1].Recipient.UserID)
+
+	now = now.AddDate(0, 0, 1)
+	require.NoError(t, digests.Tick(ctx))
+	require.Len(t, notifier.sent, 4)
+
+	// the digest hour is local to the business hours time zone
+	digests.opts.BusinessHours.Location = time.FixedZone("MSK", 3*60*60)
+	now = time.Date(2026, 10, 21, 6, 30, 0, 0, time.UTC)
+	require.NoError(t, digests.Tick(ctx))
+	require.Len(t, notifier.sent, 6)
+	subject, _ := render(notifier.sent[4])
+	require.Equal(t, "Review digest for 2026-10-21", subject)
+
+	subject, text := render(notifier.sent[0])
+	require.Equal(t, "Review digest for 2026-10-19", subject)
+	require.Equal(t, notifier.sent[0].Digest.Text, text)
+}
@@ -0,0 +1,26 @@
+package notifications
+
+import (
+	"context"
+	"log"
+
+	"avito-internship-task/internal/entity"
+)
+
+// LogNotifier writes notifications to a logger, the standard one when it is nil.
+type LogNotifier struct {
+	logger *log.Logger
+}
+
+func NewLogNotifier(logger *log.Logger) *LogNotifier {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &LogNotifier{logger: logger}
+}
+
+func (n *LogNotifier) Notify(ctx context.Context, notification entity.Notification) error {
+	_, text := render(notification)
+	n.logger.Printf("notify %s (%s): %s", notification.Recipient.UserID, notification.Event, text)
+	return nil
+}
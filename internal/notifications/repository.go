@@ -0,0 +1,204 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"avito-internship-task/internal/entity"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Repository struct {
+	db *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) *Repository {
+	return &Repository{db: db}
+}
+
+func (r *Repository) DigestEntries(ctx context.Context, userID string) ([]DigestEntry, error) {
+	rows, err := r.db.Query(ctx, `
+SELECT p.pull_request_id, p.pull_request_name, p.author_id, r.reviewer_id, r.assigned_at, s.sla_hours
+FROM pr_reviewers r
+JOIN pull_requests p ON p.pull_request_id = r.pull_request_id
+JOIN users u ON u.user_id = r.reviewer_id
+LEFT JOIN team_settings s ON s.team_name = COALESCE(r.team_name, u.team_name)
+WHERE p.status = 'OPEN'
+  AND r.review_state = 'PENDING'
+  AND (r.reviewer_id = $1 OR p.author_id = $1)
+ORDER BY r.assigned_at, p.pull_request_id, r.reviewer_id
+`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	result := make([]DigestEntry, 0)
+	for rows.Next() {
+		var e DigestEntry
+		if err := rows.Scan(&e.PullRequestID, &e.PullRequestName, &e.AuthorID, &e.ReviewerID, &e.AssignedAt, &e.SLAHours); err != nil {
+			return nil, err
+		}
+		result = append(result, e)
+	}
+	return result, rows.Err()
+}
+
+func (r *Repository) DigestRecipients(ctx context.Context, day time.Time) ([]string, error) {
+	rows, err := r.db.Query(ctx, `
+WITH pending AS (
+    SELECT r.reviewer_id, p.author_id
+    FROM pr_reviewers r
+    JOIN pull_requests p ON p.pull_request_id = r.pull_request_id
+    WHERE p.status = 'OPEN' AND r.review_state = 'PENDING'
+)
+SELECT u.user_id
+FROM users u
+WHERE u.is_active
+  AND u.user_id IN (SELECT reviewer_id FROM pending UNION SELECT author_id FROM pending)
+  AND NOT EXISTS (SELECT 1 FROM digest_deliveries d WHERE d.user_id = u.user_id AND d.day = $1::date)
+ORDER BY u.user_id
+`, day)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	result := make([]string, 0)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		result = append(result, id)
+	}
+	return result, rows.Err()
+}
+
+func (r *Repository) ClaimDigest(ctx context.Context, userID string, day time.Time) (bool, error) {
+	tag, err := r.db.Exec(ctx, `
+INSERT INTO digest_deliveries (user_id, day) VALUES ($1, $2::date)
+ON CONFLICT DO NOTHING
+`, userID, day)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// QueueNotifications turns outbox messages not yet seen by the sender into notification deliveries
+// planned by plan. It returns the number of outbox messages processed.
+func (r *Repository) QueueNotifications(ctx context.Context, limit int, plan func(OutboxEntry) []entity.Notification) (int, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `
+SELECT o.outbox_id, o.payload::text, COALESCE(p.pull_request_name, ''), COALESCE(p.author_id, ''),
+       ARRAY(SELECT r.reviewer_id FROM pr_reviewers r WHERE r.pull_request_id = p.pull_request_id ORDER BY r.assigned_at, r.reviewer_id)
+FROM outbox o
+LEFT JOIN pull_requests p ON p.pull_request_id = o.payload->>'pull_request_id'
+WHERE o.notified_at IS NULL
+ORDER BY o.outbox_id
+LIMIT $1
+FOR UPDATE OF o SKIP LOCKED
+`, limit)
+	if err != nil {
+		return 0, err
+	}
+	var ids []int64
+	batch := &pgx.Batch{}
+	for rows.Next() {
+		var id int64
+		var payload string
+		var entry OutboxEntry
+		if err := rows.Scan(&id, &payload, &entry.PullRequestName, &entry.AuthorID, &entry.Reviewers); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if err := json.Unmarshal([]byte(payload), &entry.OutboxMessage); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+		for _, n := range plan(entry) {
+			body, err := json.Marshal(n)
+			if err != nil {
+				rows.Close()
+				return 0, err
+			}
+			batch.Queue(`
+INSERT INTO notification_deliveries (outbox_id, recipient_id, event_type, payload) VALUES ($1, $2, $3, $4)
+`, id, n.Recipient.UserID, n.Event, body)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	batch.Queue(`UPDATE outbox SET notified_at = NOW() WHERE outbox_id = ANY($1)`, ids)
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return len(ids), nil
+}
+
+// ClaimDueNotifications picks pending deliveries that are due at now and leases them until now+lease,
+// so concurrent senders do not send the same notification twice.
+func (r *Repository) ClaimDueNotifications(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]Delivery, error) {
+	rows, err := r.db.Query(ctx, `
+UPDATE notification_deliveries d
+SET next_attempt_at = $2
+WHERE d.delivery_id IN (
+    SELECT x.delivery_id FROM notification_deliveries x
+    WHERE x.status = 'PENDING' AND x.next_attempt_at <= $1
+    ORDER BY x.next_attempt_at
+    LIMIT $3
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING d.delivery_id, d.payload::text, d.sent_channels, d.attempts
+`, now, now.Add(lease), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := make([]Delivery, 0)
+	for rows.Next() {
+		var d Delivery
+		var payload string
+		if err := rows.Scan(&d.DeliveryID, &payload, &d.Sent, &d.Attempts); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(payload), &d.Notification); err != nil {
+			return nil, err
+		}
+		items = append(items, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (r *Repository) RecordNotification(ctx context.Context, res AttemptResult) error {
+	sent := res.Sent
+	if sent == nil {
+		sent = []string{}
+	}
+	_, err := r.db.Exec(ctx, `
+UPDATE notification_deliveries
+SET attempts = $2, status = $3, sent_channels = $4, last_error = NULLIF($5, ''),
+    next_attempt_at = $6, delivered_at = $7
+WHERE delivery_id = $1
+`, res.DeliveryID, res.Attempts, res.Status, sent, res.Error, res.NextAttemptAt, res.DeliveredAt)
+	return err
+}
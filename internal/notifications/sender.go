@@ -0,0 +1,225 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"avito-internship-task/internal/entity"
+)
+
+// OutboxEntry is an outbox message together with the PR it is about.
+type OutboxEntry struct {
+	entity.OutboxMessage
+	PullRequestName string
+	AuthorID        string
+	Reviewers       []string
+}
+
+// Delivery is a claimed notification, Sent lists the channels it already reached.
+type Delivery struct {
+	DeliveryID   int64
+	Notification entity.Notification
+	Sent         []string
+	Attempts     int
+}
+
+// AttemptResult is the outcome of a single delivery attempt.
+type AttemptResult struct {
+	DeliveryID    int64
+	Attempts      int
+	Status        string
+	Sent          []string
+	Error         string
+	NextAttemptAt time.Time
+	DeliveredAt   *time.Time
+}
+
+// SenderRepo is the storage side of the sender.
+type SenderRepo interface {
+	QueueNotifications(ctx context.Context, limit int, plan func(OutboxEntry) []entity.Notification) (int, error)
+	ClaimDueNotifications(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]Delivery, error)
+	RecordNotification(ctx context.Context, res AttemptResult) error
+}
+
+// ChannelSender delivers a notification over the channels of its recipient except the ones in
+// done and returns every channel reached so far.
+type ChannelSender interface {
+	Send(ctx context.Context, n entity.Notification, done []string) ([]string, error)
+}
+
+type SenderOptions struct {
+	Interval time.Duration
+	// Timeout bounds one delivery over all channels of the recipient.
+	Timeout     time.Duration
+	MaxAttempts int
+	BatchSize   int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+}
+
+// Sender turns outbox messages into notifications and delivers them. Channels that failed are
+// retried with exponential backoff until MaxAttempts is reached, the ones reached are not repeated.
+type Sender struct {
+	repo     SenderRepo
+	channels ChannelSender
+	opts     SenderOptions
+	now      func() time.Time
+}
+
+func NewSender(repo SenderRepo, channels ChannelSender, opts SenderOptions) *Sender {
+	if opts.Interval <= 0 {
+		opts.Interval = time.Second
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 15 * time.Second
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 8
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+	if opts.BaseBackoff <= 0 {
+		opts.BaseBackoff = time.Second
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = time.Hour
+	}
+	return &Sender{
+		repo:     repo,
+		channels: channels,
+		opts:     opts,
+		now:      func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// Run sends notifications every Interval until ctx is cancelled.
+func (s *Sender) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.opts.Interval)
+	defer ticker.Stop()
+	for {
+		if err := s.Tick(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("notifications: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Tick runs one round: queue notifications for new outbox messages and send up to BatchSize due ones.
+// Notifications are claimed one at a time, so each lease only has to cover a single delivery.
+func (s *Sender) Tick(ctx context.Context) error {
+	if _, err := s.repo.QueueNotifications(ctx, s.opts.BatchSize, planNotifications); err != nil {
+		return fmt.Errorf("queue notifications: %w", err)
+	}
+	for i := 0; i < s.opts.BatchSize; i++ {
+		// the lease outlives a delivery timeout so a slow channel is not used twice
+		deliveries, err := s.repo.ClaimDueNotifications(ctx, s.now(), 2*s.opts.Timeout, 1)
+		if err != nil {
+			return fmt.Errorf("claim notifications: %w", err)
+		}
+		if len(deliveries) == 0 {
+			return nil
+		}
+		delivery := deliveries[0]
+		res := s.send(ctx, delivery)
+		if err := ctx.Err(); err != nil {
+			// shutting down: the attempt did not finish, the lease expires and it is retried
+			return err
+		}
+		if err := s.repo.RecordNotification(ctx, res); err != nil {
+			return fmt.Errorf("record notification %d: %w", delivery.DeliveryID, err)
+		}
+	}
+	return nil
+}
+
+func (s *Sender) send(ctx context.Context, delivery Delivery) AttemptResult {
+	res := AttemptResult{DeliveryID: delivery.DeliveryID, Attempts: delivery.Attempts + 1}
+
+	sendCtx, cancel := context.WithTimeout(ctx, s.opts.Timeout)
+	defer cancel()
+	sent, err := s.channels.Send(sendCtx, delivery.Notification, delivery.Sent)
+	res.Sent = sent
+	now := s.now()
+	if err == nil {
+		res.Status = entity.DeliveryDelivered
+		res.NextAttemptAt = now
+		res.DeliveredAt = &now
+		return res
+	}
+	res.Error = err.Error()
+	if res.Attempts >= s.opts.MaxAttempts {
+		res.Status = entity.DeliveryFailed
+		res.NextAttemptAt = now
+		return res
+	}
+	res.Status = entity.DeliveryPending
+	res.NextAttemptAt = now.Add(s.backoff(res.Attempts))
+	return res
+}
+
+// backoff returns the delay before the next attempt: BaseBackoff doubled per failed attempt, capped at MaxBackoff.
+func (s *Sender) backoff(attempts int) time.Duration {
+	delay := s.opts.BaseBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= s.opts.MaxBackoff {
+			return s.opts.MaxBackoff
+		}
+	}
+	return delay
+}
+
+// planNotifications maps outbox messages to notifications: an assigned reviewer is told about
+// the assignment, a replaced one about who took the review over, a late reviewer and the author
+// about a breached SLA, the author and the reviewers about a merge.
+func planNotifications(entry OutboxEntry) []entity.Notification {
+	var result []entity.Notification
+	notify := func(event, recipient string) {
+		result = append(result, entity.Notification{
+			Event:           event,
+			Recipient:       entity.Recipient{UserID: recipient},
+			PullRequestID:   entry.PullRequestID,
+			PullRequestName: entry.PullRequestName,
+			AuthorID:        entry.AuthorID,
+			ReviewerID:      entry.ReviewerID,
+			OldReviewerID:   entry.OldReviewerID,
+			Reason:          entry.Reason,
+		})
+	}
+	switch entry.Event {
+	case entity.WebhookReviewerAssigned:
+		notify(entity.NotifyAssigned, entry.ReviewerID)
+	case entity.WebhookReviewerReassigned:
+		notify(entity.NotifyAssigned, entry.ReviewerID)
+		notify(entity.NotifyReassigned, entry.OldReviewerID)
+	case entity.WebhookReviewSLABreached:
+		for _, userID := range []string{entry.ReviewerID, entry.AuthorID} {
+			result = append(result, entity.Notification{
+				Event:           entity.NotifySLABreached,
+				Recipient:       entity.Recipient{UserID: userID},
+				PullRequestID:   entry.PullRequestID,
+				PullRequestName: entry.PullRequestName,
+				AuthorID:        entry.AuthorID,
+				ReviewerID:      entry.ReviewerID,
+			})
+		}
+	case entity.WebhookPRMerged:
+		for _, userID := range append([]string{entry.AuthorID}, entry.Reviewers...) {
+			result = append(result, entity.Notification{
+				Event:           entity.NotifyMerged,
+				Recipient:       entity.Recipient{UserID: userID},
+				PullRequestID:   entry.PullRequestID,
+				PullRequestName: entry.PullRequestName,
+				AuthorID:        entry.AuthorID,
+			})
+		}
+	}
+	return result
+}
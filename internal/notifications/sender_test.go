@@ -0,0 +1,153 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"avito-internship-task/internal/entity"
+	"github.com/stretchr/testify/require"
+)
+
+type senderRepoStub struct {
+	entries    []OutboxEntry
+	deliveries []Delivery
+	results    []AttemptResult
+	claims     int
+}
+
+func (r *senderRepoStub) QueueNotifications(ctx context.Context, limit int, plan func(OutboxEntry) []entity.Notification) (int, error) {
+	queued := r.entries[:min(limit, len(r.entries))]
+	r.entries = r.entries[len(queued):]
+	for _, entry := range queued {
+		for _, n := range plan(entry) {
+			r.deliveries = append(r.deliveries, Delivery{DeliveryID: int64(len(r.deliveries) + 1), Notification: n})
+		}
+	}
+	return len(queued), nil
+}
+
+func (r *senderRepoStub) ClaimDueNotifications(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]Delivery, error) {
+	r.claims++
+	claimed := r.deliveries[:min(limit, len(r.deliveries))]
+	r.deliveries = r.deliveries[len(claimed):]
+	return claimed, nil
+}
+
+func (r *senderRepoStub) RecordNotification(ctx context.Context, res AttemptResult) error {
+	r.results = append(r.results, res)
+	return nil
+}
+
+func TestPlanNotifications(t *testing.T) {
+	pr := func(n entity.Notification) entity.Notification {
+		n.PullRequestID, n.PullRequestName, n.AuthorID = "pr1", "Add search", "author"
+		return n
+	}
+	entry := func(msg entity.OutboxMessage) OutboxEntry {
+		msg.PullRequestID = "pr1"
+		return OutboxEntry{OutboxMessage: msg, PullRequestName: "Add search", AuthorID: "author", Reviewers: []string{"r3", "r2"}}
+	}
+
+	require.Equal(t, []entity.Notification{
+		pr(entity.Notification{Event: entity.NotifyAssigned, Recipient: entity.Recipient{UserID: "r1"}, ReviewerID: "r1", Reason: "manual"}),
+	}, planNotifications(entry(entity.OutboxMessage{Event: entity.WebhookReviewerAssigned, ReviewerID: "r1", Reason: "manual"})))
+	require.Equal(t, []entity.Notification{
+		pr(entity.Notification{Event: entity.NotifyAssigned, Recipient: entity.Recipient{UserID: "r3"}, ReviewerID: "r3", OldReviewerID: "r1", Reason: "deactivated"}),
+		pr(entity.Notification{Event: entity.NotifyReassigned, Recipient: entity.Recipient{UserID: "r1"}, ReviewerID: "r3", OldReviewerID: "r1", Reason: "deactivated"}),
+	}, planNotifications(entry(entity.OutboxMessage{Event: entity.WebhookReviewerReassigned, ReviewerID: "r3", OldReviewerID: "r1", Reason: "deactivated"})))
+	require.Equal(t, []entity.Notification{
+		pr(entity.Notification{Event: entity.NotifyMerged, Recipient: entity.Recipient{UserID: "author"}}),
+		pr(entity.Notification{Event: entity.NotifyMerged, Recipient: entity.Recipient{UserID: "r3"}}),
+		pr(entity.Notification{Event: entity.NotifyMerged, Recipient: entity.Recipient{UserID: "r2"}}),
+	}, planNotifications(entry(entity.OutboxMessage{Event: entity.WebhookPRMerged, Status: entity.StatusMerged})))
+	require.Equal(t, []entity.Notification{
+		pr(entity.Notification{Event: entity.NotifySLABreached, Recipient: entity.Recipient{UserID: "r2"}, ReviewerID: "r2"}),
+		pr(entity.Notification{Event: entity.NotifySLABreached, Recipient: entity.Recipient{UserID: "author"}, ReviewerID: "r2"}),
+	}, planNotifications(entry(entity.OutboxMessage{Event: entity.WebhookReviewSLABreached, ReviewerID: "r2", Reason: entity.SLANotify})))
+	require.Empty(t, planNotifications(entry(entity.OutboxMessage{Event: entity.WebhookPRCreated})))
+}
+
+func TestSenderRetries(t *testing.T) {
+	ctx := context.Background()
+	prefs := &prefsRepoStub{prefs: map[string]entity.NotificationPreferences{
+		"r1": {UserID: "r1", Email: "r1@example.com", Channels: []string{"email", "log"}},
+	}}
+	email := &notifierStub{err: errors.New("smtp down")}
+	logged := &notifierStub{}
+	svc := NewService(prefs, map[string]Notifier{entity.ChannelEmail: email, entity.ChannelLog: logged})
+
+	now := time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC)
+	repo := &senderRepoStub{entries: []OutboxEntry{{
+		OutboxMessage: entity.OutboxMessage{Event: entity.WebhookReviewerAssigned, PullRequestID: "pr1", ReviewerID: "r1"},
+		AuthorID:      "author",
+	}}}
+	s := NewSender(repo, svc, SenderOptions{MaxAttempts: 3, BaseBackoff: time.Second})
+	s.now = func() time.Time { return now }
+
+	require.NoError(t, s.Tick(ctx))
+	require.Len(t, repo.results, 1)
+	res := repo.results[0]
+	require.Equal(t, entity.DeliveryPending, res.Status)
+	require.Equal(t, 1, res.Attempts)
+	require.Equal(t, []string{entity.ChannelLog}, res.Sent)
+	require.Equal(t, "email: smtp down", res.Error)
+	require.Equal(t, now.Add(time.Second), res.NextAttemptAt)
+
+	// the retry only goes to the channel that failed
+	email.err = nil
+	repo.deliveries = []Delivery{{DeliveryID: res.DeliveryID, Notification: email.sent[0], Sent: res.Sent, Attempts: res.Attempts}}
+	require.NoError(t, s.Tick(ctx))
+	res = repo.results[1]
+	require.Equal(t, entity.DeliveryDelivered, res.Status)
+	require.Equal(t, 2, res.Attempts)
+	require.ElementsMatch(t, []string{entity.ChannelLog, entity.ChannelEmail}, res.Sent)
+	require.NotNil(t, res.DeliveredAt)
+	require.Len(t, email.sent, 2)
+	require.Len(t, logged.sent, 1)
+
+	email.err = errors.New("smtp down")
+	repo.deliveries = []Delivery{{DeliveryID: 2, Notification: email.sent[0], Sent: []string{entity.ChannelLog}, Attempts: 2}}
+	require.NoError(t, s.Tick(ctx))
+	require.Equal(t, entity.DeliveryFailed, repo.results[2].Status)
+}
+
+func TestSenderClaimsOneAtATime(t *testing.T) {
+	repo := &senderRepoStub{}
+	for id := int64(1); id <= 3; id++ {
+		repo.deliveries = append(repo.deliveries, Delivery{DeliveryID: id})
+	}
+	s := NewSender(repo, NewService(&prefsRepoStub{}, nil), SenderOptions{BatchSize: 2})
+	require.NoError(t, s.Tick(context.Background()))
+	require.Len(t, repo.results, 2)
+	require.Equal(t, 2, repo.claims)
+	require.Len(t, repo.deliveries, 1)
+}
+
+func TestSenderSLABreached(t *testing.T) {
+	prefs := &prefsRepoStub{prefs: map[string]entity.NotificationPreferences{
+		"r1":     {UserID: "r1", Channels: []string{"log"}},
+		"author": {UserID: "author", Channels: []string{"log"}},
+	}}
+	logged := &notifierStub{}
+	repo := &senderRepoStub{entries: []OutboxEntry{{
+		OutboxMessage:   entity.OutboxMessage{Event: entity.WebhookReviewSLABreached, PullRequestID: "pr1", ReviewerID: "r1", Reason: entity.SLANotify},
+		PullRequestName: "Add search",
+		AuthorID:        "author",
+		Reviewers:       []string{"r1"},
+	}}}
+	s := NewSender(repo, NewService(prefs, map[string]Notifier{entity.ChannelLog: logged}), SenderOptions{})
+	require.NoError(t, s.Tick(context.Background()))
+
+	require.Len(t, logged.sent, 2)
+	require.Equal(t, "r1", logged.sent[0].Recipient.UserID)
+	require.Equal(t, "author", logged.sent[1].Recipient.UserID)
+	_, text := render(logged.sent[0])
+	require.Equal(t, `Your review of "Add search" (pr1) by author is past the team SLA.`, text)
+	_, text = render(logged.sent[1])
+	require.Equal(t, `The review of "Add search" (pr1) by r1 is past the team SLA.`, text)
+	for _, res := range repo.results {
+		require.Equal(t, entity.DeliveryDelivered, res.Status)
+	}
+}
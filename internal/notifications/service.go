@@ -0,0 +1,108 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"avito-internship-task/internal/entity"
+	"github.com/jackc/pgx/v5"
+)
+
+var ErrNoAddress = errors.New("recipient has no address for the channel")
+
+// Notifier delivers a notification to its recipient.
+type Notifier interface {
+	Notify(ctx context.Context, n entity.Notification) error
+}
+
+type Repo interface {
+	GetNotificationPreferences(ctx context.Context, userID string) (entity.NotificationPreferences, error)
+}
+
+// Service is the Notifier used by the Sender and the digests: it looks up the preferences of the
+// recipient and passes the notification to every channel the recipient enabled.
+// Channels missing from channels are not configured and are skipped.
+type Service struct {
+	repo     Repo
+	channels map[string]Notifier
+}
+
+func NewService(repo Repo, channels map[string]Notifier) *Service {
+	return &Service{repo: repo, channels: channels}
+}
+
+func (s *Service) Notify(ctx context.Context, n entity.Notification) error {
+	_, err := s.Send(ctx, n, nil)
+	return err
+}
+
+// Send is Notify that skips the channels in done, it returns every channel reached so far
+// including done, so a retry does not repeat them.
+func (s *Service) Send(ctx context.Context, n entity.Notification, done []string) ([]string, error) {
+	sent := slices.Clone(done)
+	prefs, err := s.repo.GetNotificationPreferences(ctx, n.Recipient.UserID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return sent, nil
+		}
+		return sent, err
+	}
+	if len(prefs.Events) > 0 && !slices.Contains(prefs.Events, n.Event) {
+		return sent, nil
+	}
+	n.Recipient.Email = prefs.Email
+	n.Recipient.ChatHandle = prefs.ChatHandle
+
+	var errs []error
+	for _, name := range prefs.Channels {
+		channel, ok := s.channels[name]
+		if !ok || slices.Contains(done, name) {
+			continue
+		}
+		if err := channel.Notify(ctx, n); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		sent = append(sent, name)
+	}
+	return sent, errors.Join(errs...)
+}
+
+// render formats a notification as a subject line and a plain text body.
+func render(n entity.Notification) (string, string) {
+	var subject, text string
+	switch n.Event {
+	case entity.NotifyAssigned:
+		subject = "Review requested: " + n.PullRequestName
+		text = fmt.Sprintf("You were assigned to review %q (%s) by %s.", n.PullRequestName, n.PullRequestID, n.AuthorID)
+		if n.OldReviewerID != "" {
+			text += fmt.Sprintf(" You take over from %s.", n.OldReviewerID)
+		}
+	case entity.NotifyReassigned:
+		subject = "Review reassigned: " + n.PullRequestName
+		text = fmt.Sprintf("Your review of %q (%s) was handed over to %s.", n.PullRequestName, n.PullRequestID, n.ReviewerID)
+	case entity.NotifyMerged:
+		subject = "Merged: " + n.PullRequestName
+		text = fmt.Sprintf("Pull request %q (%s) by %s was merged.", n.PullRequestName, n.PullRequestID, n.AuthorID)
+	case entity.NotifySLABreached:
+		subject = "Review past SLA: " + n.PullRequestName
+		if n.Recipient.UserID == n.ReviewerID {
+			text = fmt.Sprintf("Your review of %q (%s) by %s is past the team SLA.", n.PullRequestName, n.PullRequestID, n.AuthorID)
+		} else {
+			text = fmt.Sprintf("The review of %q (%s) by %s is past the team SLA.", n.PullRequestName, n.PullRequestID, n.ReviewerID)
+		}
+	case entity.NotifyDigest:
+		if n.Digest != nil {
+			return "Review digest for " + n.Digest.GeneratedAt.Format("2006-01-02"), n.Digest.Text
+		}
+	default:
+		subject = n.PullRequestName
+		text = fmt.Sprintf("Pull request %q (%s): %s.", n.PullRequestName, n.PullRequestID, n.Event)
+	}
+	if n.Reason != "" {
+		text += " Reason: " + n.Reason + "."
+	}
+	return subject, text
+}
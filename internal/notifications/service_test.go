@@ -0,0 +1,109 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"avito-internship-task/internal/entity"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+)
+
+type prefsRepoStub struct {
+	prefs map[string]entity.NotificationPreferences
+}
+
+func (r *prefsRepoStub) GetNotificationPreferences(ctx context.Context, userID string) (entity.NotificationPreferences, error) {
+	prefs, ok := r.prefs[userID]
+	if !ok {
+		return entity.NotificationPreferences{}, pgx.ErrNoRows
+	}
+	return prefs, nil
+}
+
+type notifierStub struct {
+	sent []entity.Notification
+	err  error
+}
+
+func (n *notifierStub) Notify(ctx context.Context, notification entity.Notification) error {
+	n.sent = append(n.sent, notification)
+	return n.err
+}
+
+func TestServiceRoutes(t *testing.T) {
+	ctx := context.Background()
+	repo := &prefsRepoStub{prefs: map[string]entity.NotificationPreferences{
+		"u1": {UserID: "u1", Email: "alice@example.com", ChatHandle: "alice", Channels: []string{"email", "chat", "log"}},
+		"u2": {UserID: "u2", Channels: []string{"log"}, Events: []string{entity.NotifyMerged}},
+	}}
+	email := &notifierStub{err: errors.New("smtp down")}
+	logged := &notifierStub{}
+	// chat is not configured in this deployment
+	svc := NewService(repo, map[string]Notifier{entity.ChannelEmail: email, entity.ChannelLog: logged})
+
+	err := svc.Notify(ctx, entity.Notification{Event: entity.NotifyAssigned, Recipient: entity.Recipient{UserID: "u1"}, PullRequestID: "pr1"})
+	require.ErrorContains(t, err, "email: smtp down")
+	require.Len(t, email.sent, 1)
+	require.Equal(t, entity.Recipient{UserID: "u1", Email: "alice@example.com", ChatHandle: "alice"}, email.sent[0].Recipient)
+	require.Len(t, logged.sent, 1)
+
+	require.NoError(t, svc.Notify(ctx, entity.Notification{Event: entity.NotifyAssigned, Recipient: entity.Recipient{UserID: "u2"}}))
+	require.NoError(t, svc.Notify(ctx, entity.Notification{Event: entity.NotifyMerged, Recipient: entity.Recipient{UserID: "u2"}}))
+	require.NoError(t, svc.Notify(ctx, entity.Notification{Event: entity.NotifyMerged, Recipient: entity.Recipient{UserID: "missing"}}))
+	require.Len(t, logged.sent, 2)
+	require.Equal(t, entity.NotifyMerged, logged.sent[1].Event)
+}
+
+func TestChatNotifier(t *testing.T) {
+	var got []chatMessage
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg chatMessage
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		got = append(got, msg)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	n := entity.Notification{
+		Event:           entity.NotifyReassigned,
+		Recipient:       entity.Recipient{UserID: "u1", ChatHandle: "alice"},
+		PullRequestID:   "pr1",
+		PullRequestName: "Add search",
+		ReviewerID:      "u3",
+		Reason:          "sla",
+	}
+	ctx := context.Background()
+	require.NoError(t, NewChatNotifier(ChatOptions{URL: srv.URL}).Notify(ctx, n))
+	require.NoError(t, NewChatNotifier(ChatOptions{URL: srv.URL, Format: ChatMattermost}).Notify(ctx, n))
+	n.Recipient.ChatHandle = ""
+	require.NoError(t, NewChatNotifier(ChatOptions{URL: srv.URL, Format: ChatMattermost}).Notify(ctx, n))
+	require.Equal(t, []chatMessage{
+		{Text: `<@alice>: Your review of "Add search" (pr1) was handed over to u3. Reason: sla.`},
+		{Text: `@alice: Your review of "Add search" (pr1) was handed over to u3. Reason: sla.`},
+		{Text: `u1: Your review of "Add search" (pr1) was handed over to u3. Reason: sla.`},
+	}, got)
+
+	status = http.StatusNotFound
+	require.ErrorContains(t, NewChatNotifier(ChatOptions{URL: srv.URL}).Notify(ctx, n), "status 404")
+}
+
+func TestLogNotifier(t *testing.T) {
+	var buf bytes.Buffer
+	n := NewLogNotifier(log.New(&buf, "", 0))
+	require.NoError(t, n.Notify(context.Background(), entity.Notification{
+		Event:           entity.NotifyMerged,
+		Recipient:       entity.Recipient{UserID: "u1"},
+		PullRequestID:   "pr1",
+		PullRequestName: "Add search",
+		AuthorID:        "u1",
+	}))
+	require.Equal(t, "notify u1 (merged): Pull request \"Add search\" (pr1) by u1 was merged.\n", buf.String())
+}
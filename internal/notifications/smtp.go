@@ -0,0 +1,131 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"avito-internship-task/internal/entity"
+)
+
+type SMTPOptions struct {
+	// Addr is the host:port of the SMTP server.
+	Addr     string
+	Username string
+	Password string
+	From     string
+	Timeout  time.Duration
+}
+
+// SMTPNotifier sends notifications as plain text emails. STARTTLS is used when the
+// server offers it, credentials are only sent when Username is set.
+type SMTPNotifier struct {
+	opts SMTPOptions
+	now  func() time.Time
+}
+
+func NewSMTPNotifier(opts SMTPOptions) *SMTPNotifier {
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Second
+	}
+	return &SMTPNotifier{
+		opts: opts,
+		now:  func() time.Time { return time.Now().UTC() },
+	}
+}
+
+func (n *SMTPNotifier) Notify(ctx context.Context, notification entity.Notification) error {
+	to := notification.Recipient.Email
+	if to == "" {
+		return ErrNoAddress
+	}
+	host, _, err := net.SplitHostPort(n.opts.Addr)
+	if err != nil {
+		return err
+	}
+	dialer := net.Dialer{Timeout: n.opts.Timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", n.opts.Addr)
+	if err != nil {
+		return err
+	}
+	if err := conn.SetDeadline(time.Now().Add(n.opts.Timeout)); err != nil {
+		conn.Close()
+		return err
+	}
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if n.opts.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", n.opts.Username, n.opts.Password, host)); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(n.opts.From); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(n.message(to, notification)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// message builds the email, digests carry their HTML form as an alternative to the text.
+func (n *SMTPNotifier) message(to string, notification entity.Notification) []byte {
+	subject, text := render(notification)
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", n.opts.From)
+	fmt.Fprintf(&buf, "To: %s\r\n", to)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", n.now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	if notification.Digest == nil || notification.Digest.HTML == "" {
+		buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+		buf.WriteString(crlf(text))
+		return buf.Bytes()
+	}
+
+	parts := multipart.NewWriter(&buf)
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", parts.Boundary())
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", text},
+		{"text/html; charset=utf-8", notification.Digest.HTML},
+	} {
+		w, _ := parts.CreatePart(textproto.MIMEHeader{"Content-Type": {part.contentType}})
+		io.WriteString(w, crlf(part.body))
+	}
+	parts.Close()
+	return buf.Bytes()
+}
+
+// crlf terminates every line of s with CRLF as SMTP requires.
+func crlf(s string) string {
+	return strings.ReplaceAll(strings.TrimRight(s, "\n"), "\n", "\r\n") + "\r\n"
+}
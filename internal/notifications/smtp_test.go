@@ -0,0 +1,120 @@
+package notifications
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"avito-internship-task/internal/entity"
+	"github.com/stretchr/testify/require"
+)
+
+type smtpMessage struct {
+	from string
+	to   []string
+	data string
+}
+
+// fakeSMTP accepts a single session without TLS or auth and sends the received message to the channel.
+func fakeSMTP(t *testing.T) (string, <-chan smtpMessage) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	messages := make(chan smtpMessage, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		tp := textproto.NewConn(conn)
+		var msg smtpMessage
+		tp.PrintfLine("220 localhost ESMTP")
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				return
+			}
+			cmd := strings.ToUpper(line)
+			switch {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				tp.PrintfLine("250 localhost")
+			case strings.HasPrefix(cmd, "MAIL FROM:"):
+				msg.from = strings.Trim(line[len("MAIL FROM:"):], "<>")
+				tp.PrintfLine("250 OK")
+			case strings.HasPrefix(cmd, "RCPT TO:"):
+				msg.to = append(msg.to, strings.Trim(line[len("RCPT TO:"):], "<>"))
+				tp.PrintfLine("250 OK")
+			case cmd == "DATA":
+				tp.PrintfLine("354 go ahead")
+				data, err := tp.ReadDotBytes()
+				if err != nil {
+					return
+				}
+				msg.data = string(data)
+				tp.PrintfLine("250 OK")
+			case cmd == "QUIT":
+				tp.PrintfLine("221 bye")
+				messages <- msg
+				return
+			default:
+				tp.PrintfLine("502 not implemented")
+			}
+		}
+	}()
+	return ln.Addr().String(), messages
+}
+
+func TestSMTPNotifier(t *testing.T) {
+	addr, messages := fakeSMTP(t)
+	n := NewSMTPNotifier(SMTPOptions{Addr: addr, From: "reviews@example.com", Timeout: time.Second})
+	n.now = func() time.Time { return time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC) }
+
+	err := n.Notify(context.Background(), entity.Notification{
+		Event:           entity.NotifyAssigned,
+		Recipient:       entity.Recipient{UserID: "u2", Email: "bob@example.com"},
+		PullRequestID:   "pr1",
+		PullRequestName: "Add search",
+		AuthorID:        "u1",
+	})
+	require.NoError(t, err)
+
+	msg := <-messages
+	require.Equal(t, "reviews@example.com", msg.from)
+	require.Equal(t, []string{"bob@example.com"}, msg.to)
+	headers, err := textproto.NewReader(bufio.NewReader(strings.NewReader(msg.data))).ReadMIMEHeader()
+	require.NoError(t, err)
+	require.Equal(t, "bob@example.com", headers.Get("To"))
+	require.Equal(t, "Review requested: Add search", headers.Get("Subject"))
+	require.Equal(t, "Fri, 16 Oct 2026 12:00:00 +0000", headers.Get("Date"))
+	require.Contains(t, msg.data, `You were assigned to review "Add search" (pr1) by u1.`)
+
+	err = n.Notify(context.Background(), entity.Notification{Event: entity.NotifyMerged, Recipient: entity.Recipient{UserID: "u2"}})
+	require.ErrorIs(t, err, ErrNoAddress)
+}
+
+func TestSMTPNotifierDigest(t *testing.T) {
+	addr, messages := fakeSMTP(t)
+	n := NewSMTPNotifier(SMTPOptions{Addr: addr, From: "reviews@example.com", Timeout: time.Second})
+
+	err := n.Notify(context.Background(), entity.Notification{
+		Event:     entity.NotifyDigest,
+		Recipient: entity.Recipient{UserID: "u1", Email: "alice@example.com"},
+		Digest:    &entity.Digest{UserID: "u1", GeneratedAt: time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC), Text: "line one\nline two\n", HTML: "<p>digest</p>\n"},
+	})
+	require.NoError(t, err)
+
+	msg := <-messages
+	headers, err := textproto.NewReader(bufio.NewReader(strings.NewReader(msg.data))).ReadMIMEHeader()
+	require.NoError(t, err)
+	require.Equal(t, "Review digest for 2026-10-19", headers.Get("Subject"))
+	require.True(t, strings.HasPrefix(headers.Get("Content-Type"), "multipart/alternative; boundary="))
+	require.Contains(t, msg.data, "Content-Type: text/plain; charset=utf-8\n\nline one\nline two\n")
+	require.Contains(t, msg.data, "Content-Type: text/html; charset=utf-8\n\n<p>digest</p>\n")
+}
@@ -1,11 +1,17 @@
 package pullrequests
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
+	"time"
 
+	"avito-internship-task/internal/codeowners"
 	"avito-internship-task/internal/entity"
 	"avito-internship-task/internal/httpserver"
 	"github.com/jackc/pgconn"
@@ -21,8 +27,20 @@ func NewHandler(service *Service) *Handler {
 
 func (h *Handler) Register(mux *http.ServeMux) {
 	mux.Handle("/pullRequest/create", httpserver.WithError(h.create))
+	mux.Handle("/pullRequest/preview", httpserver.WithError(h.preview))
 	mux.Handle("/pullRequest/merge", httpserver.WithError(h.merge))
 	mux.Handle("/pullRequest/reassign", httpserver.WithError(h.reassign))
+	mux.Handle("/pullRequest/reviewers/add", httpserver.WithError(h.addReviewer))
+	mux.Handle("/pullRequest/reviewers/remove", httpserver.WithError(h.removeReviewer))
+	mux.Handle("/pullRequest/respond", httpserver.WithError(h.respond))
+	mux.Handle("/pullRequest/review", httpserver.WithError(h.review))
+	mux.Handle("/pullRequest/ready", httpserver.WithError(h.ready))
+	mux.Handle("/pullRequest/close", httpserver.WithError(h.close))
+	mux.Handle("/pullRequest/reopen", httpserver.WithError(h.reopen))
+	mux.Handle("/pullRequest/get", httpserver.WithError(h.get))
+	mux.Handle("/pullRequest/list", httpserver.WithError(h.list))
+	mux.Handle("/pullRequest/timeline", httpserver.WithError(h.timeline))
+	mux.Handle("/pullRequest/assignmentExplain", httpserver.WithError(h.assignmentExplain))
 	mux.Handle("/pullRequest/stats", httpserver.WithError(h.stats))
 }
 
@@ -30,6 +48,13 @@ type createRequest struct {
 	PullRequestID   string `json:"pull_request_id"`
 	PullRequestName string `json:"pull_request_name"`
 	AuthorID        string `json:"author_id"`
+	Draft           bool   `json:"draft"`
+	RepositoryID    string `json:"repository_id"`
+	// Repository selects stored CODEOWNERS and defaults to RepositoryID, CodeOwners passes the content inline.
+	Repository       string   `json:"repository"`
+	ChangedFiles     []string `json:"changed_files"`
+	CodeOwners       string   `json:"codeowners"`
+	RequireCodeOwner bool     `json:"require_code_owner"`
 }
 
 type mergeRequest struct {
@@ -39,12 +64,52 @@ type mergeRequest struct {
 type reassignRequest struct {
 	PullRequestID string `json:"pull_request_id"`
 	OldReviewerID string `json:"old_reviewer_id"`
+	ActorID       string `json:"actor_id"`
+}
+
+type reviewerChangeRequest struct {
+	PullRequestID string `json:"pull_request_id"`
+	ReviewerID    string `json:"reviewer_id"`
+	ActorID       string `json:"actor_id"`
+}
+
+type respondRequest struct {
+	PullRequestID string `json:"pull_request_id"`
+	ReviewerID    string `json:"reviewer_id"`
+	Response      string `json:"response"`
+	Reason        string `json:"reason"`
+}
+
+type respondResponse struct {
+	PR         entity.PullRequest `json:"pr"`
+	ReplacedBy string             `json:"replaced_by,omitempty"`
+}
+
+type reviewRequest struct {
+	PullRequestID string `json:"pull_request_id"`
+	ReviewerID    string `json:"reviewer_id"`
+	Decision      string `json:"decision"`
 }
 
 type prEnvelope struct {
 	PR entity.PullRequest `json:"pr"`
 }
 
+type timelineResponse struct {
+	PullRequestID string           `json:"pull_request_id"`
+	Events        []entity.PREvent `json:"events"`
+}
+
+type previewResponse struct {
+	PR       entity.PullRequest        `json:"pr"`
+	Decision entity.AssignmentDecision `json:"decision"`
+}
+
+type explainResponse struct {
+	PullRequestID string                      `json:"pull_request_id"`
+	Decisions     []entity.AssignmentDecision `json:"decisions"`
+}
+
 type reassignResponse struct {
 	PR         entity.PullRequest `json:"pr"`
 	ReplacedBy string             `json:"replaced_by"`
@@ -64,8 +129,28 @@ const (
 	codePRMerged    = "PR_MERGED"
 	codeNotAssigned = "NOT_ASSIGNED"
 	codeNoCandidate = "NO_CANDIDATE"
+	codeAtCapacity  = "ALL_AT_CAPACITY"
+	codeNotApproved = "NOT_APPROVED"
+	codePRDraft     = "PR_DRAFT"
+	codePRClosed    = "PR_CLOSED"
+	codeNotDraft    = "PR_NOT_DRAFT"
+	codeNotClosed   = "PR_NOT_CLOSED"
+	codeConflict    = "STATUS_CONFLICT"
+	codeNoOwner     = "NO_CODE_OWNER"
+	codeIsAuthor    = "REVIEWER_IS_AUTHOR"
+	codeAssigned    = "ALREADY_ASSIGNED"
 )
 
+type notApprovedEnvelope struct {
+	Error struct {
+		Code             string   `json:"code"`
+		Message          string   `json:"message"`
+		Required         int      `json:"required_approvals"`
+		Approved         int      `json:"approvals"`
+		MissingApprovals []string `json:"missing_approvals"`
+	} `json:"error"`
+}
+
 func (h *Handler) create(w http.ResponseWriter, r *http.Request) error {
 	if r.Method != http.MethodPost {
 		httpserver.RespondError(w, http.StatusMethodNotAllowed, "method not allowed")
@@ -76,31 +161,76 @@ func (h *Handler) create(w http.ResponseWriter, r *http.Request) error {
 		writePRError(w, http.StatusBadRequest, codeBadRequest, "invalid json")
 		return nil
 	}
-	pr, err := h.service.Create(r.Context(), entity.PullRequest{
+	pr, owners := req.toPullRequest()
+	pr, err := h.service.CreateWithCodeOwners(r.Context(), pr, owners)
+	if err != nil {
+		return writeCreateError(w, err)
+	}
+	httpserver.RespondJSON(w, http.StatusCreated, prEnvelope{PR: pr})
+	return nil
+}
+
+func (h *Handler) preview(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		httpserver.RespondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return nil
+	}
+	var req createRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writePRError(w, http.StatusBadRequest, codeBadRequest, "invalid json")
+		return nil
+	}
+	pr, owners := req.toPullRequest()
+	pr, decision, err := h.service.Preview(r.Context(), pr, owners)
+	if err != nil {
+		return writeCreateError(w, err)
+	}
+	httpserver.RespondJSON(w, http.StatusOK, previewResponse{PR: pr, Decision: decision})
+	return nil
+}
+
+func (req createRequest) toPullRequest() (entity.PullRequest, CodeOwnersRequest) {
+	pr := entity.PullRequest{
 		PullRequestID:   req.PullRequestID,
 		PullRequestName: req.PullRequestName,
 		AuthorID:        req.AuthorID,
-	})
-	if err != nil {
-		if isPGUnique(err) || isDuplicateErr(err) {
-			writePRError(w, http.StatusConflict, codePRExists, "PR id already exists")
-			return nil
-		}
-		switch {
-		case errors.Is(err, ErrInvalidInput):
-			writePRError(w, http.StatusBadRequest, codeBadRequest, "pull_request_id, pull_request_name and author_id are required")
-			return nil
-		case errors.Is(err, ErrNotFound):
-			writePRError(w, http.StatusNotFound, codeNotFound, "author not found or inactive")
-			return nil
-		case errors.Is(err, ErrExists):
-			writePRError(w, http.StatusConflict, codePRExists, "PR id already exists")
-			return nil
-		default:
-			return err
-		}
+		RepositoryID:    req.RepositoryID,
+	}
+	if req.Draft {
+		pr.Status = entity.StatusDraft
+	}
+	return pr, CodeOwnersRequest{
+		Repository: req.Repository,
+		Content:    req.CodeOwners,
+		Files:      req.ChangedFiles,
+		Require:    req.RequireCodeOwner,
+	}
+}
+
+// writeCreateError responds to errors of PR creation and its preview, other errors are returned.
+func writeCreateError(w http.ResponseWriter, err error) error {
+	if isPGUnique(err) || isDuplicateErr(err) {
+		writePRError(w, http.StatusConflict, codePRExists, "PR id already exists")
+		return nil
+	}
+	switch {
+	case errors.Is(err, ErrInvalidInput):
+		writePRError(w, http.StatusBadRequest, codeBadRequest, "pull_request_id, pull_request_name and author_id are required")
+	case errors.Is(err, ErrNotFound):
+		writePRError(w, http.StatusNotFound, codeNotFound, "author not found or inactive")
+	case errors.Is(err, ErrNoRepository):
+		writePRError(w, http.StatusNotFound, codeNotFound, "repository not found")
+	case errors.Is(err, codeowners.ErrInvalidInput):
+		writePRError(w, http.StatusBadRequest, codeBadRequest, "invalid CODEOWNERS: "+err.Error())
+	case errors.Is(err, ErrNoCodeOwner):
+		writePRError(w, http.StatusConflict, codeNoOwner, "no code owner of the changed files can review this PR")
+	case errors.Is(err, ErrAtCapacity):
+		writePRError(w, http.StatusConflict, codeAtCapacity, "all reviewer candidates are at review capacity")
+	case errors.Is(err, ErrExists):
+		writePRError(w, http.StatusConflict, codePRExists, "PR id already exists")
+	default:
+		return err
 	}
-	httpserver.RespondJSON(w, http.StatusCreated, prEnvelope{PR: pr})
 	return nil
 }
 
@@ -123,6 +253,21 @@ func (h *Handler) merge(w http.ResponseWriter, r *http.Request) error {
 		case errors.Is(err, ErrNotFound):
 			writePRError(w, http.StatusNotFound, codeNotFound, "PR not found")
 			return nil
+		case writeStatusError(w, err):
+			return nil
+		case errors.Is(err, ErrNotApproved):
+			var approvalErr *ApprovalError
+			if !errors.As(err, &approvalErr) {
+				return err
+			}
+			var e notApprovedEnvelope
+			e.Error.Code = codeNotApproved
+			e.Error.Message = "PR does not have enough approvals"
+			e.Error.Required = approvalErr.Required
+			e.Error.Approved = approvalErr.Approved
+			e.Error.MissingApprovals = approvalErr.Missing
+			httpserver.RespondJSON(w, http.StatusConflict, e)
+			return nil
 		default:
 			return err
 		}
@@ -141,7 +286,7 @@ func (h *Handler) reassign(w http.ResponseWriter, r *http.Request) error {
 		writePRError(w, http.StatusBadRequest, codeBadRequest, "invalid json")
 		return nil
 	}
-	pr, replacement, err := h.service.Reassign(r.Context(), req.PullRequestID, req.OldReviewerID)
+	pr, replacement, err := h.service.Reassign(r.Context(), req.PullRequestID, req.OldReviewerID, req.ActorID)
 	if err != nil {
 		if isPGUnique(err) || isDuplicateErr(err) {
 			writePRError(w, http.StatusConflict, codePRExists, "PR id already exists")
@@ -157,12 +302,17 @@ func (h *Handler) reassign(w http.ResponseWriter, r *http.Request) error {
 		case errors.Is(err, ErrMerged):
 			writePRError(w, http.StatusConflict, codePRMerged, "cannot reassign on merged PR")
 			return nil
+		case writeStatusError(w, err):
+			return nil
 		case errors.Is(err, ErrNotAssigned):
 			writePRError(w, http.StatusConflict, codeNotAssigned, "reviewer is not assigned to this PR")
 			return nil
 		case errors.Is(err, ErrNoCandidate):
 			writePRError(w, http.StatusConflict, codeNoCandidate, "no active replacement candidate in team")
 			return nil
+		case errors.Is(err, ErrAtCapacity):
+			writePRError(w, http.StatusConflict, codeAtCapacity, "all replacement candidates are at review capacity")
+			return nil
 		default:
 			return err
 		}
@@ -171,6 +321,193 @@ func (h *Handler) reassign(w http.ResponseWriter, r *http.Request) error {
 	return nil
 }
 
+func (h *Handler) addReviewer(w http.ResponseWriter, r *http.Request) error {
+	return h.changeReviewer(w, r, h.service.AddReviewer)
+}
+
+func (h *Handler) removeReviewer(w http.ResponseWriter, r *http.Request) error {
+	return h.changeReviewer(w, r, h.service.RemoveReviewer)
+}
+
+func (h *Handler) changeReviewer(w http.ResponseWriter, r *http.Request, change func(ctx context.Context, prID, reviewerID, actorID string) (entity.PullRequest, error)) error {
+	if r.Method != http.MethodPost {
+		httpserver.RespondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return nil
+	}
+	var req reviewerChangeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writePRError(w, http.StatusBadRequest, codeBadRequest, "invalid json")
+		return nil
+	}
+	pr, err := change(r.Context(), req.PullRequestID, req.ReviewerID, req.ActorID)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidInput):
+			writePRError(w, http.StatusBadRequest, codeBadRequest, "pull_request_id and reviewer_id are required")
+			return nil
+		case errors.Is(err, ErrNotFound):
+			writePRError(w, http.StatusNotFound, codeNotFound, "PR not found or reviewer not found or inactive")
+			return nil
+		case writeStatusError(w, err):
+			return nil
+		case errors.Is(err, ErrIsAuthor):
+			writePRError(w, http.StatusConflict, codeIsAuthor, "author cannot review own PR")
+			return nil
+		case errors.Is(err, ErrAssigned):
+			writePRError(w, http.StatusConflict, codeAssigned, "reviewer is already assigned to this PR")
+			return nil
+		case errors.Is(err, ErrNotAssigned):
+			writePRError(w, http.StatusConflict, codeNotAssigned, "reviewer is not assigned to this PR")
+			return nil
+		default:
+			return err
+		}
+	}
+	httpserver.RespondJSON(w, http.StatusOK, prEnvelope{PR: pr})
+	return nil
+}
+
+func (h *Handler) respond(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		httpserver.RespondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return nil
+	}
+	var req respondRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writePRError(w, http.StatusBadRequest, codeBadRequest, "invalid json")
+		return nil
+	}
+	pr, replacement, err := h.service.Respond(r.Context(), req.PullRequestID, req.ReviewerID, req.Response, req.Reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidInput):
+			writePRError(w, http.StatusBadRequest, codeBadRequest, "pull_request_id and reviewer_id are required, response must be ACCEPTED or DECLINED, declining needs a reason")
+			return nil
+		case errors.Is(err, ErrNotFound):
+			writePRError(w, http.StatusNotFound, codeNotFound, "resource not found")
+			return nil
+		case writeStatusError(w, err):
+			return nil
+		case errors.Is(err, ErrNotAssigned):
+			writePRError(w, http.StatusConflict, codeNotAssigned, "reviewer is not assigned to this PR")
+			return nil
+		case errors.Is(err, ErrNoCandidate):
+			writePRError(w, http.StatusConflict, codeNoCandidate, "no active replacement candidate in team")
+			return nil
+		case errors.Is(err, ErrAtCapacity):
+			writePRError(w, http.StatusConflict, codeAtCapacity, "all replacement candidates are at review capacity")
+			return nil
+		default:
+			return err
+		}
+	}
+	httpserver.RespondJSON(w, http.StatusOK, respondResponse{PR: pr, ReplacedBy: replacement})
+	return nil
+}
+
+func (h *Handler) review(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		httpserver.RespondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return nil
+	}
+	var req reviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writePRError(w, http.StatusBadRequest, codeBadRequest, "invalid json")
+		return nil
+	}
+	pr, err := h.service.Review(r.Context(), req.PullRequestID, req.ReviewerID, req.Decision)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidInput):
+			writePRError(w, http.StatusBadRequest, codeBadRequest, "pull_request_id and reviewer_id are required, decision must be APPROVED, CHANGES_REQUESTED or COMMENTED")
+			return nil
+		case errors.Is(err, ErrNotFound):
+			writePRError(w, http.StatusNotFound, codeNotFound, "PR not found")
+			return nil
+		case errors.Is(err, ErrMerged):
+			writePRError(w, http.StatusConflict, codePRMerged, "cannot review merged PR")
+			return nil
+		case writeStatusError(w, err):
+			return nil
+		case errors.Is(err, ErrNotAssigned):
+			writePRError(w, http.StatusConflict, codeNotAssigned, "reviewer is not assigned to this PR")
+			return nil
+		default:
+			return err
+		}
+	}
+	httpserver.RespondJSON(w, http.StatusOK, prEnvelope{PR: pr})
+	return nil
+}
+
+func (h *Handler) ready(w http.ResponseWriter, r *http.Request) error {
+	return h.transition(w, r, h.service.MarkReady)
+}
+
+func (h *Handler) close(w http.ResponseWriter, r *http.Request) error {
+	return h.transition(w, r, h.service.Close)
+}
+
+func (h *Handler) reopen(w http.ResponseWriter, r *http.Request) error {
+	return h.transition(w, r, h.service.Reopen)
+}
+
+func (h *Handler) transition(w http.ResponseWriter, r *http.Request, apply func(ctx context.Context, id string) (entity.PullRequest, error)) error {
+	if r.Method != http.MethodPost {
+		httpserver.RespondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return nil
+	}
+	var req mergeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writePRError(w, http.StatusBadRequest, codeBadRequest, "invalid json")
+		return nil
+	}
+	pr, err := apply(r.Context(), req.PullRequestID)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidInput):
+			writePRError(w, http.StatusBadRequest, codeBadRequest, "pull_request_id is required")
+			return nil
+		case errors.Is(err, ErrNotFound):
+			writePRError(w, http.StatusNotFound, codeNotFound, "PR not found")
+			return nil
+		case writeStatusError(w, err):
+			return nil
+		case errors.Is(err, ErrNoCodeOwner):
+			writePRError(w, http.StatusConflict, codeNoOwner, "no code owner of the changed files can review this PR")
+			return nil
+		case errors.Is(err, ErrAtCapacity):
+			writePRError(w, http.StatusConflict, codeAtCapacity, "all reviewer candidates are at review capacity")
+			return nil
+		default:
+			return err
+		}
+	}
+	httpserver.RespondJSON(w, http.StatusOK, prEnvelope{PR: pr})
+	return nil
+}
+
+// writeStatusError writes lifecycle errors and reports whether err was one of them.
+func writeStatusError(w http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, ErrMerged):
+		writePRError(w, http.StatusConflict, codePRMerged, "PR is already merged")
+	case errors.Is(err, ErrDraft):
+		writePRError(w, http.StatusConflict, codePRDraft, "PR is a draft")
+	case errors.Is(err, ErrClosed):
+		writePRError(w, http.StatusConflict, codePRClosed, "PR is closed")
+	case errors.Is(err, ErrNotDraft):
+		writePRError(w, http.StatusConflict, codeNotDraft, "only DRAFT PR can be marked ready")
+	case errors.Is(err, ErrNotClosed):
+		writePRError(w, http.StatusConflict, codeNotClosed, "only CLOSED PR can be reopened")
+	case errors.Is(err, ErrStatusChanged):
+		writePRError(w, http.StatusConflict, codeConflict, "PR status changed concurrently, retry")
+	default:
+		return false
+	}
+	return true
+}
+
 func writePRError(w http.ResponseWriter, status int, code, message string) {
 	var e errorEnvelope
 	e.Error.Code = code
@@ -186,6 +523,140 @@ func isPGUnique(err error) bool {
 	return false
 }
 
+func (h *Handler) get(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodGet {
+		httpserver.RespondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return nil
+	}
+	pr, err := h.service.Get(r.Context(), r.URL.Query().Get("pull_request_id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidInput):
+			writePRError(w, http.StatusBadRequest, codeBadRequest, "pull_request_id is required")
+			return nil
+		case errors.Is(err, ErrNotFound):
+			writePRError(w, http.StatusNotFound, codeNotFound, "PR not found")
+			return nil
+		default:
+			return err
+		}
+	}
+	httpserver.RespondJSON(w, http.StatusOK, prEnvelope{PR: pr})
+	return nil
+}
+
+func (h *Handler) list(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodGet {
+		httpserver.RespondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return nil
+	}
+	filter, err := parseListFilter(r.URL.Query())
+	if err != nil {
+		writePRError(w, http.StatusBadRequest, codeBadRequest, err.Error())
+		return nil
+	}
+	page, err := h.service.List(r.Context(), filter, r.URL.Query().Get("cursor"))
+	if err != nil {
+		if errors.Is(err, ErrInvalidInput) {
+			writePRError(w, http.StatusBadRequest, codeBadRequest, "unknown status, sort or order, empty time range, limit out of 1..100 or cursor issued for another sort")
+			return nil
+		}
+		return err
+	}
+	httpserver.RespondJSON(w, http.StatusOK, page)
+	return nil
+}
+
+// parseListFilter reads the list filters from the query, status is a comma separated
+// list and times are RFC 3339.
+func parseListFilter(query url.Values) (ListFilter, error) {
+	filter := ListFilter{
+		AuthorID:   query.Get("author_id"),
+		ReviewerID: query.Get("reviewer_id"),
+		Team:       query.Get("team_name"),
+		Sort:       query.Get("sort"),
+		Order:      query.Get("order"),
+	}
+	if status := query.Get("status"); status != "" {
+		for _, s := range strings.Split(status, ",") {
+			filter.Statuses = append(filter.Statuses, strings.ToUpper(strings.TrimSpace(s)))
+		}
+	}
+	for _, param := range []struct {
+		name string
+		dst  **time.Time
+	}{
+		{"created_from", &filter.CreatedFrom},
+		{"created_to", &filter.CreatedTo},
+		{"merged_from", &filter.MergedFrom},
+		{"merged_to", &filter.MergedTo},
+	} {
+		value := query.Get(param.name)
+		if value == "" {
+			continue
+		}
+		ts, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return ListFilter{}, fmt.Errorf("%s must be an RFC 3339 time", param.name)
+		}
+		*param.dst = &ts
+	}
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil {
+			return ListFilter{}, errors.New("limit must be a number")
+		}
+		filter.Limit = n
+	}
+	return filter, nil
+}
+
+func (h *Handler) timeline(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodGet {
+		httpserver.RespondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return nil
+	}
+	prID := r.URL.Query().Get("pull_request_id")
+	events, err := h.service.Timeline(r.Context(), prID)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidInput):
+			writePRError(w, http.StatusBadRequest, codeBadRequest, "pull_request_id is required")
+			return nil
+		case errors.Is(err, ErrNotFound):
+			writePRError(w, http.StatusNotFound, codeNotFound, "PR not found")
+			return nil
+		default:
+			return err
+		}
+	}
+	httpserver.RespondJSON(w, http.StatusOK, timelineResponse{PullRequestID: strings.TrimSpace(prID), Events: events})
+	return nil
+}
+
+func (h *Handler) assignmentExplain(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodGet {
+		httpserver.RespondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return nil
+	}
+	prID := r.URL.Query().Get("pull_request_id")
+	decisions, err := h.service.AssignmentExplain(r.Context(), prID)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidInput):
+			writePRError(w, http.StatusBadRequest, codeBadRequest, "pull_request_id is required")
+			return nil
+		case errors.Is(err, ErrNotFound):
+			writePRError(w, http.StatusNotFound, codeNotFound, "PR not found")
+			return nil
+		default:
+			return err
+		}
+	}
+	httpserver.RespondJSON(w, http.StatusOK, explainResponse{PullRequestID: strings.TrimSpace(prID), Decisions: decisions})
+	return nil
+}
+
 func (h *Handler) stats(w http.ResponseWriter, r *http.Request) error {
 	if r.Method != http.MethodGet {
 		httpserver.RespondError(w, http.StatusMethodNotAllowed, "method not allowed")
@@ -0,0 +1,172 @@
+package pullrequests
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+
+	"avito-internship-task/internal/entity"
+)
+
+const (
+	SortCreatedAt = "created_at"
+	// SortMergedAt orders by merge time and lists merged PRs only.
+	SortMergedAt = "merged_at"
+
+	OrderDesc = "desc"
+	OrderAsc  = "asc"
+
+	DefaultListLimit = 50
+	MaxListLimit     = 100
+)
+
+// ListFilter selects the PRs returned by List, zero values do not filter.
+// Time ranges include From and exclude To.
+type ListFilter struct {
+	Statuses   []string
+	AuthorID   string
+	ReviewerID string
+	// Team matches PRs whose author is a member of the team.
+	Team        string
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	MergedFrom  *time.Time
+	MergedTo    *time.Time
+	Sort        string
+	Order       string
+	Limit       int
+	// After continues the listing past this position, it is decoded from the page cursor.
+	After *ListPosition
+}
+
+// ListPosition is the sort key and id of the last PR of a page.
+type ListPosition struct {
+	Key           time.Time
+	PullRequestID string
+}
+
+// ListPage is a page of PRs, NextCursor is empty on the last page.
+type ListPage struct {
+	PullRequests []entity.PullRequest `json:"pull_requests"`
+	NextCursor   string               `json:"next_cursor,omitempty"`
+}
+
+// listCursor is the JSON form of an opaque cursor. It remembers the ordering it was
+// issued for so it can't be replayed against a different one.
+type listCursor struct {
+	Sort  string    `json:"s"`
+	Order string    `json:"o"`
+	Key   time.Time `json:"k"`
+	ID    string    `json:"id"`
+}
+
+// List returns a page of PRs matching filter, cursor is the NextCursor of the previous page.
+func (s *Service) List(ctx context.Context, filter ListFilter, cursor string) (ListPage, error) {
+	if err := normalizeListFilter(&filter); err != nil {
+		return ListPage{}, err
+	}
+	if cursor != "" {
+		after, err := decodeListCursor(cursor, filter.Sort, filter.Order)
+		if err != nil {
+			return ListPage{}, err
+		}
+		filter.After = &after
+	}
+
+	limit := filter.Limit
+	// one extra row tells whether there is a next page
+	filter.Limit++
+	prs, err := s.repo.List(ctx, filter)
+	if err != nil {
+		return ListPage{}, err
+	}
+	page := ListPage{PullRequests: prs}
+	if len(prs) > limit {
+		page.PullRequests = prs[:limit]
+		page.NextCursor = encodeListCursor(page.PullRequests[limit-1], filter.Sort, filter.Order)
+	}
+	return page, nil
+}
+
+// Get returns a PR with its reviewers.
+func (s *Service) Get(ctx context.Context, id string) (entity.PullRequest, error) {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return entity.PullRequest{}, ErrInvalidInput
+	}
+	pr, err := s.repo.Get(ctx, id)
+	if err != nil {
+		if isNotFound(err) {
+			return entity.PullRequest{}, ErrNotFound
+		}
+		return entity.PullRequest{}, err
+	}
+	return pr, nil
+}
+
+func normalizeListFilter(f *ListFilter) error {
+	for _, status := range f.Statuses {
+		if !slices.Contains(entity.PRStatuses, status) {
+			return ErrInvalidInput
+		}
+	}
+	f.AuthorID = strings.TrimSpace(f.AuthorID)
+	f.ReviewerID = strings.TrimSpace(f.ReviewerID)
+	f.Team = strings.TrimSpace(f.Team)
+	if emptyRange(f.CreatedFrom, f.CreatedTo) || emptyRange(f.MergedFrom, f.MergedTo) {
+		return ErrInvalidInput
+	}
+	switch f.Sort {
+	case "":
+		f.Sort = SortCreatedAt
+	case SortCreatedAt, SortMergedAt:
+	default:
+		return ErrInvalidInput
+	}
+	switch f.Order {
+	case "":
+		f.Order = OrderDesc
+	case OrderDesc, OrderAsc:
+	default:
+		return ErrInvalidInput
+	}
+	switch {
+	case f.Limit == 0:
+		f.Limit = DefaultListLimit
+	case f.Limit < 0 || f.Limit > MaxListLimit:
+		return ErrInvalidInput
+	}
+	return nil
+}
+
+func emptyRange(from, to *time.Time) bool {
+	return from != nil && to != nil && !from.Before(*to)
+}
+
+func encodeListCursor(pr entity.PullRequest, sort, order string) string {
+	c := listCursor{Sort: sort, Order: order, ID: pr.PullRequestID}
+	key := pr.CreatedAt
+	if sort == SortMergedAt {
+		key = pr.MergedAt
+	}
+	if key != nil {
+		c.Key = key.UTC()
+	}
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeListCursor(cursor, sort, order string) (ListPosition, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return ListPosition{}, ErrInvalidInput
+	}
+	var c listCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == "" || c.Sort != sort || c.Order != order {
+		return ListPosition{}, ErrInvalidInput
+	}
+	return ListPosition{Key: c.Key, PullRequestID: c.ID}, nil
+}
@@ -0,0 +1,135 @@
+package pullrequests
+
+import (
+	"context"
+	"slices"
+
+	"avito-internship-task/internal/entity"
+)
+
+// poolSource is what reviewer selection reads about teams and their members.
+type poolSource interface {
+	settings(ctx context.Context, team string) (entity.TeamSettings, error)
+	members(ctx context.Context, team string) ([]Candidate, error)
+	// limits returns the review capacities of the users that have one and their OPEN review counts.
+	limits(ctx context.Context, userIDs []string) (map[string]int, map[string]int, error)
+	strategy(settings entity.TeamSettings) ReviewerStrategy
+}
+
+// repoPools reads every pool straight from the repository.
+type repoPools struct {
+	s *Service
+}
+
+func (p repoPools) settings(ctx context.Context, team string) (entity.TeamSettings, error) {
+	return p.s.repo.GetTeamSettings(ctx, team)
+}
+
+func (p repoPools) members(ctx context.Context, team string) ([]Candidate, error) {
+	return p.s.repo.GetTeamMembers(ctx, team)
+}
+
+func (p repoPools) limits(ctx context.Context, userIDs []string) (map[string]int, map[string]int, error) {
+	capacities, err := p.s.repo.ReviewerCapacities(ctx, userIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	var loads map[string]int
+	if len(capacities) > 0 {
+		if loads, err = p.s.repo.OpenReviewCounts(ctx, userIDs); err != nil {
+			return nil, nil, err
+		}
+	}
+	return capacities, loads, nil
+}
+
+func (p repoPools) strategy(settings entity.TeamSettings) ReviewerStrategy {
+	return p.s.strategyFor(settings)
+}
+
+// cachedPools serves a bulk reassignment: every team is read once, the users being deactivated
+// count as inactive and loads are kept in memory, so the caller bumps them for each pick.
+type cachedPools struct {
+	s           *Service
+	inactive    []string
+	teams       map[string][]Candidate
+	settingsFor map[string]entity.TeamSettings
+	capacities  map[string]int
+	loads       loadMap
+	known       map[string]bool
+}
+
+func newCachedPools(s *Service, inactive []string) *cachedPools {
+	return &cachedPools{
+		s:           s,
+		inactive:    inactive,
+		teams:       make(map[string][]Candidate),
+		settingsFor: make(map[string]entity.TeamSettings),
+		capacities:  make(map[string]int),
+		loads:       make(loadMap),
+		known:       make(map[string]bool),
+	}
+}
+
+func (p *cachedPools) settings(ctx context.Context, team string) (entity.TeamSettings, error) {
+	if settings, ok := p.settingsFor[team]; ok {
+		return settings, nil
+	}
+	settings, err := p.s.repo.GetTeamSettings(ctx, team)
+	if err != nil {
+		return entity.TeamSettings{}, err
+	}
+	p.settingsFor[team] = settings
+	return settings, nil
+}
+
+func (p *cachedPools) members(ctx context.Context, team string) ([]Candidate, error) {
+	if members, ok := p.teams[team]; ok {
+		return members, nil
+	}
+	members, err := p.s.repo.GetTeamMembers(ctx, team)
+	if err != nil {
+		return nil, err
+	}
+	for i := range members {
+		if slices.Contains(p.inactive, members[i].UserID) {
+			members[i].IsActive = false
+		}
+	}
+	p.teams[team] = members
+	return members, nil
+}
+
+func (p *cachedPools) limits(ctx context.Context, userIDs []string) (map[string]int, map[string]int, error) {
+	missing := make([]string, 0, len(userIDs))
+	for _, id := range userIDs {
+		if !p.known[id] {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) > 0 {
+		capacities, err := p.s.repo.ReviewerCapacities(ctx, missing)
+		if err != nil {
+			return nil, nil, err
+		}
+		loads, err := p.s.repo.OpenReviewCounts(ctx, missing)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, id := range missing {
+			if limit, ok := capacities[id]; ok {
+				p.capacities[id] = limit
+			}
+			p.loads[id] = loads[id]
+			p.known[id] = true
+		}
+	}
+	return p.capacities, p.loads, nil
+}
+
+func (p *cachedPools) strategy(settings entity.TeamSettings) ReviewerStrategy {
+	if p.s.strategyName(settings) == StrategyLeastLoaded {
+		return NewLeastLoadedStrategy(p.loads)
+	}
+	return p.s.strategyFor(settings)
+}
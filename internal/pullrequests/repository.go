@@ -2,6 +2,10 @@ package pullrequests
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"avito-internship-task/internal/entity"
@@ -9,6 +13,26 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// OpenReview is a single reviewer assignment on an OPEN pull request.
+type OpenReview struct {
+	PullRequestID string
+	AuthorID      string
+	ReviewerID    string
+	Assigned      []string
+	CodeOwners    []string
+	Declined      []string
+}
+
+// Candidate is a team member considered for review. Absent is set during an active absence.
+type Candidate struct {
+	entity.User
+	Absent bool
+}
+
+// ReassignPlanner decides replacements for reviews of deactivated users and the decisions
+// behind them. It runs inside the deactivation transaction, returning an error rolls it back.
+type ReassignPlanner func(deactivated []string, reviews []OpenReview) ([]entity.Reassignment, []entity.AssignmentDecision, error)
+
 type Repository struct {
 	db *pgxpool.Pool
 }
@@ -27,7 +51,15 @@ func (r *Repository) GetUser(ctx context.Context, userID string) (entity.User, e
 }
 
 func (r *Repository) GetActiveTeamMembers(ctx context.Context, teamName string) ([]entity.User, error) {
-	rows, err := r.db.Query(ctx, `SELECT user_id, username, team_name, is_active FROM users WHERE team_name = $1 AND is_active = TRUE`, teamName)
+	rows, err := r.db.Query(ctx, `
+SELECT u.user_id, u.username, u.team_name, u.is_active
+FROM users u
+WHERE u.team_name = $1 AND u.is_active = TRUE
+  AND NOT EXISTS (
+    SELECT 1 FROM absences a
+    WHERE a.user_id = u.user_id AND a.starts_at <= NOW() AND a.ends_at > NOW()
+  )
+`, teamName)
 	if err != nil {
 		return nil, err
 	}
@@ -46,7 +78,69 @@ func (r *Repository) GetActiveTeamMembers(ctx context.Context, teamName string)
 	return users, nil
 }
 
-func (r *Repository) Create(ctx context.Context, pr entity.PullRequest) error {
+// GetTeamMembers returns all members of a team, including inactive and absent ones,
+// so reviewer selection can explain why they were left out.
+func (r *Repository) GetTeamMembers(ctx context.Context, teamName string) ([]Candidate, error) {
+	rows, err := r.db.Query(ctx, `
+SELECT u.user_id, u.username, u.team_name, u.is_active,
+       EXISTS (
+         SELECT 1 FROM absences a
+         WHERE a.user_id = u.user_id AND a.starts_at <= NOW() AND a.ends_at > NOW()
+       )
+FROM users u
+WHERE u.team_name = $1
+ORDER BY u.user_id
+`, teamName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	members := make([]Candidate, 0)
+	for rows.Next() {
+		var c Candidate
+		if err := rows.Scan(&c.UserID, &c.Username, &c.TeamName, &c.IsActive, &c.Absent); err != nil {
+			return nil, err
+		}
+		members = append(members, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+func (r *Repository) GetTeamSettings(ctx context.Context, teamName string) (entity.TeamSettings, error) {
+	settings := entity.TeamSettings{
+		TeamName:      teamName,
+		ReviewerCount: entity.DefaultReviewerCount,
+		ExcludedUsers: []string{},
+		FallbackTeams: []string{},
+	}
+	row := r.db.QueryRow(ctx, `
+SELECT reviewer_count, strategy, excluded_users, review_capacity, required_approvals, fallback_teams, sla_hours, sla_policy
+FROM team_settings WHERE team_name = $1
+`, teamName)
+	if err := row.Scan(&settings.ReviewerCount, &settings.Strategy, &settings.ExcludedUsers, &settings.ReviewCapacity, &settings.RequiredApprovals, &settings.FallbackTeams,
+		&settings.SLAHours, &settings.SLAPolicy); err != nil {
+		if isNotFound(err) {
+			return settings, nil
+		}
+		return entity.TeamSettings{}, err
+	}
+	return settings, nil
+}
+
+func (r *Repository) GetRepository(ctx context.Context, id string) (entity.Repository, error) {
+	var repo entity.Repository
+	err := r.db.QueryRow(ctx, `
+SELECT repository_id, owner_team, secondary_teams, created_at
+FROM repositories WHERE repository_id = $1
+`, id).Scan(&repo.RepositoryID, &repo.OwnerTeam, &repo.SecondaryTeams, &repo.CreatedAt)
+	return repo, err
+}
+
+// Create stores a PR with its reviewers, decision explains their selection and is nil for drafts.
+func (r *Repository) Create(ctx context.Context, pr entity.PullRequest, decision *entity.AssignmentDecision) error {
 	tx, err := r.db.Begin(ctx)
 	if err != nil {
 		return err
@@ -54,20 +148,34 @@ func (r *Repository) Create(ctx context.Context, pr entity.PullRequest) error {
 	defer tx.Rollback(ctx)
 
 	if _, err := tx.Exec(ctx, `
-INSERT INTO pull_requests (pull_request_id, pull_request_name, author_id, status)
-VALUES ($1, $2, $3, $4)
-`, pr.PullRequestID, pr.PullRequestName, pr.AuthorID, pr.Status); err != nil {
+INSERT INTO pull_requests (pull_request_id, pull_request_name, author_id, repository_id, status, code_owners, require_code_owner)
+VALUES ($1, $2, $3, NULLIF($4, ''), $5, COALESCE($6::text[], '{}'), $7)
+`, pr.PullRequestID, pr.PullRequestName, pr.AuthorID, pr.RepositoryID, pr.Status, pr.CodeOwners, pr.RequireCodeOwner); err != nil {
 		return err
 	}
 
 	for _, reviewer := range pr.Assigned {
 		if _, err := tx.Exec(ctx, `
-INSERT INTO pr_reviewers (pull_request_id, reviewer_id) VALUES ($1, $2)
+INSERT INTO pr_reviewers (pull_request_id, reviewer_id, team_name) VALUES ($1, $2, (SELECT team_name FROM users WHERE user_id = $2))
 `, pr.PullRequestID, reviewer); err != nil {
 			return err
 		}
 	}
 
+	events := []entity.PREvent{{
+		PullRequestID: pr.PullRequestID,
+		Type:          entity.EventCreated,
+		ActorID:       pr.AuthorID,
+		ToStatus:      pr.Status,
+	}}
+	events = append(events, assignedEvents(pr.PullRequestID, pr.Assigned, time.Time{})...)
+	if err := insertEvents(ctx, tx, events...); err != nil {
+		return err
+	}
+	if err := insertDecision(ctx, tx, decision); err != nil {
+		return err
+	}
+
 	if err := tx.Commit(ctx); err != nil {
 		return err
 	}
@@ -76,61 +184,646 @@ INSERT INTO pr_reviewers (pull_request_id, reviewer_id) VALUES ($1, $2)
 
 func (r *Repository) Get(ctx context.Context, id string) (entity.PullRequest, error) {
 	row := r.db.QueryRow(ctx, `
-SELECT pull_request_id, pull_request_name, author_id, status, merged_at
+SELECT pull_request_id, pull_request_name, author_id, COALESCE(repository_id, ''), status, code_owners, require_code_owner, created_at, merged_at, closed_at
 FROM pull_requests WHERE pull_request_id = $1
 `, id)
 	var pr entity.PullRequest
-	if err := row.Scan(&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.Status, &pr.MergedAt); err != nil {
+	if err := row.Scan(&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.RepositoryID, &pr.Status, &pr.CodeOwners, &pr.RequireCodeOwner, &pr.CreatedAt, &pr.MergedAt, &pr.ClosedAt); err != nil {
 		return entity.PullRequest{}, err
 	}
 
-	revs, err := r.loadReviewers(ctx, id)
+	reviews, err := r.loadReviewers(ctx, []string{id})
 	if err != nil {
 		return entity.PullRequest{}, err
 	}
-	pr.Assigned = revs
+	setReviews(&pr, reviews[id])
 	return pr, nil
 }
 
+// List returns up to filter.Limit PRs matching filter in keyset order of (sort key, id).
+func (r *Repository) List(ctx context.Context, filter ListFilter) ([]entity.PullRequest, error) {
+	key := "p.created_at"
+	if filter.Sort == SortMergedAt {
+		key = "p.merged_at"
+	}
+	var (
+		conds []string
+		args  []any
+	)
+	arg := func(v any) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+	if len(filter.Statuses) > 0 {
+		conds = append(conds, "p.status = ANY("+arg(filter.Statuses)+")")
+	}
+	if filter.AuthorID != "" {
+		conds = append(conds, "p.author_id = "+arg(filter.AuthorID))
+	}
+	if filter.ReviewerID != "" {
+		conds = append(conds, "EXISTS (SELECT 1 FROM pr_reviewers r WHERE r.pull_request_id = p.pull_request_id AND r.reviewer_id = "+arg(filter.ReviewerID)+")")
+	}
+	if filter.Team != "" {
+		conds = append(conds, "p.author_id IN (SELECT user_id FROM users WHERE team_name = "+arg(filter.Team)+")")
+	}
+	if filter.CreatedFrom != nil {
+		conds = append(conds, "p.created_at >= "+arg(*filter.CreatedFrom))
+	}
+	if filter.CreatedTo != nil {
+		conds = append(conds, "p.created_at < "+arg(*filter.CreatedTo))
+	}
+	if filter.MergedFrom != nil {
+		conds = append(conds, "p.merged_at >= "+arg(*filter.MergedFrom))
+	}
+	if filter.MergedTo != nil {
+		conds = append(conds, "p.merged_at < "+arg(*filter.MergedTo))
+	}
+	if filter.Sort == SortMergedAt {
+		conds = append(conds, "p.merged_at IS NOT NULL")
+	}
+	dir, cmp := "DESC", "<"
+	if filter.Order == OrderAsc {
+		dir, cmp = "ASC", ">"
+	}
+	if filter.After != nil {
+		conds = append(conds, fmt.Sprintf("(%s, p.pull_request_id) %s (%s, %s)", key, cmp, arg(filter.After.Key), arg(filter.After.PullRequestID)))
+	}
+
+	query := `
+SELECT p.pull_request_id, p.pull_request_name, p.author_id, COALESCE(p.repository_id, ''), p.status, p.code_owners, p.require_code_owner, p.created_at, p.merged_at, p.closed_at
+FROM pull_requests p`
+	if len(conds) > 0 {
+		query += "\nWHERE " + strings.Join(conds, " AND ")
+	}
+	query += fmt.Sprintf("\nORDER BY %s %s, p.pull_request_id %s\nLIMIT %s", key, dir, dir, arg(filter.Limit))
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	result := make([]entity.PullRequest, 0)
+	ids := make([]string, 0)
+	for rows.Next() {
+		var pr entity.PullRequest
+		if err := rows.Scan(&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.RepositoryID, &pr.Status, &pr.CodeOwners, &pr.RequireCodeOwner, &pr.CreatedAt, &pr.MergedAt, &pr.ClosedAt); err != nil {
+			return nil, err
+		}
+		result = append(result, pr)
+		ids = append(ids, pr.PullRequestID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	reviews, err := r.loadReviewers(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range result {
+		setReviews(&result[i], reviews[result[i].PullRequestID])
+	}
+	return result, nil
+}
+
+func setReviews(pr *entity.PullRequest, reviews []entity.ReviewerState) {
+	if reviews == nil {
+		reviews = make([]entity.ReviewerState, 0)
+	}
+	pr.Reviews = reviews
+	pr.Assigned = make([]string, 0, len(reviews))
+	for _, rev := range reviews {
+		pr.Assigned = append(pr.Assigned, rev.ReviewerID)
+	}
+}
+
+// Merge marks an OPEN PR as merged, merging a MERGED one again is a no-op.
+// It returns pgx.ErrNoRows when the PR is missing or was moved to another status.
 func (r *Repository) Merge(ctx context.Context, id string, ts time.Time) error {
-	_, err := r.db.Exec(ctx, `
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var from string
+	if err := tx.QueryRow(ctx, `SELECT status FROM pull_requests WHERE pull_request_id = $1 FOR UPDATE`, id).Scan(&from); err != nil {
+		return err
+	}
+	if from == entity.StatusMerged {
+		return nil
+	}
+	if from != entity.StatusOpen {
+		return pgx.ErrNoRows
+	}
+	if _, err := tx.Exec(ctx, `
 UPDATE pull_requests
 SET status = 'MERGED', merged_at = COALESCE(merged_at, $2)
 WHERE pull_request_id = $1
-`, id, ts)
-	return err
+`, id, ts); err != nil {
+		return err
+	}
+	if err := insertEvents(ctx, tx, entity.PREvent{
+		PullRequestID: id,
+		Type:          entity.EventMerged,
+		FromStatus:    from,
+		ToStatus:      entity.StatusMerged,
+		CreatedAt:     ts,
+	}); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
 }
 
-func (r *Repository) ReplaceReviewer(ctx context.Context, prID, oldID, newID string) error {
+// Transition moves a PR from one status to another and assigns reviewers in the same transaction,
+// decision explains their selection and is nil when no selection was made.
+// It returns pgx.ErrNoRows when the PR is not in the from status anymore.
+func (r *Repository) Transition(ctx context.Context, id, from, to string, reviewers []string, decision *entity.AssignmentDecision, ts time.Time) error {
 	tx, err := r.db.Begin(ctx)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback(ctx)
 
-	if _, err := tx.Exec(ctx, `DELETE FROM pr_reviewers WHERE pull_request_id = $1 AND reviewer_id = $2`, prID, oldID); err != nil {
+	tag, err := tx.Exec(ctx, `
+UPDATE pull_requests
+SET status = $3,
+    closed_at = CASE WHEN $3 = 'CLOSED' THEN $4 ELSE NULL END
+WHERE pull_request_id = $1 AND status = $2
+`, id, from, to, ts)
+	if err != nil {
 		return err
 	}
-	if _, err := tx.Exec(ctx, `INSERT INTO pr_reviewers (pull_request_id, reviewer_id) VALUES ($1, $2)`, prID, newID); err != nil {
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	if to == entity.StatusOpen {
+		// reviewers kept from before the PR was closed get a fresh SLA clock
+		if _, err := tx.Exec(ctx, `
+UPDATE pr_reviewers SET assigned_at = $2 WHERE pull_request_id = $1 AND review_state = 'PENDING'
+`, id, ts); err != nil {
+			return err
+		}
+	}
+	for _, reviewer := range reviewers {
+		if _, err := tx.Exec(ctx, `
+INSERT INTO pr_reviewers (pull_request_id, reviewer_id, team_name) VALUES ($1, $2, (SELECT team_name FROM users WHERE user_id = $2))
+`, id, reviewer); err != nil {
+			return err
+		}
+	}
+
+	events := []entity.PREvent{{
+		PullRequestID: id,
+		Type:          entity.EventStatusChanged,
+		FromStatus:    from,
+		ToStatus:      to,
+		CreatedAt:     ts,
+	}}
+	events = append(events, assignedEvents(id, reviewers, ts)...)
+	if err := insertEvents(ctx, tx, events...); err != nil {
+		return err
+	}
+	if err := insertDecision(ctx, tx, decision); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ReplaceReviewer swaps oldID for newID on an OPEN PR, actorID is who requested it and may be empty.
+// reason is stored in the timeline and is empty for plain reassignments.
+// It returns pgx.ErrNoRows when the PR is not OPEN anymore or oldID is not assigned.
+func (r *Repository) ReplaceReviewer(ctx context.Context, prID, oldID, newID, actorID, reason string, decision *entity.AssignmentDecision) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockOpen(ctx, tx, prID); err != nil {
+		return err
+	}
+	tag, err := tx.Exec(ctx, `DELETE FROM pr_reviewers WHERE pull_request_id = $1 AND reviewer_id = $2`, prID, oldID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	if _, err := tx.Exec(ctx, `INSERT INTO pr_reviewers (pull_request_id, reviewer_id, team_name) VALUES ($1, $2, (SELECT team_name FROM users WHERE user_id = $2))`, prID, newID); err != nil {
+		return err
+	}
+	if err := insertEvents(ctx, tx, entity.PREvent{
+		PullRequestID: prID,
+		Type:          entity.EventReviewerReplaced,
+		ActorID:       actorID,
+		ReviewerID:    newID,
+		OldReviewerID: oldID,
+		Reason:        reason,
+	}); err != nil {
+		return err
+	}
+	if err := insertDecision(ctx, tx, decision); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// AddReviewer assigns reviewerID to an OPEN PR, actorID is who requested it and may be empty,
+// reason is stored in the timeline and decision is nil when the reviewer was not selected by the service.
+// It returns pgx.ErrNoRows when the PR is not OPEN anymore and ErrAssigned for a lost race
+// with another assignment of the same reviewer.
+func (r *Repository) AddReviewer(ctx context.Context, prID, reviewerID, actorID, reason string, decision *entity.AssignmentDecision) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockOpen(ctx, tx, prID); err != nil {
+		return err
+	}
+	tag, err := tx.Exec(ctx, `
+INSERT INTO pr_reviewers (pull_request_id, reviewer_id, team_name) VALUES ($1, $2, (SELECT team_name FROM users WHERE user_id = $2))
+ON CONFLICT DO NOTHING
+`, prID, reviewerID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrAssigned
+	}
+	if err := insertEvents(ctx, tx, entity.PREvent{
+		PullRequestID: prID,
+		Type:          entity.EventReviewerAssigned,
+		ActorID:       actorID,
+		ReviewerID:    reviewerID,
+		Reason:        reason,
+	}); err != nil {
+		return err
+	}
+	if err := insertDecision(ctx, tx, decision); err != nil {
 		return err
 	}
 
 	return tx.Commit(ctx)
 }
 
-func (r *Repository) loadReviewers(ctx context.Context, prID string) ([]string, error) {
-	rows, err := r.db.Query(ctx, `SELECT reviewer_id FROM pr_reviewers WHERE pull_request_id = $1`, prID)
+// RemoveReviewer drops reviewerID from an OPEN PR without a replacement.
+// It returns pgx.ErrNoRows when the PR is not OPEN anymore or the reviewer is not assigned.
+func (r *Repository) RemoveReviewer(ctx context.Context, prID, reviewerID, actorID string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockOpen(ctx, tx, prID); err != nil {
+		return err
+	}
+	tag, err := tx.Exec(ctx, `DELETE FROM pr_reviewers WHERE pull_request_id = $1 AND reviewer_id = $2`, prID, reviewerID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	if err := insertEvents(ctx, tx, entity.PREvent{
+		PullRequestID: prID,
+		Type:          entity.EventReviewerRemoved,
+		ActorID:       actorID,
+		OldReviewerID: reviewerID,
+		Reason:        "manual",
+	}); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// AcceptReview marks the assignment of reviewerID on an OPEN PR as accepted, accepting twice keeps the first time.
+// It returns pgx.ErrNoRows when the PR is not OPEN anymore or the reviewer is not assigned.
+func (r *Repository) AcceptReview(ctx context.Context, prID, reviewerID string, ts time.Time) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockOpen(ctx, tx, prID); err != nil {
+		return err
+	}
+	var accepted bool
+	if err := tx.QueryRow(ctx, `
+SELECT response = 'ACCEPTED' FROM pr_reviewers WHERE pull_request_id = $1 AND reviewer_id = $2 FOR UPDATE
+`, prID, reviewerID).Scan(&accepted); err != nil {
+		return err
+	}
+	if accepted {
+		return nil
+	}
+	if _, err := tx.Exec(ctx, `
+UPDATE pr_reviewers SET response = 'ACCEPTED', responded_at = $3
+WHERE pull_request_id = $1 AND reviewer_id = $2
+`, prID, reviewerID, ts); err != nil {
+		return err
+	}
+	if err := insertEvents(ctx, tx, entity.PREvent{
+		PullRequestID: prID,
+		Type:          entity.EventReviewerAccepted,
+		ActorID:       reviewerID,
+		ReviewerID:    reviewerID,
+		CreatedAt:     ts,
+	}); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// DeclineReview records that reviewerID declined the PR for reason and hands the review to newID.
+// It returns pgx.ErrNoRows when the reviewer is not assigned anymore.
+func (r *Repository) DeclineReview(ctx context.Context, prID, reviewerID, newID, reason string, decision *entity.AssignmentDecision) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockOpen(ctx, tx, prID); err != nil {
+		return err
+	}
+	tag, err := tx.Exec(ctx, `DELETE FROM pr_reviewers WHERE pull_request_id = $1 AND reviewer_id = $2`, prID, reviewerID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	if _, err := tx.Exec(ctx, `
+INSERT INTO pr_declines (pull_request_id, reviewer_id, reason) VALUES ($1, $2, $3)
+ON CONFLICT (pull_request_id, reviewer_id) DO UPDATE SET reason = EXCLUDED.reason, declined_at = NOW()
+`, prID, reviewerID, reason); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `INSERT INTO pr_reviewers (pull_request_id, reviewer_id, team_name) VALUES ($1, $2, (SELECT team_name FROM users WHERE user_id = $2))`, prID, newID); err != nil {
+		return err
+	}
+	if err := insertEvents(ctx, tx, entity.PREvent{
+		PullRequestID: prID,
+		Type:          entity.EventReviewerReplaced,
+		ActorID:       reviewerID,
+		ReviewerID:    newID,
+		OldReviewerID: reviewerID,
+		Reason:        "declined: " + reason,
+	}); err != nil {
+		return err
+	}
+	if err := insertDecision(ctx, tx, decision); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// DeclinedReviewers returns everyone who declined to review a PR.
+func (r *Repository) DeclinedReviewers(ctx context.Context, prID string) ([]string, error) {
+	rows, err := r.db.Query(ctx, `SELECT reviewer_id FROM pr_declines WHERE pull_request_id = $1 ORDER BY reviewer_id`, prID)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
+
+// lockOpen locks the row of an OPEN PR for the rest of tx, pgx.ErrNoRows means it is not OPEN.
+func lockOpen(ctx context.Context, tx pgx.Tx, prID string) error {
+	var id string
+	return tx.QueryRow(ctx, `
+SELECT pull_request_id FROM pull_requests WHERE pull_request_id = $1 AND status = 'OPEN' FOR UPDATE
+`, prID).Scan(&id)
+}
+
+// SetReviewState records the review of reviewerID on an OPEN PR.
+// It returns pgx.ErrNoRows when the PR is not OPEN anymore or the reviewer is not assigned.
+func (r *Repository) SetReviewState(ctx context.Context, prID, reviewerID, state string, ts time.Time) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockOpen(ctx, tx, prID); err != nil {
+		return err
+	}
+	tag, err := tx.Exec(ctx, `
+UPDATE pr_reviewers SET review_state = $3, reviewed_at = $4
+WHERE pull_request_id = $1 AND reviewer_id = $2
+`, prID, reviewerID, state, ts)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	if err := insertEvents(ctx, tx, entity.PREvent{
+		PullRequestID: prID,
+		Type:          entity.EventReviewed,
+		ActorID:       reviewerID,
+		ReviewerID:    reviewerID,
+		Reason:        state,
+		CreatedAt:     ts,
+	}); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Events returns the timeline of a PR, oldest first.
+func (r *Repository) Events(ctx context.Context, prID string) ([]entity.PREvent, error) {
+	rows, err := r.db.Query(ctx, `
+SELECT event_id, pull_request_id, event_type, COALESCE(actor_id, ''), COALESCE(reviewer_id, ''),
+       COALESCE(old_reviewer_id, ''), COALESCE(from_status, ''), COALESCE(to_status, ''),
+       COALESCE(reason, ''), created_at
+FROM pr_events WHERE pull_request_id = $1
+ORDER BY event_id
+`, prID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	result := make([]string, 0)
+	events := make([]entity.PREvent, 0)
 	for rows.Next() {
-		var id string
-		if err := rows.Scan(&id); err != nil {
+		var ev entity.PREvent
+		if err := rows.Scan(&ev.EventID, &ev.PullRequestID, &ev.Type, &ev.ActorID, &ev.ReviewerID,
+			&ev.OldReviewerID, &ev.FromStatus, &ev.ToStatus, &ev.Reason, &ev.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, ev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// Decisions returns the recorded reviewer selections of a PR, oldest first.
+func (r *Repository) Decisions(ctx context.Context, prID string) ([]entity.AssignmentDecision, error) {
+	rows, err := r.db.Query(ctx, `
+SELECT decision_id, decision, created_at
+FROM assignment_decisions WHERE pull_request_id = $1
+ORDER BY decision_id
+`, prID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	decisions := make([]entity.AssignmentDecision, 0)
+	for rows.Next() {
+		var (
+			id        int64
+			payload   []byte
+			createdAt time.Time
+		)
+		if err := rows.Scan(&id, &payload, &createdAt); err != nil {
+			return nil, err
+		}
+		var d entity.AssignmentDecision
+		if err := json.Unmarshal(payload, &d); err != nil {
+			return nil, err
+		}
+		d.DecisionID = id
+		d.CreatedAt = createdAt
+		decisions = append(decisions, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return decisions, nil
+}
+
+func insertDecision(ctx context.Context, tx pgx.Tx, decision *entity.AssignmentDecision) error {
+	if decision == nil {
+		return nil
+	}
+	payload, err := json.Marshal(decision)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, `INSERT INTO assignment_decisions (pull_request_id, decision) VALUES ($1, $2)`, decision.PullRequestID, payload)
+	return err
+}
+
+// insertEvents appends events to pr_events, a zero CreatedAt means the database NOW().
+func insertEvents(ctx context.Context, tx pgx.Tx, events ...entity.PREvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	batch := &pgx.Batch{}
+	for _, ev := range events {
+		if err := queueEvent(batch, ev); err != nil {
+			return err
+		}
+	}
+	return tx.SendBatch(ctx, batch).Close()
+}
+
+// queueEvent queues the timeline insert and, for events published to webhooks,
+// the outbox message, so both are committed together with the change itself.
+func queueEvent(batch *pgx.Batch, ev entity.PREvent) error {
+	var createdAt *time.Time
+	if !ev.CreatedAt.IsZero() {
+		createdAt = &ev.CreatedAt
+	}
+	batch.Queue(`
+INSERT INTO pr_events (pull_request_id, event_type, actor_id, reviewer_id, old_reviewer_id, from_status, to_status, reason, created_at)
+VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), NULLIF($5, ''), NULLIF($6, ''), NULLIF($7, ''), NULLIF($8, ''), COALESCE($9::timestamptz, NOW()))
+`, ev.PullRequestID, ev.Type, ev.ActorID, ev.ReviewerID, ev.OldReviewerID, ev.FromStatus, ev.ToStatus, ev.Reason, createdAt)
+
+	topic := outboxTopic(ev.Type)
+	if topic == "" {
+		return nil
+	}
+	occurredAt := ev.CreatedAt
+	if occurredAt.IsZero() {
+		occurredAt = time.Now().UTC()
+	}
+	payload, err := json.Marshal(entity.OutboxMessage{
+		Event:         topic,
+		OccurredAt:    occurredAt,
+		PullRequestID: ev.PullRequestID,
+		ActorID:       ev.ActorID,
+		ReviewerID:    ev.ReviewerID,
+		OldReviewerID: ev.OldReviewerID,
+		Status:        ev.ToStatus,
+		Reason:        ev.Reason,
+	})
+	if err != nil {
+		return err
+	}
+	batch.Queue(`INSERT INTO outbox (event_type, payload) VALUES ($1, $2)`, topic, payload)
+	return nil
+}
+
+func outboxTopic(eventType string) string {
+	switch eventType {
+	case entity.EventCreated:
+		return entity.WebhookPRCreated
+	case entity.EventReviewerAssigned:
+		return entity.WebhookReviewerAssigned
+	case entity.EventReviewerReplaced:
+		return entity.WebhookReviewerReassigned
+	case entity.EventReviewerRemoved:
+		return entity.WebhookReviewerRemoved
+	case entity.EventMerged:
+		return entity.WebhookPRMerged
+	case entity.EventSLABreached:
+		return entity.WebhookReviewSLABreached
+	}
+	return ""
+}
+
+func assignedEvents(prID string, reviewers []string, ts time.Time) []entity.PREvent {
+	events := make([]entity.PREvent, 0, len(reviewers))
+	for _, reviewer := range reviewers {
+		events = append(events, entity.PREvent{
+			PullRequestID: prID,
+			Type:          entity.EventReviewerAssigned,
+			ReviewerID:    reviewer,
+			CreatedAt:     ts,
+		})
+	}
+	return events
+}
+
+// loadReviewers returns the reviewers of each of the PRs keyed by PR id.
+func (r *Repository) loadReviewers(ctx context.Context, prIDs []string) (map[string][]entity.ReviewerState, error) {
+	rows, err := r.db.Query(ctx, `
+SELECT r.pull_request_id, r.reviewer_id, r.review_state, r.reviewed_at, r.reviewer_id = ANY(p.code_owners), COALESCE(r.team_name, ''),
+       r.response, r.responded_at, r.assigned_at
+FROM pr_reviewers r
+JOIN pull_requests p ON p.pull_request_id = r.pull_request_id
+WHERE r.pull_request_id = ANY($1)
+ORDER BY r.pull_request_id, r.reviewer_id
+`, prIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	result := make(map[string][]entity.ReviewerState, len(prIDs))
+	for rows.Next() {
+		var (
+			prID string
+			rev  entity.ReviewerState
+		)
+		if err := rows.Scan(&prID, &rev.ReviewerID, &rev.State, &rev.ReviewedAt, &rev.CodeOwner, &rev.Team,
+			&rev.Response, &rev.RespondedAt, &rev.AssignedAt); err != nil {
 			return nil, err
 		}
-		result = append(result, id)
+		result[prID] = append(result[prID], rev)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, err
@@ -162,3 +855,235 @@ func (r *Repository) StatsAssignments(ctx context.Context) (map[string]int, erro
 	}
 	return stats, nil
 }
+
+func (r *Repository) OpenReviewCounts(ctx context.Context, userIDs []string) (map[string]int, error) {
+	rows, err := r.db.Query(ctx, `
+SELECT r.reviewer_id, COUNT(*)
+FROM pr_reviewers r
+JOIN pull_requests pr ON pr.pull_request_id = r.pull_request_id
+WHERE pr.status = 'OPEN' AND r.reviewer_id = ANY($1)
+GROUP BY r.reviewer_id
+`, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	counts := make(map[string]int, len(userIDs))
+	for rows.Next() {
+		var id string
+		var cnt int
+		if err := rows.Scan(&id, &cnt); err != nil {
+			return nil, err
+		}
+		counts[id] = cnt
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+func (r *Repository) DeactivateUsers(ctx context.Context, teamName string, userIDs []string, plan ReassignPlanner) ([]string, []entity.Reassignment, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var exists bool
+	if err := tx.QueryRow(ctx, `SELECT TRUE FROM teams WHERE name = $1`, teamName).Scan(&exists); err != nil {
+		return nil, nil, err
+	}
+
+	rows, err := tx.Query(ctx, `
+UPDATE users SET is_active = FALSE
+WHERE team_name = $1 AND (cardinality($2::text[]) = 0 OR user_id = ANY($2))
+RETURNING user_id
+`, teamName, userIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	deactivated, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, err = tx.Query(ctx, `
+SELECT pr.pull_request_id, pr.author_id, r.reviewer_id,
+       ARRAY(SELECT x.reviewer_id FROM pr_reviewers x WHERE x.pull_request_id = pr.pull_request_id),
+       pr.code_owners,
+       ARRAY(SELECT d.reviewer_id FROM pr_declines d WHERE d.pull_request_id = pr.pull_request_id)
+FROM pr_reviewers r
+JOIN pull_requests pr ON pr.pull_request_id = r.pull_request_id
+WHERE pr.status = 'OPEN' AND r.reviewer_id = ANY($1)
+ORDER BY pr.pull_request_id, r.reviewer_id
+FOR UPDATE OF pr
+`, deactivated)
+	if err != nil {
+		return nil, nil, err
+	}
+	reviews := make([]OpenReview, 0)
+	for rows.Next() {
+		var rev OpenReview
+		if err := rows.Scan(&rev.PullRequestID, &rev.AuthorID, &rev.ReviewerID, &rev.Assigned, &rev.CodeOwners, &rev.Declined); err != nil {
+			rows.Close()
+			return nil, nil, err
+		}
+		reviews = append(reviews, rev)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	reassignments, decisions, err := plan(deactivated, reviews)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	batch := &pgx.Batch{}
+	for _, decision := range decisions {
+		payload, err := json.Marshal(decision)
+		if err != nil {
+			return nil, nil, err
+		}
+		batch.Queue(`INSERT INTO assignment_decisions (pull_request_id, decision) VALUES ($1, $2)`, decision.PullRequestID, payload)
+	}
+	for _, ra := range reassignments {
+		batch.Queue(`DELETE FROM pr_reviewers WHERE pull_request_id = $1 AND reviewer_id = $2`, ra.PullRequestID, ra.OldReviewerID)
+		ev := entity.PREvent{
+			PullRequestID: ra.PullRequestID,
+			Type:          entity.EventReviewerRemoved,
+			OldReviewerID: ra.OldReviewerID,
+			Reason:        "deactivated",
+		}
+		if ra.NewReviewerID != "" {
+			batch.Queue(`INSERT INTO pr_reviewers (pull_request_id, reviewer_id, team_name) VALUES ($1, $2, (SELECT team_name FROM users WHERE user_id = $2))`, ra.PullRequestID, ra.NewReviewerID)
+			ev.Type = entity.EventReviewerReplaced
+			ev.ReviewerID = ra.NewReviewerID
+		}
+		if err := queueEvent(batch, ev); err != nil {
+			return nil, nil, err
+		}
+	}
+	if batch.Len() > 0 {
+		if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, err
+	}
+	return deactivated, reassignments, nil
+}
+
+// ReviewerCapacities returns effective review limits, users without a limit are omitted.
+func (r *Repository) ReviewerCapacities(ctx context.Context, userIDs []string) (map[string]int, error) {
+	rows, err := r.db.Query(ctx, `
+SELECT u.user_id, COALESCE(u.review_capacity, s.review_capacity)
+FROM users u
+LEFT JOIN team_settings s ON s.team_name = u.team_name
+WHERE u.user_id = ANY($1) AND COALESCE(u.review_capacity, s.review_capacity) IS NOT NULL
+`, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	capacities := make(map[string]int)
+	for rows.Next() {
+		var id string
+		var limit int
+		if err := rows.Scan(&id, &limit); err != nil {
+			return nil, err
+		}
+		capacities[id] = limit
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return capacities, nil
+}
+
+func (r *Repository) BreachCandidates(ctx context.Context, now time.Time, after *Breach, limit int) ([]Breach, error) {
+	var afterAt *time.Time
+	var afterPR, afterReviewer string
+	if after != nil {
+		afterAt, afterPR, afterReviewer = &after.AssignedAt, after.PullRequestID, after.ReviewerID
+	}
+	rows, err := r.db.Query(ctx, `
+SELECT r.pull_request_id, r.reviewer_id, s.team_name, r.assigned_at, s.sla_hours, s.sla_policy
+FROM pr_reviewers r
+JOIN pull_requests p ON p.pull_request_id = r.pull_request_id
+JOIN users u ON u.user_id = r.reviewer_id
+JOIN team_settings s ON s.team_name = COALESCE(r.team_name, u.team_name)
+WHERE p.status = 'OPEN'
+  AND r.review_state = 'PENDING'
+  AND s.sla_hours IS NOT NULL
+  AND r.assigned_at <= $1::timestamptz - make_interval(hours => s.sla_hours)
+  AND NOT EXISTS (
+      SELECT 1 FROM sla_escalations e
+      WHERE e.pull_request_id = r.pull_request_id AND e.reviewer_id = r.reviewer_id AND e.assigned_at = r.assigned_at
+  )
+  AND ($3::timestamptz IS NULL OR (r.assigned_at, r.pull_request_id, r.reviewer_id) > ($3, $4, $5))
+ORDER BY r.assigned_at, r.pull_request_id, r.reviewer_id
+LIMIT $2
+`, now, limit, afterAt, afterPR, afterReviewer)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	result := make([]Breach, 0)
+	for rows.Next() {
+		var b Breach
+		if err := rows.Scan(&b.PullRequestID, &b.ReviewerID, &b.TeamName, &b.AssignedAt, &b.SLAHours, &b.Policy); err != nil {
+			return nil, err
+		}
+		result = append(result, b)
+	}
+	return result, rows.Err()
+}
+
+// ClaimEscalation stores the escalation together with its SLA_BREACHED timeline event.
+func (r *Repository) ClaimEscalation(ctx context.Context, esc entity.Escalation) (int64, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	var id int64
+	err = tx.QueryRow(ctx, `
+INSERT INTO sla_escalations (pull_request_id, reviewer_id, team_name, policy, assigned_at, created_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (pull_request_id, reviewer_id, assigned_at) DO NOTHING
+RETURNING escalation_id
+`, esc.PullRequestID, esc.ReviewerID, esc.TeamName, esc.Policy, esc.AssignedAt, esc.CreatedAt).Scan(&id)
+	if err != nil {
+		if isNotFound(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	if err := insertEvents(ctx, tx, entity.PREvent{
+		PullRequestID: esc.PullRequestID,
+		Type:          entity.EventSLABreached,
+		ReviewerID:    esc.ReviewerID,
+		Reason:        esc.Policy,
+		CreatedAt:     esc.CreatedAt,
+	}); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (r *Repository) CompleteEscalation(ctx context.Context, id int64, newReviewerID, errText string) error {
+	_, err := r.db.Exec(ctx, `
+UPDATE sla_escalations SET new_reviewer_id = NULLIF($2, ''), error = NULLIF($3, '')
+WHERE escalation_id = $1
+`, id, newReviewerID, errText)
+	return err
+}
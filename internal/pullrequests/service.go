@@ -3,7 +3,9 @@ package pullrequests
 import (
 	"context"
 	"errors"
+	"fmt"
 	"math/rand"
+	"slices"
 	"strings"
 	"time"
 
@@ -12,80 +14,229 @@ import (
 )
 
 var (
-	ErrInvalidInput = errors.New("invalid input")
-	ErrNotFound     = errors.New("not found")
-	ErrExists       = errors.New("pr exists")
-	ErrMerged       = errors.New("pr merged")
-	ErrNotAssigned  = errors.New("not assigned")
-	ErrNoCandidate  = errors.New("no candidate")
+	ErrInvalidInput  = errors.New("invalid input")
+	ErrNotFound      = errors.New("not found")
+	ErrExists        = errors.New("pr exists")
+	ErrMerged        = errors.New("pr merged")
+	ErrNotAssigned   = errors.New("not assigned")
+	ErrNoCandidate   = errors.New("no candidate")
+	ErrAtCapacity    = errors.New("all candidates at capacity")
+	ErrNotApproved   = errors.New("not approved")
+	ErrDraft         = errors.New("pr is draft")
+	ErrClosed        = errors.New("pr closed")
+	ErrNotDraft      = errors.New("pr is not draft")
+	ErrNotClosed     = errors.New("pr is not closed")
+	ErrStatusChanged = errors.New("pr status changed concurrently")
+	ErrNoCodeOwner   = errors.New("no eligible code owner")
+	ErrNoRepository  = errors.New("repository not found")
+	ErrIsAuthor      = errors.New("reviewer is the author")
+	ErrAssigned      = errors.New("already assigned")
 )
 
+// ApprovalError reports why a merge was rejected by the approval policy.
+type ApprovalError struct {
+	Required int
+	Approved int
+	// Missing lists assigned reviewers that have not approved yet.
+	Missing []string
+}
+
+func (e *ApprovalError) Error() string {
+	return fmt.Sprintf("not approved: %d of %d required approvals", e.Approved, e.Required)
+}
+
+func (e *ApprovalError) Unwrap() error {
+	return ErrNotApproved
+}
+
 type Service struct {
-	repo Repo
-	rand *rand.Rand
+	repo       Repo
+	rand       *rand.Rand
+	strategies map[string]ReviewerStrategy
+	strategy   string
+	codeOwners CodeOwnerResolver
+
+	requiredApprovals int
+}
+
+type Options struct {
+	Strategy string
+	// RequiredApprovals is the default number of approvals needed to merge, teams may override it.
+	RequiredApprovals int
+	// CodeOwners resolves the owners of changed files, without it they are not considered.
+	CodeOwners CodeOwnerResolver
+}
+
+// CodeOwnerResolver returns the user ids owning any of files according to CODEOWNERS
+// content, or to the CODEOWNERS stored for repository when content is empty.
+type CodeOwnerResolver interface {
+	Owners(ctx context.Context, repository, content string, files []string) ([]string, error)
+}
+
+// CodeOwnersRequest describes the changes of a new PR for CODEOWNERS-aware selection.
+type CodeOwnersRequest struct {
+	Repository string
+	Content    string
+	Files      []string
+	// Require fails the PR creation when no code owner can be assigned.
+	Require bool
 }
 
 type Repo interface {
 	GetUser(ctx context.Context, userID string) (entity.User, error)
-	GetActiveTeamMembers(ctx context.Context, teamName string) ([]entity.User, error)
-	Create(ctx context.Context, pr entity.PullRequest) error
+	GetTeamMembers(ctx context.Context, teamName string) ([]Candidate, error)
+	GetTeamSettings(ctx context.Context, teamName string) (entity.TeamSettings, error)
+	GetRepository(ctx context.Context, id string) (entity.Repository, error)
+	Create(ctx context.Context, pr entity.PullRequest, decision *entity.AssignmentDecision) error
 	Get(ctx context.Context, id string) (entity.PullRequest, error)
+	List(ctx context.Context, filter ListFilter) ([]entity.PullRequest, error)
 	Merge(ctx context.Context, id string, ts time.Time) error
-	ReplaceReviewer(ctx context.Context, prID, oldID, newID string) error
+	ReplaceReviewer(ctx context.Context, prID, oldID, newID, actorID, reason string, decision *entity.AssignmentDecision) error
+	AddReviewer(ctx context.Context, prID, reviewerID, actorID, reason string, decision *entity.AssignmentDecision) error
+	AcceptReview(ctx context.Context, prID, reviewerID string, ts time.Time) error
+	DeclineReview(ctx context.Context, prID, reviewerID, newID, reason string, decision *entity.AssignmentDecision) error
+	DeclinedReviewers(ctx context.Context, prID string) ([]string, error)
+	RemoveReviewer(ctx context.Context, prID, reviewerID, actorID string) error
+	Events(ctx context.Context, prID string) ([]entity.PREvent, error)
+	Decisions(ctx context.Context, prID string) ([]entity.AssignmentDecision, error)
 	StatsAssignments(ctx context.Context) (map[string]int, error)
+	SetReviewState(ctx context.Context, prID, reviewerID, state string, ts time.Time) error
+	Transition(ctx context.Context, id, from, to string, reviewers []string, decision *entity.AssignmentDecision, ts time.Time) error
+	OpenReviewCounts(ctx context.Context, userIDs []string) (map[string]int, error)
+	ReviewerCapacities(ctx context.Context, userIDs []string) (map[string]int, error)
+	DeactivateUsers(ctx context.Context, teamName string, userIDs []string, plan ReassignPlanner) ([]string, []entity.Reassignment, error)
 }
 
-func NewService(repo Repo) *Service {
+func NewService(repo Repo, opts Options) *Service {
+	strategy := opts.Strategy
+	if !IsKnownStrategy(strategy) {
+		strategy = StrategyRandom
+	}
 	return &Service{
 		repo: repo,
 		rand: rand.New(rand.NewSource(time.Now().UnixNano())),
+		strategies: map[string]ReviewerStrategy{
+			StrategyRandom:      RandomStrategy{},
+			StrategyLeastLoaded: NewLeastLoadedStrategy(repo),
+		},
+		strategy:          strategy,
+		codeOwners:        opts.CodeOwners,
+		requiredApprovals: opts.RequiredApprovals,
 	}
 }
 
 func (s *Service) Create(ctx context.Context, pr entity.PullRequest) (entity.PullRequest, error) {
+	return s.CreateWithCodeOwners(ctx, pr, CodeOwnersRequest{})
+}
+
+// CreateWithCodeOwners creates a PR whose reviewers are preferably, or with Require
+// necessarily, taken from the code owners of the changed files.
+func (s *Service) CreateWithCodeOwners(ctx context.Context, pr entity.PullRequest, req CodeOwnersRequest) (entity.PullRequest, error) {
+	pr, decision, err := s.prepare(ctx, pr, req, entity.DecisionCreate)
+	if err != nil {
+		return entity.PullRequest{}, err
+	}
+	if err := s.repo.Create(ctx, pr, decision); err != nil {
+		if isUniqueViolation(err) {
+			return entity.PullRequest{}, ErrExists
+		}
+		return entity.PullRequest{}, err
+	}
+	return pr, nil
+}
+
+// Preview runs the validation and reviewer selection of CreateWithCodeOwners without storing
+// anything. The PR is previewed as OPEN, even when it would be created as a draft, and holds
+// the proposed reviewers; the decision holds the candidate pool they were picked from.
+func (s *Service) Preview(ctx context.Context, pr entity.PullRequest, req CodeOwnersRequest) (entity.PullRequest, entity.AssignmentDecision, error) {
+	pr.Status = entity.StatusOpen
+	pr, decision, err := s.prepare(ctx, pr, req, entity.DecisionPreview)
+	if err != nil {
+		return entity.PullRequest{}, entity.AssignmentDecision{}, err
+	}
+	if _, err := s.repo.Get(ctx, pr.PullRequestID); err == nil {
+		return entity.PullRequest{}, entity.AssignmentDecision{}, ErrExists
+	} else if !isNotFound(err) {
+		return entity.PullRequest{}, entity.AssignmentDecision{}, err
+	}
+	return pr, *decision, nil
+}
+
+// prepare validates a new PR, resolves its code owners and selects its reviewers, drafts get
+// none and a nil decision. It is shared by Create and Preview and must not write anything.
+func (s *Service) prepare(ctx context.Context, pr entity.PullRequest, req CodeOwnersRequest, trigger string) (entity.PullRequest, *entity.AssignmentDecision, error) {
 	pr.PullRequestID = strings.TrimSpace(pr.PullRequestID)
 	pr.PullRequestName = strings.TrimSpace(pr.PullRequestName)
 	pr.AuthorID = strings.TrimSpace(pr.AuthorID)
+	pr.RepositoryID = strings.TrimSpace(pr.RepositoryID)
 	if pr.PullRequestID == "" || pr.PullRequestName == "" || pr.AuthorID == "" {
-		return entity.PullRequest{}, ErrInvalidInput
+		return entity.PullRequest{}, nil, ErrInvalidInput
 	}
 
 	author, err := s.repo.GetUser(ctx, pr.AuthorID)
 	if err != nil {
 		if isNotFound(err) {
-			return entity.PullRequest{}, ErrNotFound
+			return entity.PullRequest{}, nil, ErrNotFound
 		}
-		return entity.PullRequest{}, err
+		return entity.PullRequest{}, nil, err
 	}
 	if !author.IsActive {
-		return entity.PullRequest{}, ErrNotFound
+		return entity.PullRequest{}, nil, ErrNotFound
 	}
-
-	candidates, err := s.repo.GetActiveTeamMembers(ctx, author.TeamName)
-	if err != nil {
-		return entity.PullRequest{}, err
+	if pr.RepositoryID != "" {
+		if _, err := s.repository(ctx, pr.RepositoryID); err != nil {
+			return entity.PullRequest{}, nil, err
+		}
 	}
-	filtered := make([]entity.User, 0, len(candidates))
-	for _, u := range candidates {
-		if u.UserID == pr.AuthorID {
-			continue
+	if len(req.Files) > 0 && s.codeOwners != nil {
+		if req.Repository == "" {
+			req.Repository = pr.RepositoryID
+		}
+		owners, err := s.codeOwners.Owners(ctx, req.Repository, req.Content, req.Files)
+		if err != nil {
+			return entity.PullRequest{}, nil, err
 		}
-		filtered = append(filtered, u)
+		pr.CodeOwners = slices.DeleteFunc(owners, func(id string) bool { return id == author.UserID })
+		pr.RequireCodeOwner = req.Require
+	}
+	if pr.CodeOwners == nil {
+		pr.CodeOwners = []string{}
 	}
-	selected := s.pickRandom(filtered, 2)
-	pr.Status = "OPEN"
-	pr.Assigned = selected
 
-	if err := s.repo.Create(ctx, pr); err != nil {
-		if isUniqueViolation(err) {
-			return entity.PullRequest{}, ErrExists
+	// Drafts get no reviewers until they are marked ready.
+	var sel *selection
+	if pr.Status != entity.StatusDraft {
+		pr.Status = entity.StatusOpen
+		sel, err = s.selectReviewers(ctx, author, pr, trigger)
+		if err != nil {
+			return entity.PullRequest{}, nil, err
 		}
-		return entity.PullRequest{}, err
 	}
-	return pr, nil
+	var (
+		teams    map[string]string
+		decision *entity.AssignmentDecision
+	)
+	pr.Assigned = []string{}
+	if sel != nil {
+		pr.Assigned = sel.decision.Selected
+		teams = sel.origin
+		decision = &sel.decision
+	}
+	pr.Reviews = pendingReviews(pr.Assigned, pr.CodeOwners, teams)
+	return pr, decision, nil
 }
 
 func (s *Service) Merge(ctx context.Context, id string) (entity.PullRequest, error) {
+	return s.merge(ctx, id, true)
+}
+
+// MarkMerged records a merge that already happened on the code host,
+// so the approval gate is not applied.
+func (s *Service) MarkMerged(ctx context.Context, id string) (entity.PullRequest, error) {
+	return s.merge(ctx, id, false)
+}
+
+func (s *Service) merge(ctx context.Context, id string, gated bool) (entity.PullRequest, error) {
 	id = strings.TrimSpace(id)
 	if id == "" {
 		return entity.PullRequest{}, ErrInvalidInput
@@ -97,105 +248,805 @@ func (s *Service) Merge(ctx context.Context, id string) (entity.PullRequest, err
 		}
 		return entity.PullRequest{}, err
 	}
-	if pr.Status == "MERGED" {
+	switch pr.Status {
+	case entity.StatusMerged:
 		return pr, nil
+	case entity.StatusDraft:
+		return entity.PullRequest{}, ErrDraft
+	case entity.StatusClosed:
+		return entity.PullRequest{}, ErrClosed
+	}
+	if gated {
+		if err := s.checkApprovals(ctx, pr); err != nil {
+			return entity.PullRequest{}, err
+		}
 	}
 	now := time.Now().UTC()
 	if err := s.repo.Merge(ctx, id, now); err != nil {
+		if isNotFound(err) {
+			return entity.PullRequest{}, ErrStatusChanged
+		}
 		return entity.PullRequest{}, err
 	}
-	pr.Status = "MERGED"
+	pr.Status = entity.StatusMerged
 	pr.MergedAt = &now
 	return pr, nil
 }
 
-func (s *Service) Reassign(ctx context.Context, prID, oldReviewer string) (entity.PullRequest, string, error) {
+// MarkReady turns a DRAFT into an OPEN PR and assigns reviewers at that moment.
+func (s *Service) MarkReady(ctx context.Context, id string) (entity.PullRequest, error) {
+	pr, err := s.getForTransition(ctx, id)
+	if err != nil {
+		return entity.PullRequest{}, err
+	}
+	if pr.Status != entity.StatusDraft {
+		if err := statusError(pr.Status); err != nil {
+			return entity.PullRequest{}, err
+		}
+		return entity.PullRequest{}, ErrNotDraft
+	}
+	return s.openWithReviewers(ctx, pr)
+}
+
+// Close abandons an OPEN or DRAFT PR, closing an already CLOSED PR returns its state.
+func (s *Service) Close(ctx context.Context, id string) (entity.PullRequest, error) {
+	pr, err := s.getForTransition(ctx, id)
+	if err != nil {
+		return entity.PullRequest{}, err
+	}
+	switch pr.Status {
+	case entity.StatusClosed:
+		return pr, nil
+	case entity.StatusMerged:
+		return entity.PullRequest{}, ErrMerged
+	}
+	if err := s.repo.Transition(ctx, id, pr.Status, entity.StatusClosed, nil, nil, time.Now().UTC()); err != nil {
+		return entity.PullRequest{}, s.transitionErr(err)
+	}
+	return s.repo.Get(ctx, id)
+}
+
+// Reopen moves a CLOSED PR back to OPEN. It keeps its reviewers and assigns new ones
+// only if the PR was closed without any.
+func (s *Service) Reopen(ctx context.Context, id string) (entity.PullRequest, error) {
+	pr, err := s.getForTransition(ctx, id)
+	if err != nil {
+		return entity.PullRequest{}, err
+	}
+	if pr.Status != entity.StatusClosed {
+		if pr.Status == entity.StatusMerged {
+			return entity.PullRequest{}, ErrMerged
+		}
+		return entity.PullRequest{}, ErrNotClosed
+	}
+	return s.openWithReviewers(ctx, pr)
+}
+
+func (s *Service) getForTransition(ctx context.Context, id string) (entity.PullRequest, error) {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return entity.PullRequest{}, ErrInvalidInput
+	}
+	pr, err := s.repo.Get(ctx, id)
+	if err != nil {
+		if isNotFound(err) {
+			return entity.PullRequest{}, ErrNotFound
+		}
+		return entity.PullRequest{}, err
+	}
+	return pr, nil
+}
+
+func (s *Service) openWithReviewers(ctx context.Context, pr entity.PullRequest) (entity.PullRequest, error) {
+	var (
+		reviewers []string
+		decision  *entity.AssignmentDecision
+	)
+	if len(pr.Assigned) == 0 {
+		author, err := s.repo.GetUser(ctx, pr.AuthorID)
+		if err != nil {
+			if isNotFound(err) {
+				return entity.PullRequest{}, ErrNotFound
+			}
+			return entity.PullRequest{}, err
+		}
+		trigger := entity.DecisionReady
+		if pr.Status == entity.StatusClosed {
+			trigger = entity.DecisionReopen
+		}
+		sel, err := s.selectReviewers(ctx, author, pr, trigger)
+		if err != nil {
+			return entity.PullRequest{}, err
+		}
+		reviewers = sel.decision.Selected
+		decision = &sel.decision
+	}
+	if err := s.repo.Transition(ctx, pr.PullRequestID, pr.Status, entity.StatusOpen, reviewers, decision, time.Now().UTC()); err != nil {
+		return entity.PullRequest{}, s.transitionErr(err)
+	}
+	return s.repo.Get(ctx, pr.PullRequestID)
+}
+
+// transitionErr maps a lost race on the PR status to a conflict.
+func (s *Service) transitionErr(err error) error {
+	if isNotFound(err) {
+		return ErrStatusChanged
+	}
+	return err
+}
+
+// statusError returns the error for operations that need an OPEN PR.
+func statusError(status string) error {
+	switch status {
+	case entity.StatusMerged:
+		return ErrMerged
+	case entity.StatusClosed:
+		return ErrClosed
+	case entity.StatusDraft:
+		return ErrDraft
+	}
+	return nil
+}
+
+// selectReviewers picks reviewers for a new PR, code owners of the PR first. They come from the
+// owning team of the PR repository, or the author's team for PRs without one; secondary teams of
+// the repository fill the slots the owning team cannot, then the fallback teams of the owning team
+// in their order. Reviewers at capacity are skipped and the PR may get fewer reviewers, but when
+// candidates existed and all of them are full ErrAtCapacity is returned. The returned selection
+// holds the picked reviewers and how they were chosen.
+func (s *Service) selectReviewers(ctx context.Context, author entity.User, pr entity.PullRequest, trigger string) (*selection, error) {
+	teams := []string{author.TeamName}
+	if pr.RepositoryID != "" {
+		repo, err := s.repository(ctx, pr.RepositoryID)
+		if err != nil {
+			return nil, err
+		}
+		teams = append([]string{repo.OwnerTeam}, repo.SecondaryTeams...)
+	}
+	settings, err := s.repo.GetTeamSettings(ctx, teams[0])
+	if err != nil {
+		return nil, err
+	}
+	sel := s.newSelection(pr.PullRequestID, trigger, settings, repoPools{s})
+	sel.decision.ReviewerCount = settings.ReviewerCount
+	sel.skip[author.UserID] = entity.ExcludedAuthor
+	pools := make([][]entity.User, 0, len(teams))
+	for i, team := range teams {
+		// exclusions are per team, the strategy and reviewer count are the owning team's
+		teamSettings := settings
+		if i > 0 {
+			if teamSettings, err = s.repo.GetTeamSettings(ctx, team); err != nil {
+				return nil, err
+			}
+		}
+		pool, _, err := s.teamPool(ctx, sel, team, teamSettings)
+		if err != nil {
+			return nil, err
+		}
+		pools = append(pools, pool)
+	}
+	picked, owners, err := s.pickOwnersFirst(ctx, sel, settings, pools, settings.ReviewerCount, pr.CodeOwners)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, team := range settings.FallbackTeams {
+		if len(picked) >= settings.ReviewerCount {
+			break
+		}
+		if slices.Contains(teams, team) {
+			continue
+		}
+		teamSettings, err := s.repo.GetTeamSettings(ctx, team)
+		if err != nil {
+			return nil, err
+		}
+		pool, _, err := s.teamPool(ctx, sel, team, teamSettings)
+		if err != nil {
+			return nil, err
+		}
+		rest, restOwners, err := s.pickOwnersFirst(ctx, sel, settings, [][]entity.User{pool}, settings.ReviewerCount-len(picked), pr.CodeOwners)
+		if err != nil {
+			return nil, err
+		}
+		picked = append(picked, rest...)
+		owners += restOwners
+	}
+
+	if len(picked) == 0 && settings.ReviewerCount > 0 && sel.allAtCapacity() {
+		return nil, ErrAtCapacity
+	}
+	if pr.RequireCodeOwner && len(pr.CodeOwners) > 0 && owners == 0 {
+		return nil, ErrNoCodeOwner
+	}
+	if picked != nil {
+		sel.decision.Selected = picked
+	}
+	return sel, nil
+}
+
+// selection collects what a single reviewer selection saw and becomes its recorded decision.
+type selection struct {
+	decision entity.AssignmentDecision
+	pools    poolSource
+	rnd      *rand.Rand
+	// skip maps users that cannot be picked to the reason, an empty reason marks
+	// users already offered by an earlier pool.
+	skip map[string]string
+	// origin is the team each candidate was offered by.
+	origin map[string]string
+}
+
+// newSelection starts a selection with its own seed, so the recorded pick can be replayed.
+func (s *Service) newSelection(prID, trigger string, settings entity.TeamSettings, pools poolSource) *selection {
+	seed := s.rand.Int63()
+	return &selection{
+		decision: entity.AssignmentDecision{
+			PullRequestID: prID,
+			Trigger:       trigger,
+			Strategy:      s.strategyName(settings),
+			Seed:          seed,
+			Teams:         []string{},
+			Candidates:    []string{},
+			Excluded:      []entity.ExcludedCandidate{},
+			Selected:      []string{},
+		},
+		pools:  pools,
+		rnd:    rand.New(rand.NewSource(seed)),
+		skip:   make(map[string]string),
+		origin: make(map[string]string),
+	}
+}
+
+// allAtCapacity reports whether no candidate is left and at least one was dropped for being at capacity.
+func (sel *selection) allAtCapacity() bool {
+	if len(sel.decision.Candidates) > 0 {
+		return false
+	}
+	return slices.ContainsFunc(sel.decision.Excluded, func(e entity.ExcludedCandidate) bool {
+		return e.Reason == entity.ExcludedAtCapacity
+	})
+}
+
+func (sel *selection) exclude(userID, team, reason string) {
+	if reason == "" {
+		return
+	}
+	sel.decision.Excluded = append(sel.decision.Excluded, entity.ExcludedCandidate{UserID: userID, Team: team, Reason: reason})
+}
+
+// teamPool returns the members of team that can review: active, not absent, not excluded by
+// the team settings and within their review capacity. Everyone else is recorded in the
+// selection with the reason. atCapacity is true when candidates existed but all of them are full.
+func (s *Service) teamPool(ctx context.Context, sel *selection, team string, settings entity.TeamSettings) ([]entity.User, bool, error) {
+	members, err := sel.pools.members(ctx, team)
+	if err != nil {
+		return nil, false, err
+	}
+	sel.decision.Teams = append(sel.decision.Teams, team)
+	candidates := make([]entity.User, 0, len(members))
+	for _, m := range members {
+		reason, skipped := sel.skip[m.UserID]
+		switch {
+		case skipped:
+		case !m.IsActive:
+			reason = entity.ExcludedInactive
+		case m.Absent:
+			reason = entity.ExcludedAbsent
+		case slices.Contains(settings.ExcludedUsers, m.UserID):
+			reason = entity.ExcludedBySettings
+		default:
+			candidates = append(candidates, m.User)
+			continue
+		}
+		sel.exclude(m.UserID, team, reason)
+	}
+	if len(candidates) == 0 {
+		return candidates, false, nil
+	}
+
+	capacities, loads, err := sel.pools.limits(ctx, userIDs(candidates))
+	if err != nil {
+		return nil, false, err
+	}
+	pool := make([]entity.User, 0, len(candidates))
+	for _, u := range candidates {
+		if limit, ok := capacities[u.UserID]; ok && loads[u.UserID] >= limit {
+			sel.exclude(u.UserID, team, entity.ExcludedAtCapacity)
+			continue
+		}
+		pool = append(pool, u)
+		sel.skip[u.UserID] = ""
+		sel.origin[u.UserID] = team
+		sel.decision.Candidates = append(sel.decision.Candidates, u.UserID)
+	}
+	return pool, len(pool) == 0, nil
+}
+
+func (s *Service) repository(ctx context.Context, id string) (entity.Repository, error) {
+	repo, err := s.repo.GetRepository(ctx, id)
+	if err != nil {
+		if isNotFound(err) {
+			return entity.Repository{}, ErrNoRepository
+		}
+		return entity.Repository{}, err
+	}
+	return repo, nil
+}
+
+// pickOwnersFirst fills up to count slots from candidates that are code owners, then from
+// the other candidates pool by pool. It also returns how many picked reviewers are code owners.
+func (s *Service) pickOwnersFirst(ctx context.Context, sel *selection, settings entity.TeamSettings, pools [][]entity.User, count int, codeOwners []string) ([]string, int, error) {
+	var owners []entity.User
+	others := make([][]entity.User, len(pools))
+	for i, pool := range pools {
+		for _, u := range pool {
+			if slices.Contains(codeOwners, u.UserID) {
+				owners = append(owners, u)
+			} else {
+				others[i] = append(others[i], u)
+			}
+		}
+	}
+	strategy := sel.pools.strategy(settings)
+	picked, err := strategy.Pick(ctx, owners, count, sel.rnd)
+	if err != nil {
+		return nil, 0, err
+	}
+	fromOwners := len(picked)
+	for _, pool := range others {
+		if len(picked) >= count {
+			break
+		}
+		rest, err := strategy.Pick(ctx, pool, count-len(picked), sel.rnd)
+		if err != nil {
+			return nil, 0, err
+		}
+		picked = append(picked, rest...)
+	}
+	return picked, fromOwners, nil
+}
+
+func (s *Service) checkApprovals(ctx context.Context, pr entity.PullRequest) error {
+	required := s.requiredApprovals
+	author, err := s.repo.GetUser(ctx, pr.AuthorID)
+	if err != nil && !isNotFound(err) {
+		return err
+	}
+	if err == nil {
+		settings, err := s.repo.GetTeamSettings(ctx, author.TeamName)
+		if err != nil {
+			return err
+		}
+		if settings.RequiredApprovals != nil {
+			required = *settings.RequiredApprovals
+		}
+	}
+	if required <= 0 {
+		return nil
+	}
+	approved := 0
+	missing := make([]string, 0, len(pr.Reviews))
+	for _, rev := range pr.Reviews {
+		if rev.State == entity.ReviewApproved {
+			approved++
+			continue
+		}
+		missing = append(missing, rev.ReviewerID)
+	}
+	if approved >= required {
+		return nil
+	}
+	return &ApprovalError{Required: required, Approved: approved, Missing: missing}
+}
+
+// Review records the decision of an assigned reviewer, a repeated call overrides the previous one.
+func (s *Service) Review(ctx context.Context, prID, reviewerID, decision string) (entity.PullRequest, error) {
 	prID = strings.TrimSpace(prID)
-	oldReviewer = strings.TrimSpace(oldReviewer)
-	if prID == "" || oldReviewer == "" {
-		return entity.PullRequest{}, "", ErrInvalidInput
+	reviewerID = strings.TrimSpace(reviewerID)
+	decision = strings.TrimSpace(decision)
+	if prID == "" || reviewerID == "" {
+		return entity.PullRequest{}, ErrInvalidInput
+	}
+	switch decision {
+	case entity.ReviewApproved, entity.ReviewChangesRequested, entity.ReviewCommented:
+	default:
+		return entity.PullRequest{}, ErrInvalidInput
 	}
 	pr, err := s.repo.Get(ctx, prID)
 	if err != nil {
 		if isNotFound(err) {
-			return entity.PullRequest{}, "", ErrNotFound
+			return entity.PullRequest{}, ErrNotFound
 		}
-		return entity.PullRequest{}, "", err
+		return entity.PullRequest{}, err
 	}
-	if pr.Status == "MERGED" {
-		return entity.PullRequest{}, "", ErrMerged
+	if err := statusError(pr.Status); err != nil {
+		return entity.PullRequest{}, err
 	}
-	found := false
-	for _, r := range pr.Assigned {
-		if r == oldReviewer {
-			found = true
-			break
+	if !slices.Contains(pr.Assigned, reviewerID) {
+		return entity.PullRequest{}, ErrNotAssigned
+	}
+	if err := s.repo.SetReviewState(ctx, prID, reviewerID, decision, time.Now().UTC()); err != nil {
+		if isNotFound(err) {
+			return entity.PullRequest{}, ErrStatusChanged
 		}
+		return entity.PullRequest{}, err
 	}
-	if !found {
-		return entity.PullRequest{}, "", ErrNotAssigned
+	return s.repo.Get(ctx, prID)
+}
+
+// AddReviewer assigns a specific active user to an OPEN PR on top of its current reviewers.
+// actorID is who asked for it and may be empty.
+func (s *Service) AddReviewer(ctx context.Context, prID, reviewerID, actorID string) (entity.PullRequest, error) {
+	pr, reviewerID, err := s.getForReviewerChange(ctx, prID, reviewerID)
+	if err != nil {
+		return entity.PullRequest{}, err
 	}
-	reviewer, err := s.repo.GetUser(ctx, oldReviewer)
+	if reviewerID == pr.AuthorID {
+		return entity.PullRequest{}, ErrIsAuthor
+	}
+	if slices.Contains(pr.Assigned, reviewerID) {
+		return entity.PullRequest{}, ErrAssigned
+	}
+	reviewer, err := s.repo.GetUser(ctx, reviewerID)
 	if err != nil {
 		if isNotFound(err) {
-			return entity.PullRequest{}, "", ErrNotFound
+			return entity.PullRequest{}, ErrNotFound
 		}
-		return entity.PullRequest{}, "", err
+		return entity.PullRequest{}, err
 	}
 	if !reviewer.IsActive {
-		return entity.PullRequest{}, "", ErrNotFound
+		return entity.PullRequest{}, ErrNotFound
 	}
-	candidates, err := s.repo.GetActiveTeamMembers(ctx, reviewer.TeamName)
+	if err := s.repo.AddReviewer(ctx, pr.PullRequestID, reviewerID, strings.TrimSpace(actorID), "manual", nil); err != nil {
+		if isNotFound(err) {
+			return entity.PullRequest{}, ErrStatusChanged
+		}
+		return entity.PullRequest{}, err
+	}
+	return s.repo.Get(ctx, pr.PullRequestID)
+}
+
+// RemoveReviewer drops a reviewer from an OPEN PR without a replacement.
+// actorID is who asked for it and may be empty.
+func (s *Service) RemoveReviewer(ctx context.Context, prID, reviewerID, actorID string) (entity.PullRequest, error) {
+	pr, reviewerID, err := s.getForReviewerChange(ctx, prID, reviewerID)
+	if err != nil {
+		return entity.PullRequest{}, err
+	}
+	if !slices.Contains(pr.Assigned, reviewerID) {
+		return entity.PullRequest{}, ErrNotAssigned
+	}
+	if err := s.repo.RemoveReviewer(ctx, pr.PullRequestID, reviewerID, strings.TrimSpace(actorID)); err != nil {
+		if isNotFound(err) {
+			return entity.PullRequest{}, ErrStatusChanged
+		}
+		return entity.PullRequest{}, err
+	}
+	return s.repo.Get(ctx, pr.PullRequestID)
+}
+
+// getForReviewerChange loads an OPEN PR for a manual reviewer change and returns the trimmed reviewer id.
+func (s *Service) getForReviewerChange(ctx context.Context, prID, reviewerID string) (entity.PullRequest, string, error) {
+	reviewerID = strings.TrimSpace(reviewerID)
+	if reviewerID == "" {
+		return entity.PullRequest{}, "", ErrInvalidInput
+	}
+	pr, err := s.getForTransition(ctx, prID)
 	if err != nil {
 		return entity.PullRequest{}, "", err
 	}
-	filtered := make([]entity.User, 0, len(candidates))
-	assignedSet := make(map[string]struct{}, len(pr.Assigned))
-	for _, r := range pr.Assigned {
-		assignedSet[r] = struct{}{}
+	if err := statusError(pr.Status); err != nil {
+		return entity.PullRequest{}, "", err
 	}
-	assignedSet[pr.AuthorID] = struct{}{}
+	return pr, reviewerID, nil
+}
 
-	for _, c := range candidates {
-		if c.UserID == oldReviewer {
-			continue
+func (s *Service) Reassign(ctx context.Context, prID, oldReviewer, actorID string) (entity.PullRequest, string, error) {
+	prID = strings.TrimSpace(prID)
+	oldReviewer = strings.TrimSpace(oldReviewer)
+	if prID == "" || oldReviewer == "" {
+		return entity.PullRequest{}, "", ErrInvalidInput
+	}
+	pr, err := s.getAssigned(ctx, prID, oldReviewer)
+	if err != nil {
+		return entity.PullRequest{}, "", err
+	}
+	sel, err := s.pickReplacement(ctx, pr, oldReviewer, entity.DecisionReassign, nil)
+	if err != nil {
+		return entity.PullRequest{}, "", err
+	}
+	replacement := sel.decision.Selected[0]
+	if err := s.repo.ReplaceReviewer(ctx, prID, oldReviewer, replacement, strings.TrimSpace(actorID), "", &sel.decision); err != nil {
+		if isNotFound(err) {
+			return entity.PullRequest{}, "", ErrStatusChanged
 		}
-		if _, exists := assignedSet[c.UserID]; exists {
-			continue
+		return entity.PullRequest{}, "", err
+	}
+	pr, err = s.repo.Get(ctx, prID)
+	if err != nil {
+		return entity.PullRequest{}, "", err
+	}
+	return pr, replacement, nil
+}
+
+// Respond records the answer of an assigned reviewer. Accepting marks the assignment, declining
+// replaces the reviewer like Reassign does, never picking anyone who declined the PR before.
+// The replacement is empty for acceptances.
+func (s *Service) Respond(ctx context.Context, prID, reviewerID, response, reason string) (entity.PullRequest, string, error) {
+	prID = strings.TrimSpace(prID)
+	reviewerID = strings.TrimSpace(reviewerID)
+	response = strings.TrimSpace(response)
+	reason = strings.TrimSpace(reason)
+	if prID == "" || reviewerID == "" {
+		return entity.PullRequest{}, "", ErrInvalidInput
+	}
+	switch response {
+	case entity.ResponseAccepted:
+	case entity.ResponseDeclined:
+		if reason == "" {
+			return entity.PullRequest{}, "", ErrInvalidInput
 		}
-		filtered = append(filtered, c)
+	default:
+		return entity.PullRequest{}, "", ErrInvalidInput
 	}
-	if len(filtered) == 0 {
-		return entity.PullRequest{}, "", ErrNoCandidate
+	pr, err := s.getAssigned(ctx, prID, reviewerID)
+	if err != nil {
+		return entity.PullRequest{}, "", err
 	}
-	replacement := s.pickRandom(filtered, 1)
-	if err := s.repo.ReplaceReviewer(ctx, prID, oldReviewer, replacement[0]); err != nil {
+
+	if response == entity.ResponseAccepted {
+		if err := s.repo.AcceptReview(ctx, prID, reviewerID, time.Now().UTC()); err != nil {
+			if isNotFound(err) {
+				return entity.PullRequest{}, "", ErrStatusChanged
+			}
+			return entity.PullRequest{}, "", err
+		}
+		pr, err = s.repo.Get(ctx, prID)
+		return pr, "", err
+	}
+
+	declined, err := s.repo.DeclinedReviewers(ctx, prID)
+	if err != nil {
+		return entity.PullRequest{}, "", err
+	}
+	sel, err := s.pickReplacement(ctx, pr, reviewerID, entity.DecisionDecline, declined)
+	if err != nil {
+		return entity.PullRequest{}, "", err
+	}
+	replacement := sel.decision.Selected[0]
+	if err := s.repo.DeclineReview(ctx, prID, reviewerID, replacement, reason, &sel.decision); err != nil {
+		if isNotFound(err) {
+			return entity.PullRequest{}, "", ErrNotAssigned
+		}
 		return entity.PullRequest{}, "", err
 	}
 	pr, err = s.repo.Get(ctx, prID)
 	if err != nil {
 		return entity.PullRequest{}, "", err
 	}
-	return pr, replacement[0], nil
+	return pr, replacement, nil
+}
+
+// getAssigned loads an OPEN PR reviewerID is assigned to.
+func (s *Service) getAssigned(ctx context.Context, prID, reviewerID string) (entity.PullRequest, error) {
+	pr, err := s.repo.Get(ctx, prID)
+	if err != nil {
+		if isNotFound(err) {
+			return entity.PullRequest{}, ErrNotFound
+		}
+		return entity.PullRequest{}, err
+	}
+	if err := statusError(pr.Status); err != nil {
+		return entity.PullRequest{}, err
+	}
+	if !slices.Contains(pr.Assigned, reviewerID) {
+		return entity.PullRequest{}, ErrNotAssigned
+	}
+	return pr, nil
+}
+
+// pickReplacement selects who takes over the review of oldReviewer: the reviewer's own team is
+// asked first, then its fallback teams in order. Users in declined are never picked.
+func (s *Service) pickReplacement(ctx context.Context, pr entity.PullRequest, oldReviewer, trigger string, declined []string) (*selection, error) {
+	reviewer, err := s.repo.GetUser(ctx, oldReviewer)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if !reviewer.IsActive {
+		return nil, ErrNotFound
+	}
+	sel, err := s.selectReplacement(ctx, repoPools{s}, reviewer.TeamName, pr, oldReviewer, trigger, declined)
+	if err != nil {
+		return nil, err
+	}
+	if err := sel.replacementErr(); err != nil {
+		return nil, err
+	}
+	return sel, nil
+}
+
+// selectReplacement runs the replacement rules of pickReplacement for a reviewer of team.
+// When nobody qualifies the selection has no reviewer selected, see replacementErr.
+func (s *Service) selectReplacement(ctx context.Context, pools poolSource, team string, pr entity.PullRequest, oldReviewer, trigger string, declined []string) (*selection, error) {
+	settings, err := pools.settings(ctx, team)
+	if err != nil {
+		return nil, err
+	}
+	sel := s.newSelection(pr.PullRequestID, trigger, settings, pools)
+	sel.decision.ReviewerCount = 1
+	sel.decision.ReplacedReviewerID = oldReviewer
+	for _, id := range declined {
+		sel.skip[id] = entity.ExcludedDeclined
+	}
+	for _, id := range pr.Assigned {
+		sel.skip[id] = entity.ExcludedAssigned
+	}
+	sel.skip[pr.AuthorID] = entity.ExcludedAuthor
+
+	filtered, _, err := s.teamPool(ctx, sel, team, settings)
+	if err != nil {
+		return nil, err
+	}
+	for _, fallback := range settings.FallbackTeams {
+		if len(filtered) > 0 {
+			break
+		}
+		teamSettings, err := pools.settings(ctx, fallback)
+		if err != nil {
+			return nil, err
+		}
+		if filtered, _, err = s.teamPool(ctx, sel, fallback, teamSettings); err != nil {
+			return nil, err
+		}
+	}
+	replacement, _, err := s.pickOwnersFirst(ctx, sel, settings, [][]entity.User{filtered}, 1, pr.CodeOwners)
+	if err != nil {
+		return nil, err
+	}
+	if replacement != nil {
+		sel.decision.Selected = replacement
+	}
+	return sel, nil
 }
 
-func (s *Service) pickRandom(users []entity.User, count int) []string {
-	if len(users) == 0 || count == 0 {
+// replacementErr tells why a replacement selection picked nobody, it is nil when it picked someone.
+func (sel *selection) replacementErr() error {
+	if len(sel.decision.Selected) > 0 {
 		return nil
 	}
-	if len(users) <= count {
-		ids := make([]string, 0, len(users))
-		for _, u := range users {
-			ids = append(ids, u.UserID)
+	if sel.allAtCapacity() {
+		return ErrAtCapacity
+	}
+	return ErrNoCandidate
+}
+
+// DeactivateUsers deactivates the given members of a team (the whole team when userIDs is empty)
+// and replaces them on every OPEN PR by the rules of Reassign, or removes them if nobody qualifies.
+func (s *Service) DeactivateUsers(ctx context.Context, teamName string, userIDs []string) ([]string, []entity.Reassignment, error) {
+	teamName = strings.TrimSpace(teamName)
+	if teamName == "" {
+		return nil, nil, ErrInvalidInput
+	}
+	ids := make([]string, 0, len(userIDs))
+	seen := make(map[string]struct{}, len(userIDs))
+	for _, id := range userIDs {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			return nil, nil, ErrInvalidInput
+		}
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		ids = append(ids, id)
+	}
+
+	deactivated, reassignments, err := s.repo.DeactivateUsers(ctx, teamName, ids, func(deactivated []string, reviews []OpenReview) ([]entity.Reassignment, []entity.AssignmentDecision, error) {
+		if len(deactivated) != len(ids) && len(ids) > 0 {
+			return nil, nil, ErrNotFound
+		}
+		return s.planReassignments(ctx, teamName, deactivated, reviews)
+	})
+	if err != nil {
+		if isNotFound(err) {
+			return nil, nil, ErrNotFound
+		}
+		return nil, nil, err
+	}
+	return deactivated, reassignments, nil
+}
+
+// planReassignments selects a replacement for every review of a deactivated member of team the
+// way pickReplacement does. Teams are loaded once and loads are tracked in memory, so a bulk run
+// does not query the database per PR.
+func (s *Service) planReassignments(ctx context.Context, team string, deactivated []string, reviews []OpenReview) ([]entity.Reassignment, []entity.AssignmentDecision, error) {
+	pools := newCachedPools(s, deactivated)
+	assigned := make(map[string][]string)
+	reassignments := make([]entity.Reassignment, 0, len(reviews))
+	decisions := make([]entity.AssignmentDecision, 0, len(reviews))
+	for _, rev := range reviews {
+		current, ok := assigned[rev.PullRequestID]
+		if !ok {
+			current = slices.Clone(rev.Assigned)
+		}
+		pr := entity.PullRequest{PullRequestID: rev.PullRequestID, AuthorID: rev.AuthorID, Assigned: current, CodeOwners: rev.CodeOwners}
+		sel, err := s.selectReplacement(ctx, pools, team, pr, rev.ReviewerID, entity.DecisionDeactivate, rev.Declined)
+		if err != nil {
+			return nil, nil, err
+		}
+		ra := entity.Reassignment{PullRequestID: rev.PullRequestID, OldReviewerID: rev.ReviewerID}
+		if err := sel.replacementErr(); err != nil {
+			ra.Error = err.Error()
+		} else {
+			ra.NewReviewerID = sel.decision.Selected[0]
+			current = append(current, ra.NewReviewerID)
+			pools.loads[ra.NewReviewerID]++
+		}
+		assigned[rev.PullRequestID] = current
+		reassignments = append(reassignments, ra)
+		decisions = append(decisions, sel.decision)
+	}
+	return reassignments, decisions, nil
+}
+
+func pendingReviews(reviewers, codeOwners []string, teams map[string]string) []entity.ReviewerState {
+	reviews := make([]entity.ReviewerState, 0, len(reviewers))
+	for _, id := range reviewers {
+		reviews = append(reviews, entity.ReviewerState{
+			ReviewerID: id,
+			State:      entity.ReviewPending,
+			Response:   entity.ResponsePending,
+			CodeOwner:  slices.Contains(codeOwners, id),
+			Team:       teams[id],
+		})
+	}
+	return reviews
+}
+
+func (s *Service) strategyFor(settings entity.TeamSettings) ReviewerStrategy {
+	return s.strategies[s.strategyName(settings)]
+}
+
+// strategyName is the team strategy, or the service default when the team has none.
+func (s *Service) strategyName(settings entity.TeamSettings) string {
+	if _, ok := s.strategies[settings.Strategy]; ok {
+		return settings.Strategy
+	}
+	return s.strategy
+}
+
+// Timeline returns the recorded history of a PR, oldest event first.
+func (s *Service) Timeline(ctx context.Context, id string) ([]entity.PREvent, error) {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return nil, ErrInvalidInput
+	}
+	if _, err := s.repo.Get(ctx, id); err != nil {
+		if isNotFound(err) {
+			return nil, ErrNotFound
 		}
-		return ids
+		return nil, err
 	}
-	perm := s.rand.Perm(len(users))
-	selected := make([]string, 0, count)
-	for i := 0; i < count; i++ {
-		selected = append(selected, users[perm[i]].UserID)
+	return s.repo.Events(ctx, id)
+}
+
+// AssignmentExplain returns how the reviewers of a PR were selected, oldest selection first.
+func (s *Service) AssignmentExplain(ctx context.Context, id string) ([]entity.AssignmentDecision, error) {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return nil, ErrInvalidInput
+	}
+	if _, err := s.repo.Get(ctx, id); err != nil {
+		if isNotFound(err) {
+			return nil, ErrNotFound
+		}
+		return nil, err
 	}
-	return selected
+	return s.repo.Decisions(ctx, id)
 }
 
 func (s *Service) Stats(ctx context.Context) (map[string]int, error) {
@@ -2,7 +2,10 @@ package pullrequests
 
 import (
 	"context"
+	"fmt"
 	"math/rand"
+	"slices"
+	"sort"
 	"testing"
 	"time"
 
@@ -16,6 +19,14 @@ type prRepoStub struct {
 	users     map[string]entity.User
 	prs       map[string]entity.PullRequest
 	reviewers map[string][]string
+	settings  map[string]entity.TeamSettings
+	states    map[string]map[string]string
+	events    map[string][]entity.PREvent
+	repos     map[string]entity.Repository
+	absent    map[string]bool
+	decisions map[string][]entity.AssignmentDecision
+	responses map[string]map[string]string
+	declined  map[string][]string
 }
 
 func newPRRepoStub() *prRepoStub {
@@ -23,6 +34,14 @@ func newPRRepoStub() *prRepoStub {
 		users:     make(map[string]entity.User),
 		prs:       make(map[string]entity.PullRequest),
 		reviewers: make(map[string][]string),
+		settings:  make(map[string]entity.TeamSettings),
+		states:    make(map[string]map[string]string),
+		events:    make(map[string][]entity.PREvent),
+		repos:     make(map[string]entity.Repository),
+		absent:    make(map[string]bool),
+		decisions: make(map[string][]entity.AssignmentDecision),
+		responses: make(map[string]map[string]string),
+		declined:  make(map[string][]string),
 	}
 }
 
@@ -44,10 +63,37 @@ func (r *prRepoStub) GetActiveTeamMembers(ctx context.Context, teamName string)
 	return result, nil
 }
 
-func (r *prRepoStub) Create(ctx context.Context, pr entity.PullRequest) error {
+func (r *prRepoStub) GetTeamMembers(ctx context.Context, teamName string) ([]Candidate, error) {
+	result := make([]Candidate, 0)
+	for _, u := range r.users {
+		if u.TeamName == teamName {
+			result = append(result, Candidate{User: u, Absent: r.absent[u.UserID]})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].UserID < result[j].UserID })
+	return result, nil
+}
+
+func (r *prRepoStub) GetTeamSettings(ctx context.Context, teamName string) (entity.TeamSettings, error) {
+	if settings, ok := r.settings[teamName]; ok {
+		return settings, nil
+	}
+	return entity.TeamSettings{TeamName: teamName, ReviewerCount: entity.DefaultReviewerCount}, nil
+}
+
+func (r *prRepoStub) GetRepository(ctx context.Context, id string) (entity.Repository, error) {
+	repo, ok := r.repos[id]
+	if !ok {
+		return entity.Repository{}, pgx.ErrNoRows
+	}
+	return repo, nil
+}
+
+func (r *prRepoStub) Create(ctx context.Context, pr entity.PullRequest, decision *entity.AssignmentDecision) error {
 	if _, ok := r.prs[pr.PullRequestID]; ok {
 		return &pgconn.PgError{Code: "23505"}
 	}
+	r.recordDecision(decision)
 	r.prs[pr.PullRequestID] = pr
 	r.reviewers[pr.PullRequestID] = append([]string{}, pr.Assigned...)
 	return nil
@@ -60,14 +106,86 @@ func (r *prRepoStub) Get(ctx context.Context, id string) (entity.PullRequest, er
 	}
 	revs := r.reviewers[id]
 	pr.Assigned = append([]string{}, revs...)
+	pr.Reviews = make([]entity.ReviewerState, 0, len(revs))
+	for _, rev := range revs {
+		state := r.states[id][rev]
+		if state == "" {
+			state = entity.ReviewPending
+		}
+		response := r.responses[id][rev]
+		if response == "" {
+			response = entity.ResponsePending
+		}
+		pr.Reviews = append(pr.Reviews, entity.ReviewerState{ReviewerID: rev, State: state, Response: response})
+	}
 	return pr, nil
 }
 
+// List mirrors the keyset query of Repository.List, merged time filters are not supported.
+func (r *prRepoStub) List(ctx context.Context, filter ListFilter) ([]entity.PullRequest, error) {
+	key := func(pr entity.PullRequest) time.Time {
+		if filter.Sort == SortMergedAt {
+			return *pr.MergedAt
+		}
+		return *pr.CreatedAt
+	}
+	before := func(a, b entity.PullRequest) bool {
+		if !key(a).Equal(key(b)) {
+			return key(a).Before(key(b)) == (filter.Order == OrderAsc)
+		}
+		if a.PullRequestID == b.PullRequestID {
+			return false
+		}
+		return (a.PullRequestID < b.PullRequestID) == (filter.Order == OrderAsc)
+	}
+
+	result := make([]entity.PullRequest, 0)
+	for id := range r.prs {
+		pr, _ := r.Get(ctx, id)
+		switch {
+		case len(filter.Statuses) > 0 && !slices.Contains(filter.Statuses, pr.Status),
+			filter.AuthorID != "" && pr.AuthorID != filter.AuthorID,
+			filter.ReviewerID != "" && !slices.Contains(pr.Assigned, filter.ReviewerID),
+			filter.Team != "" && r.users[pr.AuthorID].TeamName != filter.Team,
+			filter.CreatedFrom != nil && pr.CreatedAt.Before(*filter.CreatedFrom),
+			filter.CreatedTo != nil && !pr.CreatedAt.Before(*filter.CreatedTo),
+			filter.Sort == SortMergedAt && pr.MergedAt == nil:
+			continue
+		}
+		if filter.After != nil && !before(entity.PullRequest{PullRequestID: filter.After.PullRequestID, CreatedAt: &filter.After.Key, MergedAt: &filter.After.Key}, pr) {
+			continue
+		}
+		result = append(result, pr)
+	}
+	sort.Slice(result, func(i, j int) bool { return before(result[i], result[j]) })
+	if len(result) > filter.Limit {
+		result = result[:filter.Limit]
+	}
+	return result, nil
+}
+
+func (r *prRepoStub) SetReviewState(ctx context.Context, prID, reviewerID, state string, ts time.Time) error {
+	if r.prs[prID].Status != entity.StatusOpen || !slices.Contains(r.reviewers[prID], reviewerID) {
+		return pgx.ErrNoRows
+	}
+	if r.states[prID] == nil {
+		r.states[prID] = make(map[string]string)
+	}
+	r.states[prID][reviewerID] = state
+	return nil
+}
+
 func (r *prRepoStub) Merge(ctx context.Context, id string, ts time.Time) error {
 	pr, ok := r.prs[id]
 	if !ok {
 		return pgx.ErrNoRows
 	}
+	if pr.Status == entity.StatusMerged {
+		return nil
+	}
+	if pr.Status != entity.StatusOpen {
+		return pgx.ErrNoRows
+	}
 	pr.Status = "MERGED"
 	if pr.MergedAt == nil {
 		pr.MergedAt = &ts
@@ -76,18 +194,124 @@ func (r *prRepoStub) Merge(ctx context.Context, id string, ts time.Time) error {
 	return nil
 }
 
-func (r *prRepoStub) ReplaceReviewer(ctx context.Context, prID, oldID, newID string) error {
+func (r *prRepoStub) Transition(ctx context.Context, id, from, to string, reviewers []string, decision *entity.AssignmentDecision, ts time.Time) error {
+	pr, ok := r.prs[id]
+	if !ok || pr.Status != from {
+		return pgx.ErrNoRows
+	}
+	r.recordDecision(decision)
+	pr.Status = to
+	pr.ClosedAt = nil
+	if to == entity.StatusClosed {
+		pr.ClosedAt = &ts
+	}
+	r.prs[id] = pr
+	r.reviewers[id] = append(r.reviewers[id], reviewers...)
+	return nil
+}
+
+func (r *prRepoStub) ReplaceReviewer(ctx context.Context, prID, oldID, newID, actorID, reason string, decision *entity.AssignmentDecision) error {
+	if r.prs[prID].Status != entity.StatusOpen {
+		return pgx.ErrNoRows
+	}
 	revs := r.reviewers[prID]
 	for i, v := range revs {
 		if v == oldID {
+			r.recordDecision(decision)
 			revs[i] = newID
 			r.reviewers[prID] = revs
+			r.events[prID] = append(r.events[prID], entity.PREvent{
+				EventID:       int64(len(r.events[prID]) + 1),
+				PullRequestID: prID,
+				Type:          entity.EventReviewerReplaced,
+				ActorID:       actorID,
+				ReviewerID:    newID,
+				OldReviewerID: oldID,
+				Reason:        reason,
+			})
 			return nil
 		}
 	}
+	return pgx.ErrNoRows
+}
+
+func (r *prRepoStub) AddReviewer(ctx context.Context, prID, reviewerID, actorID, reason string, decision *entity.AssignmentDecision) error {
+	if r.prs[prID].Status != entity.StatusOpen {
+		return pgx.ErrNoRows
+	}
+	if slices.Contains(r.reviewers[prID], reviewerID) {
+		return ErrAssigned
+	}
+	r.recordDecision(decision)
+	r.reviewers[prID] = append(r.reviewers[prID], reviewerID)
+	r.events[prID] = append(r.events[prID], entity.PREvent{
+		EventID:       int64(len(r.events[prID]) + 1),
+		PullRequestID: prID,
+		Type:          entity.EventReviewerAssigned,
+		ActorID:       actorID,
+		ReviewerID:    reviewerID,
+		Reason:        reason,
+	})
+	return nil
+}
+
+func (r *prRepoStub) RemoveReviewer(ctx context.Context, prID, reviewerID, actorID string) error {
+	revs := r.reviewers[prID]
+	if r.prs[prID].Status != entity.StatusOpen || !slices.Contains(revs, reviewerID) {
+		return pgx.ErrNoRows
+	}
+	r.reviewers[prID] = slices.DeleteFunc(revs, func(id string) bool { return id == reviewerID })
+	r.events[prID] = append(r.events[prID], entity.PREvent{
+		EventID:       int64(len(r.events[prID]) + 1),
+		PullRequestID: prID,
+		Type:          entity.EventReviewerRemoved,
+		ActorID:       actorID,
+		OldReviewerID: reviewerID,
+		Reason:        "manual",
+	})
+	return nil
+}
+
+func (r *prRepoStub) AcceptReview(ctx context.Context, prID, reviewerID string, ts time.Time) error {
+	if r.prs[prID].Status != entity.StatusOpen || !slices.Contains(r.reviewers[prID], reviewerID) {
+		return pgx.ErrNoRows
+	}
+	if r.responses[prID] == nil {
+		r.responses[prID] = make(map[string]string)
+	}
+	r.responses[prID][reviewerID] = entity.ResponseAccepted
+	return nil
+}
+
+func (r *prRepoStub) DeclineReview(ctx context.Context, prID, reviewerID, newID, reason string, decision *entity.AssignmentDecision) error {
+	if err := r.ReplaceReviewer(ctx, prID, reviewerID, newID, reviewerID, "", decision); err != nil {
+		return err
+	}
+	r.declined[prID] = append(r.declined[prID], reviewerID)
 	return nil
 }
 
+func (r *prRepoStub) DeclinedReviewers(ctx context.Context, prID string) ([]string, error) {
+	return r.declined[prID], nil
+}
+
+func (r *prRepoStub) recordDecision(decision *entity.AssignmentDecision) {
+	if decision == nil {
+		return
+	}
+	d := *decision
+	d.DecisionID = int64(len(r.decisions[d.PullRequestID]) + 1)
+	r.decisions[d.PullRequestID] = append(r.decisions[d.PullRequestID], d)
+}
+
+func (r *prRepoStub) Decisions(ctx context.Context, prID string) ([]entity.AssignmentDecision, error) {
+	return r.decisions[prID], nil
+}
+
+func (r *prRepoStub) Events(ctx context.Context, prID string) ([]entity.PREvent, error) {
+	return append([]entity.PREvent{}, r.events[prID]...), nil
+}
+
 func (r *prRepoStub) StatsAssignments(ctx context.Context) (map[string]int, error) {
 	result := make(map[string]int)
 	for prID, revs := range r.reviewers {
@@ -99,6 +323,88 @@ func (r *prRepoStub) StatsAssignments(ctx context.Context) (map[string]int, erro
 	return result, nil
 }
 
+func (r *prRepoStub) OpenReviewCounts(ctx context.Context, userIDs []string) (map[string]int, error) {
+	result := make(map[string]int)
+	for prID, revs := range r.reviewers {
+		if r.prs[prID].Status != "OPEN" {
+			continue
+		}
+		for _, id := range revs {
+			result[id]++
+		}
+	}
+	return result, nil
+}
+
+func (r *prRepoStub) ReviewerCapacities(ctx context.Context, userIDs []string) (map[string]int, error) {
+	result := make(map[string]int)
+	for _, id := range userIDs {
+		u := r.users[id]
+		if u.ReviewCapacity != nil {
+			result[id] = *u.ReviewCapacity
+		} else if limit := r.settings[u.TeamName].ReviewCapacity; limit != nil {
+			result[id] = *limit
+		}
+	}
+	return result, nil
+}
+
+func (r *prRepoStub) DeactivateUsers(ctx context.Context, teamName string, userIDs []string, plan ReassignPlanner) ([]string, []entity.Reassignment, error) {
+	deactivated := make([]string, 0)
+	for id, u := range r.users {
+		if u.TeamName != teamName || (len(userIDs) > 0 && !slices.Contains(userIDs, id)) {
+			continue
+		}
+		deactivated = append(deactivated, id)
+	}
+	sort.Strings(deactivated)
+	reviews := make([]OpenReview, 0)
+	prIDs := make([]string, 0, len(r.prs))
+	for id := range r.prs {
+		prIDs = append(prIDs, id)
+	}
+	sort.Strings(prIDs)
+	for _, prID := range prIDs {
+		pr := r.prs[prID]
+		if pr.Status != "OPEN" {
+			continue
+		}
+		for _, rev := range r.reviewers[prID] {
+			if slices.Contains(deactivated, rev) {
+				reviews = append(reviews, OpenReview{
+					PullRequestID: prID,
+					AuthorID:      pr.AuthorID,
+					ReviewerID:    rev,
+					Assigned:      slices.Clone(r.reviewers[prID]),
+					CodeOwners:    pr.CodeOwners,
+					Declined:      r.declined[prID],
+				})
+			}
+		}
+	}
+	// like the transaction of Repository.DeactivateUsers, nothing is visible until the plan succeeds
+	reassignments, decisions, err := plan(deactivated, reviews)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, id := range deactivated {
+		u := r.users[id]
+		u.IsActive = false
+		r.users[id] = u
+	}
+	for i := range decisions {
+		r.recordDecision(&decisions[i])
+	}
+	for _, ra := range reassignments {
+		revs := slices.DeleteFunc(r.reviewers[ra.PullRequestID], func(id string) bool { return id == ra.OldReviewerID })
+		if ra.NewReviewerID != "" {
+			revs = append(revs, ra.NewReviewerID)
+		}
+		r.reviewers[ra.PullRequestID] = revs
+	}
+	return deactivated, reassignments, nil
+}
+
 func TestCreate(t *testing.T) {
 	ctx := context.Background()
 	repo := newPRRepoStub()
@@ -149,7 +455,7 @@ func TestCreate(t *testing.T) {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			svc := NewService(repo)
+			svc := NewService(repo, Options{})
 			svc.rand = randSource(1)
 			pr, err := svc.Create(ctx, tt.input)
 			if tt.wantErr != nil {
@@ -170,7 +476,7 @@ func TestMerge(t *testing.T) {
 	ctx := context.Background()
 	repo := newPRRepoStub()
 	repo.prs["pr1"] = entity.PullRequest{PullRequestID: "pr1", Status: "OPEN"}
-	svc := NewService(repo)
+	svc := NewService(repo, Options{})
 
 	tests := []struct {
 		name    string
@@ -198,6 +504,122 @@ func TestMerge(t *testing.T) {
 	}
 }
 
+func TestLifecycle(t *testing.T) {
+	ctx := context.Background()
+	repo := newPRRepoStub()
+	repo.users["a"] = entity.User{UserID: "a", TeamName: "team", IsActive: true}
+	repo.users["b"] = entity.User{UserID: "b", TeamName: "team", IsActive: true}
+	repo.users["c"] = entity.User{UserID: "c", TeamName: "team", IsActive: true}
+	svc := NewService(repo, Options{})
+
+	draft, err := svc.Create(ctx, entity.PullRequest{PullRequestID: "pr1", PullRequestName: "P", AuthorID: "a", Status: entity.StatusDraft})
+	require.NoError(t, err)
+	require.Equal(t, entity.StatusDraft, draft.Status)
+	require.Empty(t, draft.Assigned)
+
+	_, err = svc.Merge(ctx, "pr1")
+	require.ErrorIs(t, err, ErrDraft)
+	_, err = svc.Reopen(ctx, "pr1")
+	require.ErrorIs(t, err, ErrNotClosed)
+
+	ready, err := svc.MarkReady(ctx, "pr1")
+	require.NoError(t, err)
+	require.Equal(t, entity.StatusOpen, ready.Status)
+	require.ElementsMatch(t, []string{"b", "c"}, ready.Assigned)
+	_, err = svc.MarkReady(ctx, "pr1")
+	require.ErrorIs(t, err, ErrNotDraft)
+
+	closed, err := svc.Close(ctx, "pr1")
+	require.NoError(t, err)
+	require.Equal(t, entity.StatusClosed, closed.Status)
+	require.NotNil(t, closed.ClosedAt)
+	closed, err = svc.Close(ctx, "pr1")
+	require.NoError(t, err)
+	require.Equal(t, entity.StatusClosed, closed.Status)
+
+	_, err = svc.Merge(ctx, "pr1")
+	require.ErrorIs(t, err, ErrClosed)
+	_, _, err = svc.Reassign(ctx, "pr1", "b", "")
+	require.ErrorIs(t, err, ErrClosed)
+	_, err = svc.Review(ctx, "pr1", "b", entity.ReviewApproved)
+	require.ErrorIs(t, err, ErrClosed)
+
+	reopened, err := svc.Reopen(ctx, "pr1")
+	require.NoError(t, err)
+	require.Equal(t, entity.StatusOpen, reopened.Status)
+	require.Nil(t, reopened.ClosedAt)
+	require.ElementsMatch(t, []string{"b", "c"}, reopened.Assigned)
+
+	merged, err := svc.Merge(ctx, "pr1")
+	require.NoError(t, err)
+	require.Equal(t, entity.StatusMerged, merged.Status)
+	_, err = svc.Close(ctx, "pr1")
+	require.ErrorIs(t, err, ErrMerged)
+	_, err = svc.Reopen(ctx, "pr1")
+	require.ErrorIs(t, err, ErrMerged)
+	_, err = svc.MarkReady(ctx, "missing")
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestMergeApprovals(t *testing.T) {
+	ctx := context.Background()
+	one, zero := 1, 0
+	tests := []struct {
+		name        string
+		global      int
+		team        *int
+		approved    []string
+		status      string
+		wantMissing []string
+	}{
+		{name: "no policy", global: 0},
+		{name: "global not met", global: 2, approved: []string{"b"}, wantMissing: []string{"c"}},
+		{name: "global met", global: 2, approved: []string{"b", "c"}},
+		{name: "team overrides global", global: 2, team: &one, approved: []string{"c"}},
+		{name: "team disables", global: 2, team: &zero},
+		{name: "team requires", team: &one, wantMissing: []string{"b", "c"}},
+		{name: "already merged", global: 2, status: "MERGED"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			repo := newPRRepoStub()
+			repo.users["a"] = entity.User{UserID: "a", TeamName: "team", IsActive: true}
+			repo.settings["team"] = entity.TeamSettings{TeamName: "team", RequiredApprovals: tt.team}
+			status := "OPEN"
+			if tt.status != "" {
+				status = tt.status
+			}
+			repo.prs["pr1"] = entity.PullRequest{PullRequestID: "pr1", AuthorID: "a", Status: status}
+			repo.reviewers["pr1"] = []string{"b", "c"}
+			svc := NewService(repo, Options{RequiredApprovals: tt.global})
+			for _, id := range tt.approved {
+				_, err := svc.Review(ctx, "pr1", id, entity.ReviewApproved)
+				require.NoError(t, err)
+			}
+
+			pr, err := svc.Merge(ctx, "pr1")
+			if tt.wantMissing != nil {
+				require.ErrorIs(t, err, ErrNotApproved)
+				var approvalErr *ApprovalError
+				require.ErrorAs(t, err, &approvalErr)
+				require.Equal(t, tt.wantMissing, approvalErr.Missing)
+				require.Equal(t, "OPEN", repo.prs["pr1"].Status)
+
+				// a merge done on the code host is recorded regardless of the gate
+				pr, err = svc.MarkMerged(ctx, "pr1")
+				require.NoError(t, err)
+				require.Equal(t, "MERGED", pr.Status)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, "MERGED", pr.Status)
+		})
+	}
+}
+
 func TestReassign(t *testing.T) {
 	ctx := context.Background()
 	tests := []struct {
@@ -234,9 +656,9 @@ func TestReassign(t *testing.T) {
 				repo.users["c"] = entity.User{UserID: "c", TeamName: "team", IsActive: false}
 			}
 
-			svc := NewService(repo)
+			svc := NewService(repo, Options{})
 			svc.rand = randSource(2)
-			pr, replaced, err := svc.Reassign(ctx, tt.id, tt.old)
+			pr, replaced, err := svc.Reassign(ctx, tt.id, tt.old, "")
 			if tt.wantErr != nil {
 				require.ErrorIs(t, err, tt.wantErr)
 				return
@@ -249,6 +671,748 @@ func TestReassign(t *testing.T) {
 	}
 }
 
+func TestCreateTeamSettings(t *testing.T) {
+	ctx := context.Background()
+	repo := newPRRepoStub()
+	for _, id := range []string{"author", "u1", "u2", "u3", "u4"} {
+		repo.users[id] = entity.User{UserID: id, TeamName: "platform", IsActive: true}
+	}
+	repo.users["d1"] = entity.User{UserID: "d1", TeamName: "docs", IsActive: true}
+	repo.users["d2"] = entity.User{UserID: "d2", TeamName: "docs", IsActive: true}
+	repo.users["d3"] = entity.User{UserID: "d3", TeamName: "docs", IsActive: true}
+	repo.settings["platform"] = entity.TeamSettings{TeamName: "platform", ReviewerCount: 3, ExcludedUsers: []string{"u4"}}
+	repo.settings["docs"] = entity.TeamSettings{TeamName: "docs", ReviewerCount: 1}
+	svc := NewService(repo, Options{})
+	svc.rand = randSource(3)
+
+	pr, err := svc.Create(ctx, entity.PullRequest{PullRequestID: "p1", PullRequestName: "P", AuthorID: "author"})
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"u1", "u2", "u3"}, pr.Assigned)
+
+	pr, err = svc.Create(ctx, entity.PullRequest{PullRequestID: "d1", PullRequestName: "D", AuthorID: "d1"})
+	require.NoError(t, err)
+	require.Len(t, pr.Assigned, 1)
+
+	_, _, err = svc.Reassign(ctx, "p1", "u1", "")
+	require.ErrorIs(t, err, ErrNoCandidate)
+}
+
+func TestDeactivateUsers(t *testing.T) {
+	ctx := context.Background()
+	newRepo := func() *prRepoStub {
+		repo := newPRRepoStub()
+		for _, id := range []string{"a", "b", "c", "d"} {
+			repo.users[id] = entity.User{UserID: id, TeamName: "team", IsActive: true}
+		}
+		repo.users["x"] = entity.User{UserID: "x", TeamName: "other", IsActive: true}
+		repo.prs["pr1"] = entity.PullRequest{PullRequestID: "pr1", AuthorID: "a", Status: "OPEN"}
+		repo.reviewers["pr1"] = []string{"b", "c"}
+		repo.prs["pr2"] = entity.PullRequest{PullRequestID: "pr2", AuthorID: "x", Status: "OPEN"}
+		repo.reviewers["pr2"] = []string{"b"}
+		repo.prs["pr3"] = entity.PullRequest{PullRequestID: "pr3", AuthorID: "a", Status: "MERGED"}
+		repo.reviewers["pr3"] = []string{"b"}
+		return repo
+	}
+
+	t.Run("replace with teammate", func(t *testing.T) {
+		repo := newRepo()
+		svc := NewService(repo, Options{})
+		svc.rand = randSource(4)
+		deactivated, reassignments, err := svc.DeactivateUsers(ctx, "team", []string{"b", "c"})
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"b", "c"}, deactivated)
+		require.Len(t, reassignments, 3)
+		require.ElementsMatch(t, []string{"d"}, repo.reviewers["pr1"])
+		require.Len(t, repo.reviewers["pr2"], 1)
+		require.NotContains(t, repo.reviewers["pr2"], "b")
+		require.Equal(t, []string{"b"}, repo.reviewers["pr3"])
+	})
+
+	t.Run("whole team", func(t *testing.T) {
+		repo := newRepo()
+		svc := NewService(repo, Options{Strategy: StrategyLeastLoaded})
+		svc.rand = randSource(5)
+		deactivated, reassignments, err := svc.DeactivateUsers(ctx, "team", nil)
+		require.NoError(t, err)
+		require.Len(t, deactivated, 4)
+		for _, ra := range reassignments {
+			require.Empty(t, ra.NewReviewerID)
+		}
+		require.Empty(t, repo.reviewers["pr1"])
+		require.Empty(t, repo.reviewers["pr2"])
+	})
+
+	t.Run("fallback team and declined", func(t *testing.T) {
+		repo := newRepo()
+		repo.settings["team"] = entity.TeamSettings{TeamName: "team", ReviewerCount: 2, FallbackTeams: []string{"other"}}
+		repo.declined["pr1"] = []string{"d"}
+		svc := NewService(repo, Options{})
+		_, reassignments, err := svc.DeactivateUsers(ctx, "team", []string{"b", "c"})
+		require.NoError(t, err)
+		require.Len(t, reassignments, 3)
+		require.Equal(t, []entity.Reassignment{
+			{PullRequestID: "pr1", OldReviewerID: "b", NewReviewerID: "x"},
+			{PullRequestID: "pr1", OldReviewerID: "c", Error: ErrNoCandidate.Error()},
+		}, reassignments[:2])
+		require.Equal(t, []string{"x"}, repo.reviewers["pr1"])
+
+		decisions := repo.decisions["pr1"]
+		require.Len(t, decisions, 2)
+		require.Equal(t, entity.DecisionDeactivate, decisions[0].Trigger)
+		require.Equal(t, "b", decisions[0].ReplacedReviewerID)
+		require.Equal(t, []string{"team", "other"}, decisions[0].Teams)
+		require.Contains(t, decisions[0].Excluded, entity.ExcludedCandidate{UserID: "d", Team: "team", Reason: entity.ExcludedDeclined})
+		require.Equal(t, []string{"x"}, decisions[0].Selected)
+		require.Empty(t, decisions[1].Selected)
+		require.Contains(t, decisions[1].Excluded, entity.ExcludedCandidate{UserID: "x", Team: "other", Reason: entity.ExcludedAssigned})
+		require.Contains(t, repo.decisions["pr2"][0].Excluded, entity.ExcludedCandidate{UserID: "c", Team: "team", Reason: entity.ExcludedInactive})
+	})
+
+	t.Run("capacity counts earlier picks", func(t *testing.T) {
+		one, zero := 1, 0
+		repo := newRepo()
+		repo.users["a"] = entity.User{UserID: "a", TeamName: "team", IsActive: true, ReviewCapacity: &zero}
+		repo.users["d"] = entity.User{UserID: "d", TeamName: "team", IsActive: true, ReviewCapacity: &one}
+		svc := NewService(repo, Options{})
+		_, reassignments, err := svc.DeactivateUsers(ctx, "team", []string{"b", "c"})
+		require.NoError(t, err)
+		require.Equal(t, "d", reassignments[0].NewReviewerID)
+		require.Equal(t, ErrNoCandidate.Error(), reassignments[1].Error)
+		require.Equal(t, ErrAtCapacity.Error(), reassignments[2].Error)
+	})
+
+	t.Run("unknown member", func(t *testing.T) {
+		svc := NewService(newRepo(), Options{})
+		_, _, err := svc.DeactivateUsers(ctx, "team", []string{"b", "x"})
+		require.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		svc := NewService(newRepo(), Options{})
+		_, _, err := svc.DeactivateUsers(ctx, " ", nil)
+		require.ErrorIs(t, err, ErrInvalidInput)
+	})
+}
+
+// BenchmarkPlanReassignments plans the bulk deactivation of half of a 200 member team
+// with 300 open PRs, the planner must not read the repository per review.
+func BenchmarkPlanReassignments(b *testing.B) {
+	ctx := context.Background()
+	repo := newPRRepoStub()
+	for i := 0; i < 200; i++ {
+		id := fmt.Sprintf("u%d", i)
+		repo.users[id] = entity.User{UserID: id, TeamName: "big", IsActive: true}
+	}
+	deactivated := make([]string, 0, 100)
+	for i := 0; i < 100; i++ {
+		deactivated = append(deactivated, fmt.Sprintf("u%d", i))
+	}
+	reviews := make([]OpenReview, 0, 600)
+	for i := 0; i < 300; i++ {
+		prID := fmt.Sprintf("pr%d", i)
+		assigned := []string{fmt.Sprintf("u%d", (i+1)%200), fmt.Sprintf("u%d", (i+2)%200)}
+		for _, reviewer := range assigned {
+			if slices.Contains(deactivated, reviewer) {
+				reviews = append(reviews, OpenReview{PullRequestID: prID, AuthorID: fmt.Sprintf("u%d", i%200), ReviewerID: reviewer, Assigned: assigned})
+			}
+		}
+	}
+	svc := NewService(repo, Options{Strategy: StrategyLeastLoaded})
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := svc.planReassignments(ctx, "big", deactivated, reviews); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// racingRepo moves every PR to status right after it was read, like a status change racing with the request.
+type racingRepo struct {
+	*prRepoStub
+	status string
+}
+
+func (r racingRepo) Get(ctx context.Context, id string) (entity.PullRequest, error) {
+	pr, err := r.prRepoStub.Get(ctx, id)
+	if err == nil {
+		changed := r.prs[id]
+		changed.Status = r.status
+		r.prs[id] = changed
+	}
+	return pr, err
+}
+
+func TestConcurrentStatusChange(t *testing.T) {
+	ctx := context.Background()
+	newRepo := func(status string) racingRepo {
+		repo := newPRRepoStub()
+		for _, id := range []string{"a", "b", "c"} {
+			repo.users[id] = entity.User{UserID: id, TeamName: "team", IsActive: true}
+		}
+		repo.prs["pr1"] = entity.PullRequest{PullRequestID: "pr1", AuthorID: "a", Status: entity.StatusOpen}
+		repo.reviewers["pr1"] = []string{"b"}
+		return racingRepo{repo, status}
+	}
+
+	repo := newRepo(entity.StatusMerged)
+	_, _, err := NewService(repo, Options{}).Reassign(ctx, "pr1", "b", "")
+	require.ErrorIs(t, err, ErrStatusChanged)
+	require.Equal(t, []string{"b"}, repo.reviewers["pr1"])
+	require.Empty(t, repo.decisions["pr1"])
+
+	repo = newRepo(entity.StatusMerged)
+	_, err = NewService(repo, Options{}).Review(ctx, "pr1", "b", entity.ReviewApproved)
+	require.ErrorIs(t, err, ErrStatusChanged)
+	require.Empty(t, repo.states["pr1"])
+
+	repo = newRepo(entity.StatusClosed)
+	_, _, err = NewService(repo, Options{}).Respond(ctx, "pr1", "b", entity.ResponseAccepted, "")
+	require.ErrorIs(t, err, ErrStatusChanged)
+	require.Empty(t, repo.responses["pr1"])
+
+	// a PR closed while the merge was checked stays closed
+	repo = newRepo(entity.StatusClosed)
+	_, err = NewService(repo, Options{}).Merge(ctx, "pr1")
+	require.ErrorIs(t, err, ErrStatusChanged)
+	require.Equal(t, entity.StatusClosed, repo.prs["pr1"].Status)
+	require.Nil(t, repo.prs["pr1"].MergedAt)
+}
+
+func TestReviewCapacity(t *testing.T) {
+	ctx := context.Background()
+	one, zero := 1, 0
+	repo := newPRRepoStub()
+	repo.users["a"] = entity.User{UserID: "a", TeamName: "team", IsActive: true}
+	repo.users["b"] = entity.User{UserID: "b", TeamName: "team", IsActive: true}
+	repo.users["c"] = entity.User{UserID: "c", TeamName: "team", IsActive: true, ReviewCapacity: &zero}
+	repo.users["d"] = entity.User{UserID: "d", TeamName: "team", IsActive: true}
+	repo.settings["team"] = entity.TeamSettings{TeamName: "team", ReviewerCount: 2, ReviewCapacity: &one}
+	repo.prs["busy"] = entity.PullRequest{PullRequestID: "busy", AuthorID: "a", Status: "OPEN"}
+	repo.reviewers["busy"] = []string{"d"}
+	svc := NewService(repo, Options{})
+	svc.rand = randSource(6)
+
+	pr, err := svc.Create(ctx, entity.PullRequest{PullRequestID: "pr1", PullRequestName: "P", AuthorID: "a"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"b"}, pr.Assigned)
+
+	_, _, err = svc.Reassign(ctx, "busy", "d", "")
+	require.ErrorIs(t, err, ErrAtCapacity)
+
+	repo.users["c"] = entity.User{UserID: "c", TeamName: "team", IsActive: true}
+	_, replacement, err := svc.Reassign(ctx, "busy", "d", "")
+	require.NoError(t, err)
+	require.Equal(t, "c", replacement)
+
+	// b and c are full now and d takes no reviews
+	repo.users["d"] = entity.User{UserID: "d", TeamName: "team", IsActive: true, ReviewCapacity: &zero}
+	_, err = svc.Create(ctx, entity.PullRequest{PullRequestID: "pr2", PullRequestName: "P", AuthorID: "a"})
+	require.ErrorIs(t, err, ErrAtCapacity)
+	_, err = repo.Get(ctx, "pr2")
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+}
+
+func TestReview(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name     string
+		id       string
+		reviewer string
+		decision string
+		wantErr  error
+	}{
+		{name: "approve", id: "pr1", reviewer: "b", decision: entity.ReviewApproved},
+		{name: "request changes", id: "pr1", reviewer: "b", decision: entity.ReviewChangesRequested},
+		{name: "unknown decision", id: "pr1", reviewer: "b", decision: "LGTM", wantErr: ErrInvalidInput},
+		{name: "invalid", id: "", reviewer: "b", decision: entity.ReviewApproved, wantErr: ErrInvalidInput},
+		{name: "missing pr", id: "missing", reviewer: "b", decision: entity.ReviewApproved, wantErr: ErrNotFound},
+		{name: "merged", id: "pr2", reviewer: "b", decision: entity.ReviewApproved, wantErr: ErrMerged},
+		{name: "not assigned", id: "pr1", reviewer: "c", decision: entity.ReviewApproved, wantErr: ErrNotAssigned},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			repo := newPRRepoStub()
+			repo.prs["pr1"] = entity.PullRequest{PullRequestID: "pr1", AuthorID: "a", Status: "OPEN"}
+			repo.reviewers["pr1"] = []string{"b", "d"}
+			repo.prs["pr2"] = entity.PullRequest{PullRequestID: "pr2", AuthorID: "a", Status: "MERGED"}
+			repo.reviewers["pr2"] = []string{"b"}
+			svc := NewService(repo, Options{})
+
+			pr, err := svc.Review(ctx, tt.id, tt.reviewer, tt.decision)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.ElementsMatch(t, []entity.ReviewerState{
+				{ReviewerID: "b", State: tt.decision, Response: entity.ResponsePending},
+				{ReviewerID: "d", State: entity.ReviewPending, Response: entity.ResponsePending},
+			}, pr.Reviews)
+		})
+	}
+}
+
+func TestLeastLoadedStrategy(t *testing.T) {
+	ctx := context.Background()
+	repo := newPRRepoStub()
+	repo.users["author"] = entity.User{UserID: "author", TeamName: "team", IsActive: true}
+	repo.users["busy"] = entity.User{UserID: "busy", TeamName: "team", IsActive: true}
+	repo.users["free1"] = entity.User{UserID: "free1", TeamName: "team", IsActive: true}
+	repo.users["free2"] = entity.User{UserID: "free2", TeamName: "team", IsActive: true}
+	repo.prs["old1"] = entity.PullRequest{PullRequestID: "old1", Status: "OPEN"}
+	repo.reviewers["old1"] = []string{"busy"}
+	repo.prs["old2"] = entity.PullRequest{PullRequestID: "old2", Status: "MERGED"}
+	repo.reviewers["old2"] = []string{"free1", "free2"}
+
+	for seed := int64(0); seed < 10; seed++ {
+		svc := NewService(repo, Options{Strategy: StrategyLeastLoaded})
+		picked, err := svc.strategyFor(entity.TeamSettings{}).Pick(ctx, []entity.User{repo.users["busy"], repo.users["free1"], repo.users["free2"]}, 2, randSource(seed))
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"free1", "free2"}, picked)
+	}
+}
+
 func randSource(seed int64) *rand.Rand {
 	return rand.New(rand.NewSource(seed))
 }
+
+func TestTimeline(t *testing.T) {
+	ctx := context.Background()
+	repo := newPRRepoStub()
+	for _, id := range []string{"a", "b", "c"} {
+		repo.users[id] = entity.User{UserID: id, TeamName: "team", IsActive: true}
+	}
+	repo.prs["pr1"] = entity.PullRequest{PullRequestID: "pr1", AuthorID: "a", Status: entity.StatusOpen}
+	repo.reviewers["pr1"] = []string{"b"}
+	svc := NewService(repo, Options{})
+
+	_, err := svc.Timeline(ctx, " ")
+	require.ErrorIs(t, err, ErrInvalidInput)
+	_, err = svc.Timeline(ctx, "missing")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	_, replaced, err := svc.Reassign(ctx, "pr1", "b", " lead ")
+	require.NoError(t, err)
+	require.Equal(t, "c", replaced)
+
+	events, err := svc.Timeline(ctx, "pr1")
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, entity.EventReviewerReplaced, events[0].Type)
+	require.Equal(t, "lead", events[0].ActorID)
+	require.Equal(t, "b", events[0].OldReviewerID)
+	require.Equal(t, "c", events[0].ReviewerID)
+}
+
+type codeOwnersStub map[string][]string
+
+func (s codeOwnersStub) Owners(ctx context.Context, repository, content string, files []string) ([]string, error) {
+	var owners []string
+	for _, f := range files {
+		owners = append(owners, s[f]...)
+	}
+	return owners, nil
+}
+
+func TestCreateCodeOwners(t *testing.T) {
+	ctx := context.Background()
+	repo := newPRRepoStub()
+	for _, id := range []string{"author", "u1", "u2", "u3", "u4"} {
+		repo.users[id] = entity.User{UserID: id, TeamName: "team", IsActive: true}
+	}
+	owners := codeOwnersStub{
+		"api/handler.go": {"author", "u3"},
+		"docs/README.md": {"outsider"},
+	}
+	svc := NewService(repo, Options{CodeOwners: owners})
+	svc.rand = randSource(1)
+
+	pr, err := svc.CreateWithCodeOwners(ctx, entity.PullRequest{PullRequestID: "p1", PullRequestName: "P", AuthorID: "author"},
+		CodeOwnersRequest{Files: []string{"api/handler.go"}, Require: true})
+	require.NoError(t, err)
+	require.Equal(t, []string{"u3"}, pr.CodeOwners)
+	require.Len(t, pr.Assigned, 2)
+	require.Contains(t, pr.Assigned, "u3")
+	for _, rev := range pr.Reviews {
+		require.Equal(t, rev.ReviewerID == "u3", rev.CodeOwner)
+	}
+
+	_, err = svc.CreateWithCodeOwners(ctx, entity.PullRequest{PullRequestID: "p2", PullRequestName: "P", AuthorID: "author"},
+		CodeOwnersRequest{Files: []string{"docs/README.md"}, Require: true})
+	require.ErrorIs(t, err, ErrNoCodeOwner)
+
+	pr, err = svc.CreateWithCodeOwners(ctx, entity.PullRequest{PullRequestID: "p3", PullRequestName: "P", AuthorID: "author"},
+		CodeOwnersRequest{Files: []string{"docs/README.md"}})
+	require.NoError(t, err)
+	require.Len(t, pr.Assigned, 2)
+
+	// the owners of a draft are kept until it is marked ready
+	pr, err = svc.CreateWithCodeOwners(ctx, entity.PullRequest{PullRequestID: "p4", PullRequestName: "P", AuthorID: "author", Status: entity.StatusDraft},
+		CodeOwnersRequest{Files: []string{"api/handler.go"}, Require: true})
+	require.NoError(t, err)
+	require.Empty(t, pr.Assigned)
+	pr, err = svc.MarkReady(ctx, "p4")
+	require.NoError(t, err)
+	require.Contains(t, pr.Assigned, "u3")
+}
+
+func TestCreateRepositoryTeams(t *testing.T) {
+	ctx := context.Background()
+	repo := newPRRepoStub()
+	repo.users["author"] = entity.User{UserID: "author", TeamName: "web", IsActive: true}
+	repo.users["w1"] = entity.User{UserID: "w1", TeamName: "web", IsActive: true}
+	repo.users["p1"] = entity.User{UserID: "p1", TeamName: "platform", IsActive: true}
+	repo.users["s1"] = entity.User{UserID: "s1", TeamName: "sre", IsActive: true}
+	repo.users["s2"] = entity.User{UserID: "s2", TeamName: "sre", IsActive: true}
+	repo.repos["acme/api"] = entity.Repository{RepositoryID: "acme/api", OwnerTeam: "platform", SecondaryTeams: []string{"sre"}}
+	repo.repos["acme/web"] = entity.Repository{RepositoryID: "acme/web", OwnerTeam: "web"}
+	repo.settings["sre"] = entity.TeamSettings{TeamName: "sre", ReviewerCount: 2, ExcludedUsers: []string{"s2"}}
+	svc := NewService(repo, Options{})
+	svc.rand = randSource(1)
+
+	pr, err := svc.Create(ctx, entity.PullRequest{PullRequestID: "pr1", PullRequestName: "API", AuthorID: "author", RepositoryID: "acme/api"})
+	require.NoError(t, err)
+	require.Equal(t, "acme/api", pr.RepositoryID)
+	require.Equal(t, []string{"p1", "s1"}, pr.Assigned)
+
+	pr, err = svc.Create(ctx, entity.PullRequest{PullRequestID: "pr2", PullRequestName: "Web", AuthorID: "author", RepositoryID: "acme/web"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"w1"}, pr.Assigned)
+
+	_, err = svc.Create(ctx, entity.PullRequest{PullRequestID: "pr3", PullRequestName: "X", AuthorID: "author", RepositoryID: "acme/missing"})
+	require.ErrorIs(t, err, ErrNoRepository)
+}
+
+func TestFallbackTeams(t *testing.T) {
+	ctx := context.Background()
+	repo := newPRRepoStub()
+	repo.users["solo"] = entity.User{UserID: "solo", TeamName: "mobile", IsActive: true}
+	repo.users["m1"] = entity.User{UserID: "m1", TeamName: "mobile", IsActive: true}
+	repo.users["w1"] = entity.User{UserID: "w1", TeamName: "web", IsActive: true}
+	repo.users["p1"] = entity.User{UserID: "p1", TeamName: "platform", IsActive: true}
+	repo.settings["mobile"] = entity.TeamSettings{TeamName: "mobile", ReviewerCount: 2, FallbackTeams: []string{"web", "platform"}}
+	repo.settings["web"] = entity.TeamSettings{TeamName: "web", ReviewerCount: 2, FallbackTeams: []string{"platform"}}
+	svc := NewService(repo, Options{})
+	svc.rand = randSource(1)
+
+	pr, err := svc.Create(ctx, entity.PullRequest{PullRequestID: "pr1", PullRequestName: "App", AuthorID: "solo"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"m1", "w1"}, pr.Assigned)
+	require.Equal(t, "mobile", pr.Reviews[0].Team)
+	require.Equal(t, "web", pr.Reviews[1].Team)
+
+	pr, newReviewer, err := svc.Reassign(ctx, "pr1", "w1", "")
+	require.NoError(t, err)
+	require.Equal(t, "p1", newReviewer)
+	require.Equal(t, []string{"m1", "p1"}, pr.Assigned)
+
+	_, newReviewer, err = svc.Reassign(ctx, "pr1", "m1", "")
+	require.NoError(t, err)
+	require.Equal(t, "w1", newReviewer)
+
+	_, _, err = svc.Reassign(ctx, "pr1", "p1", "")
+	require.ErrorIs(t, err, ErrNoCandidate)
+}
+
+func TestAssignmentExplain(t *testing.T) {
+	ctx := context.Background()
+	zero := 0
+	repo := newPRRepoStub()
+	repo.users["author"] = entity.User{UserID: "author", TeamName: "team", IsActive: true}
+	repo.users["away"] = entity.User{UserID: "away", TeamName: "team", IsActive: true}
+	repo.users["full"] = entity.User{UserID: "full", TeamName: "team", IsActive: true, ReviewCapacity: &zero}
+	repo.users["gone"] = entity.User{UserID: "gone", TeamName: "team"}
+	repo.users["lead"] = entity.User{UserID: "lead", TeamName: "team", IsActive: true}
+	repo.users["r1"] = entity.User{UserID: "r1", TeamName: "team", IsActive: true}
+	repo.users["r2"] = entity.User{UserID: "r2", TeamName: "team", IsActive: true}
+	repo.users["r3"] = entity.User{UserID: "r3", TeamName: "team", IsActive: true}
+	repo.absent["away"] = true
+	repo.settings["team"] = entity.TeamSettings{TeamName: "team", ReviewerCount: 2, ExcludedUsers: []string{"lead"}}
+	svc := NewService(repo, Options{})
+	svc.rand = randSource(3)
+
+	_, err := svc.AssignmentExplain(ctx, "missing")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	pr, err := svc.Create(ctx, entity.PullRequest{PullRequestID: "pr1", PullRequestName: "P", AuthorID: "author"})
+	require.NoError(t, err)
+	decisions, err := svc.AssignmentExplain(ctx, " pr1 ")
+	require.NoError(t, err)
+	require.Len(t, decisions, 1)
+	d := decisions[0]
+	require.Equal(t, entity.DecisionCreate, d.Trigger)
+	require.Equal(t, StrategyRandom, d.Strategy)
+	require.Equal(t, []string{"team"}, d.Teams)
+	require.Equal(t, []string{"r1", "r2", "r3"}, d.Candidates)
+	require.Equal(t, pr.Assigned, d.Selected)
+	require.Equal(t, []entity.ExcludedCandidate{
+		{UserID: "author", Team: "team", Reason: entity.ExcludedAuthor},
+		{UserID: "away", Team: "team", Reason: entity.ExcludedAbsent},
+		{UserID: "gone", Team: "team", Reason: entity.ExcludedInactive},
+		{UserID: "lead", Team: "team", Reason: entity.ExcludedBySettings},
+		{UserID: "full", Team: "team", Reason: entity.ExcludedAtCapacity},
+	}, d.Excluded)
+
+	// the recorded seed replays the same pick
+	candidates := []entity.User{repo.users["r1"], repo.users["r2"], repo.users["r3"]}
+	replayed, err := RandomStrategy{}.Pick(ctx, candidates, 2, randSource(d.Seed))
+	require.NoError(t, err)
+	require.Equal(t, d.Selected, replayed)
+
+	_, replacement, err := svc.Reassign(ctx, "pr1", pr.Assigned[0], "")
+	require.NoError(t, err)
+	decisions, err = svc.AssignmentExplain(ctx, "pr1")
+	require.NoError(t, err)
+	require.Len(t, decisions, 2)
+	require.Equal(t, entity.DecisionReassign, decisions[1].Trigger)
+	require.Equal(t, pr.Assigned[0], decisions[1].ReplacedReviewerID)
+	require.Equal(t, []string{replacement}, decisions[1].Candidates)
+	require.Equal(t, []string{replacement}, decisions[1].Selected)
+}
+
+func TestPreview(t *testing.T) {
+	ctx := context.Background()
+	repo := newPRRepoStub()
+	for _, id := range []string{"author", "r1", "r2", "r3", "r4"} {
+		repo.users[id] = entity.User{UserID: id, TeamName: "team", IsActive: true}
+	}
+	repo.users["gone"] = entity.User{UserID: "gone", TeamName: "team"}
+	input := entity.PullRequest{PullRequestID: "pr1", PullRequestName: "P", AuthorID: "author", Status: entity.StatusDraft}
+
+	preview := NewService(repo, Options{})
+	preview.rand = randSource(4)
+	pr, decision, err := preview.Preview(ctx, input, CodeOwnersRequest{})
+	require.NoError(t, err)
+	require.Equal(t, entity.StatusOpen, pr.Status)
+	require.Len(t, pr.Assigned, entity.DefaultReviewerCount)
+	require.Equal(t, pr.Assigned, decision.Selected)
+	require.Equal(t, entity.DecisionPreview, decision.Trigger)
+	require.Equal(t, []string{"r1", "r2", "r3", "r4"}, decision.Candidates)
+	require.Empty(t, repo.prs)
+	require.Empty(t, repo.decisions)
+
+	// the same seed makes the real creation pick the previewed reviewers
+	create := NewService(repo, Options{})
+	create.rand = randSource(4)
+	input.Status = ""
+	created, err := create.Create(ctx, input)
+	require.NoError(t, err)
+	require.Equal(t, pr.Assigned, created.Assigned)
+
+	_, _, err = preview.Preview(ctx, input, CodeOwnersRequest{})
+	require.ErrorIs(t, err, ErrExists)
+	_, _, err = preview.Preview(ctx, entity.PullRequest{PullRequestID: "pr2", AuthorID: "author"}, CodeOwnersRequest{})
+	require.ErrorIs(t, err, ErrInvalidInput)
+	_, _, err = preview.Preview(ctx, entity.PullRequest{PullRequestID: "pr2", PullRequestName: "P", AuthorID: "gone"}, CodeOwnersRequest{})
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestManualReviewers(t *testing.T) {
+	ctx := context.Background()
+	repo := newPRRepoStub()
+	repo.users["author"] = entity.User{UserID: "author", TeamName: "team", IsActive: true}
+	repo.users["r1"] = entity.User{UserID: "r1", TeamName: "team", IsActive: true}
+	repo.users["r2"] = entity.User{UserID: "r2", TeamName: "other", IsActive: true}
+	repo.users["gone"] = entity.User{UserID: "gone", TeamName: "team"}
+	repo.prs["pr1"] = entity.PullRequest{PullRequestID: "pr1", AuthorID: "author", Status: entity.StatusOpen}
+	repo.reviewers["pr1"] = []string{"r1"}
+	repo.prs["merged"] = entity.PullRequest{PullRequestID: "merged", AuthorID: "author", Status: entity.StatusMerged}
+	svc := NewService(repo, Options{})
+
+	pr, err := svc.AddReviewer(ctx, " pr1 ", " r2 ", "lead")
+	require.NoError(t, err)
+	require.Equal(t, []string{"r1", "r2"}, pr.Assigned)
+
+	tests := []struct {
+		name     string
+		prID     string
+		reviewer string
+		wantErr  error
+	}{
+		{name: "empty", prID: "pr1", reviewer: " ", wantErr: ErrInvalidInput},
+		{name: "missing pr", prID: "missing", reviewer: "r2", wantErr: ErrNotFound},
+		{name: "merged", prID: "merged", reviewer: "r2", wantErr: ErrMerged},
+		{name: "author", prID: "pr1", reviewer: "author", wantErr: ErrIsAuthor},
+		{name: "assigned", prID: "pr1", reviewer: "r1", wantErr: ErrAssigned},
+		{name: "inactive", prID: "pr1", reviewer: "gone", wantErr: ErrNotFound},
+		{name: "unknown user", prID: "pr1", reviewer: "nobody", wantErr: ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.AddReviewer(ctx, tt.prID, tt.reviewer, "lead")
+			require.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+
+	pr, err = svc.RemoveReviewer(ctx, "pr1", "r1", "lead")
+	require.NoError(t, err)
+	require.Equal(t, []string{"r2"}, pr.Assigned)
+	_, err = svc.RemoveReviewer(ctx, "pr1", "r1", "lead")
+	require.ErrorIs(t, err, ErrNotAssigned)
+	_, err = svc.RemoveReviewer(ctx, "merged", "r1", "lead")
+	require.ErrorIs(t, err, ErrMerged)
+
+	events, err := svc.Timeline(ctx, "pr1")
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	require.Equal(t, entity.EventReviewerAssigned, events[0].Type)
+	require.Equal(t, "lead", events[0].ActorID)
+	require.Equal(t, entity.EventReviewerRemoved, events[1].Type)
+	require.Equal(t, "r1", events[1].OldReviewerID)
+}
+
+func TestRespond(t *testing.T) {
+	ctx := context.Background()
+	repo := newPRRepoStub()
+	for _, id := range []string{"author", "r1", "r2"} {
+		repo.users[id] = entity.User{UserID: id, TeamName: "team", IsActive: true}
+	}
+	repo.prs["pr1"] = entity.PullRequest{PullRequestID: "pr1", AuthorID: "author", Status: entity.StatusOpen}
+	repo.reviewers["pr1"] = []string{"r1"}
+	svc := NewService(repo, Options{})
+
+	pr, replacement, err := svc.Respond(ctx, "pr1", "r1", entity.ResponseAccepted, "")
+	require.NoError(t, err)
+	require.Empty(t, replacement)
+	require.Equal(t, entity.ResponseAccepted, pr.Reviews[0].Response)
+
+	_, _, err = svc.Respond(ctx, "pr1", "r1", entity.ResponseDeclined, " ")
+	require.ErrorIs(t, err, ErrInvalidInput)
+	_, _, err = svc.Respond(ctx, "pr1", "r1", "MAYBE", "")
+	require.ErrorIs(t, err, ErrInvalidInput)
+	_, _, err = svc.Respond(ctx, "pr1", "r2", entity.ResponseAccepted, "")
+	require.ErrorIs(t, err, ErrNotAssigned)
+
+	pr, replacement, err = svc.Respond(ctx, "pr1", "r1", entity.ResponseDeclined, "on vacation")
+	require.NoError(t, err)
+	require.Equal(t, "r2", replacement)
+	require.Equal(t, []string{"r2"}, pr.Assigned)
+
+	// r1 already declined and is not offered back
+	_, _, err = svc.Respond(ctx, "pr1", "r2", entity.ResponseDeclined, "busy")
+	require.ErrorIs(t, err, ErrNoCandidate)
+
+	repo.users["r3"] = entity.User{UserID: "r3", TeamName: "team", IsActive: true}
+	_, replacement, err = svc.Respond(ctx, "pr1", "r2", entity.ResponseDeclined, "busy")
+	require.NoError(t, err)
+	require.Equal(t, "r3", replacement)
+
+	decisions, err := svc.AssignmentExplain(ctx, "pr1")
+	require.NoError(t, err)
+	require.Len(t, decisions, 2)
+	require.Equal(t, entity.DecisionDecline, decisions[1].Trigger)
+	require.Contains(t, decisions[1].Excluded, entity.ExcludedCandidate{UserID: "r1", Team: "team", Reason: entity.ExcludedDeclined})
+}
+
+func TestList(t *testing.T) {
+	ctx := context.Background()
+	repo := newPRRepoStub()
+	repo.users["a"] = entity.User{UserID: "a", TeamName: "backend", IsActive: true}
+	repo.users["b"] = entity.User{UserID: "b", TeamName: "frontend", IsActive: true}
+	base := time.Date(2026, 10, 1, 10, 0, 0, 0, time.UTC)
+	at := func(hours int) *time.Time {
+		ts := base.Add(time.Duration(hours) * time.Hour)
+		return &ts
+	}
+	repo.prs["pr1"] = entity.PullRequest{PullRequestID: "pr1", AuthorID: "a", Status: entity.StatusMerged, CreatedAt: at(0), MergedAt: at(5)}
+	repo.prs["pr2"] = entity.PullRequest{PullRequestID: "pr2", AuthorID: "a", Status: entity.StatusOpen, CreatedAt: at(1)}
+	repo.prs["pr3"] = entity.PullRequest{PullRequestID: "pr3", AuthorID: "b", Status: entity.StatusOpen, CreatedAt: at(1)}
+	repo.prs["pr4"] = entity.PullRequest{PullRequestID: "pr4", AuthorID: "b", Status: entity.StatusMerged, CreatedAt: at(2), MergedAt: at(3)}
+	repo.reviewers["pr2"] = []string{"b"}
+	repo.reviewers["pr4"] = []string{"a"}
+	svc := NewService(repo, Options{})
+
+	ids := func(page ListPage) []string {
+		result := make([]string, 0, len(page.PullRequests))
+		for _, pr := range page.PullRequests {
+			result = append(result, pr.PullRequestID)
+		}
+		return result
+	}
+
+	// pages of two walk all PRs newest first, ties broken by id
+	var all []string
+	cursor := ""
+	for {
+		page, err := svc.List(ctx, ListFilter{Limit: 2}, cursor)
+		require.NoError(t, err)
+		all = append(all, ids(page)...)
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+	require.Equal(t, []string{"pr4", "pr3", "pr2", "pr1"}, all)
+
+	page, err := svc.List(ctx, ListFilter{Limit: 1, Order: OrderAsc}, "")
+	require.NoError(t, err)
+	require.Equal(t, []string{"pr1"}, ids(page))
+	page, err = svc.List(ctx, ListFilter{Limit: 2, Order: OrderAsc}, page.NextCursor)
+	require.NoError(t, err)
+	require.Equal(t, []string{"pr2", "pr3"}, ids(page))
+
+	page, err = svc.List(ctx, ListFilter{Sort: SortMergedAt}, "")
+	require.NoError(t, err)
+	require.Equal(t, []string{"pr1", "pr4"}, ids(page))
+	require.Empty(t, page.NextCursor)
+
+	for _, tc := range []struct {
+		name   string
+		filter ListFilter
+		want   []string
+	}{
+		{"status", ListFilter{Statuses: []string{entity.StatusOpen}}, []string{"pr3", "pr2"}},
+		{"author", ListFilter{AuthorID: " a "}, []string{"pr2", "pr1"}},
+		{"reviewer", ListFilter{ReviewerID: "a"}, []string{"pr4"}},
+		{"team", ListFilter{Team: "frontend"}, []string{"pr4", "pr3"}},
+		{"created range", ListFilter{CreatedFrom: at(1), CreatedTo: at(2)}, []string{"pr3", "pr2"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			page, err := svc.List(ctx, tc.filter, "")
+			require.NoError(t, err)
+			require.Equal(t, tc.want, ids(page))
+		})
+	}
+
+	asc, err := svc.List(ctx, ListFilter{Limit: 1, Order: OrderAsc}, "")
+	require.NoError(t, err)
+	for _, tc := range []struct {
+		name   string
+		filter ListFilter
+		cursor string
+	}{
+		{"unknown status", ListFilter{Statuses: []string{"NEW"}}, ""},
+		{"unknown sort", ListFilter{Sort: "name"}, ""},
+		{"unknown order", ListFilter{Order: "up"}, ""},
+		{"limit too large", ListFilter{Limit: MaxListLimit + 1}, ""},
+		{"negative limit", ListFilter{Limit: -1}, ""},
+		{"empty range", ListFilter{CreatedFrom: at(2), CreatedTo: at(1)}, ""},
+		{"malformed cursor", ListFilter{}, "!!"},
+		{"cursor of another order", ListFilter{}, asc.NextCursor},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := svc.List(ctx, tc.filter, tc.cursor)
+			require.ErrorIs(t, err, ErrInvalidInput)
+		})
+	}
+}
+
+func TestGet(t *testing.T) {
+	ctx := context.Background()
+	repo := newPRRepoStub()
+	repo.prs["pr1"] = entity.PullRequest{PullRequestID: "pr1", AuthorID: "a", Status: entity.StatusOpen}
+	repo.reviewers["pr1"] = []string{"b"}
+	svc := NewService(repo, Options{})
+
+	pr, err := svc.Get(ctx, " pr1 ")
+	require.NoError(t, err)
+	require.Equal(t, []string{"b"}, pr.Assigned)
+	_, err = svc.Get(ctx, "")
+	require.ErrorIs(t, err, ErrInvalidInput)
+	_, err = svc.Get(ctx, "missing")
+	require.ErrorIs(t, err, ErrNotFound)
+}
@@ -0,0 +1,233 @@
+package pullrequests
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"avito-internship-task/internal/entity"
+)
+
+// Breach is a pending review assignment older than the SLA of the reviewer's team.
+type Breach struct {
+	PullRequestID string
+	ReviewerID    string
+	TeamName      string
+	AssignedAt    time.Time
+	SLAHours      int
+	Policy        string
+}
+
+// SLARepo is the storage side of the SLA worker.
+type SLARepo interface {
+	// BreachCandidates returns pending assignments on OPEN PRs assigned at least SLAHours
+	// calendar hours before now and not escalated yet, oldest first. A non-nil after continues
+	// the listing past that assignment.
+	BreachCandidates(ctx context.Context, now time.Time, after *Breach, limit int) ([]Breach, error)
+	// ClaimEscalation records the escalation and returns its id, or 0 when the same
+	// assignment has already been escalated.
+	ClaimEscalation(ctx context.Context, esc entity.Escalation) (int64, error)
+	CompleteEscalation(ctx context.Context, id int64, newReviewerID, errText string) error
+}
+
+// SLAOptions configure the SLA worker. Now is the clock, the current time by default.
+type SLAOptions struct {
+	Interval      time.Duration
+	BatchSize     int
+	BusinessHours BusinessHours
+	Now           func() time.Time
+}
+
+// SLAWorker escalates review assignments that were not started within
+// the business hours SLA of the reviewer's team.
+type SLAWorker struct {
+	service *Service
+	repo    SLARepo
+	opts    SLAOptions
+}
+
+func NewSLAWorker(service *Service, repo SLARepo, opts SLAOptions) *SLAWorker {
+	if opts.Interval <= 0 {
+		opts.Interval = time.Minute
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+	if !opts.BusinessHours.Valid() {
+		opts.BusinessHours.Start, opts.BusinessHours.End = DefaultBusinessHours.Start, DefaultBusinessHours.End
+	}
+	if opts.Now == nil {
+		opts.Now = func() time.Time { return time.Now().UTC() }
+	}
+	return &SLAWorker{
+		service: service,
+		repo:    repo,
+		opts:    opts,
+	}
+}
+
+// Run checks assignments every Interval until ctx is cancelled.
+func (w *SLAWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.opts.Interval)
+	defer ticker.Stop()
+	for {
+		if err := w.Tick(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("sla: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Tick runs one check: up to BatchSize breached assignments are recorded once and the team policy
+// is applied to them. A policy that cannot be applied is recorded with the error and not retried.
+// Candidates are read page by page, so the ones still within their business hours SLA do not
+// hide the breaches behind them.
+func (w *SLAWorker) Tick(ctx context.Context) error {
+	now := w.opts.Now()
+	handled := 0
+	var after *Breach
+	for {
+		candidates, err := w.repo.BreachCandidates(ctx, now, after, w.opts.BatchSize)
+		if err != nil {
+			return fmt.Errorf("find breaches: %w", err)
+		}
+		for _, breach := range candidates {
+			if w.opts.BusinessHours.Between(breach.AssignedAt, now) < time.Duration(breach.SLAHours)*time.Hour {
+				continue
+			}
+			if err := w.escalate(ctx, breach, now); err != nil {
+				return err
+			}
+			if handled++; handled == w.opts.BatchSize {
+				return nil
+			}
+		}
+		if len(candidates) < w.opts.BatchSize {
+			return nil
+		}
+		after = &candidates[len(candidates)-1]
+	}
+}
+
+func (w *SLAWorker) escalate(ctx context.Context, breach Breach, now time.Time) error {
+	policy := breach.Policy
+	if policy == "" {
+		policy = entity.SLANotify
+	}
+	id, err := w.repo.ClaimEscalation(ctx, entity.Escalation{
+		PullRequestID: breach.PullRequestID,
+		ReviewerID:    breach.ReviewerID,
+		TeamName:      breach.TeamName,
+		Policy:        policy,
+		AssignedAt:    breach.AssignedAt,
+		CreatedAt:     now,
+	})
+	if err != nil {
+		return fmt.Errorf("record escalation of %s on %s: %w", breach.ReviewerID, breach.PullRequestID, err)
+	}
+	if id == 0 || policy == entity.SLANotify {
+		return nil
+	}
+	var errText string
+	newReviewer, err := w.service.Escalate(ctx, breach.PullRequestID, breach.ReviewerID, policy)
+	if err != nil {
+		errText = err.Error()
+	}
+	if err := w.repo.CompleteEscalation(ctx, id, newReviewer, errText); err != nil {
+		return fmt.Errorf("complete escalation %d: %w", id, err)
+	}
+	return nil
+}
+
+// Escalate applies an SLA policy to a late reviewer of an OPEN PR: add_reviewer assigns one more
+// reviewer from the late reviewer's team or its fallback teams, reassign replaces the late reviewer.
+// It returns the reviewer that was assigned, nobody is assigned for notify.
+func (s *Service) Escalate(ctx context.Context, prID, reviewerID, policy string) (string, error) {
+	prID = strings.TrimSpace(prID)
+	reviewerID = strings.TrimSpace(reviewerID)
+	if prID == "" || reviewerID == "" || !entity.IsKnownSLAPolicy(policy) {
+		return "", ErrInvalidInput
+	}
+	if policy == "" || policy == entity.SLANotify {
+		return "", nil
+	}
+	pr, err := s.getAssigned(ctx, prID, reviewerID)
+	if err != nil {
+		return "", err
+	}
+	declined, err := s.repo.DeclinedReviewers(ctx, prID)
+	if err != nil {
+		return "", err
+	}
+	sel, err := s.pickReplacement(ctx, pr, reviewerID, entity.DecisionSLA, declined)
+	if err != nil {
+		return "", err
+	}
+	reviewer := sel.decision.Selected[0]
+	if policy == entity.SLAAddReviewer {
+		sel.decision.ReplacedReviewerID = ""
+		err = s.repo.AddReviewer(ctx, prID, reviewer, "", "sla", &sel.decision)
+	} else {
+		err = s.repo.ReplaceReviewer(ctx, prID, reviewerID, reviewer, "", "sla", &sel.decision)
+	}
+	if err != nil {
+		if isNotFound(err) {
+			return "", ErrStatusChanged
+		}
+		return "", err
+	}
+	return reviewer, nil
+}
+
+// BusinessHours is the daily window SLAs are measured in: from the Start hour to the End hour
+// of every weekday in Location, UTC when it is nil. Holidays are not taken into account.
+type BusinessHours struct {
+	Start    int
+	End      int
+	Location *time.Location
+}
+
+var DefaultBusinessHours = BusinessHours{Start: 9, End: 18}
+
+func (h BusinessHours) Valid() bool {
+	return h.Start >= 0 && h.End <= 24 && h.Start < h.End
+}
+
+// Loc is the time zone of the window.
+func (h BusinessHours) Loc() *time.Location {
+	if h.Location == nil {
+		return time.UTC
+	}
+	return h.Location
+}
+
+// Between is the part of [from, to) falling into business hours.
+func (h BusinessHours) Between(from, to time.Time) time.Duration {
+	loc := h.Loc()
+	from, to = from.In(loc), to.In(loc)
+	var total time.Duration
+	day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc)
+	for day.Before(to) {
+		if weekday := day.Weekday(); weekday != time.Saturday && weekday != time.Sunday {
+			start := time.Date(day.Year(), day.Month(), day.Day(), h.Start, 0, 0, 0, loc)
+			end := time.Date(day.Year(), day.Month(), day.Day(), h.End, 0, 0, 0, loc)
+			if start.Before(from) {
+				start = from
+			}
+			if end.After(to) {
+				end = to
+			}
+			if start.Before(end) {
+				total += end.Sub(start)
+			}
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	return total
+}
@@ -0,0 +1,182 @@
+package pullrequests
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"avito-internship-task/internal/entity"
+	"github.com/stretchr/testify/require"
+)
+
+type slaRepoStub struct {
+	prs         *prRepoStub
+	assignments []Breach
+	escalations []entity.Escalation
+	pages       int
+}
+
+func (r *slaRepoStub) BreachCandidates(ctx context.Context, now time.Time, after *Breach, limit int) ([]Breach, error) {
+	r.pages++
+	key := func(b Breach) string {
+		return b.AssignedAt.Format(time.RFC3339Nano) + "/" + b.PullRequestID + "/" + b.ReviewerID
+	}
+	assignments := slices.Clone(r.assignments)
+	slices.SortFunc(assignments, func(a, b Breach) int { return strings.Compare(key(a), key(b)) })
+	result := make([]Breach, 0)
+	for _, a := range assignments {
+		if a.AssignedAt.Add(time.Duration(a.SLAHours)*time.Hour).After(now) || !slices.Contains(r.prs.reviewers[a.PullRequestID], a.ReviewerID) {
+			continue
+		}
+		if after != nil && key(a) <= key(*after) {
+			continue
+		}
+		escalated := slices.ContainsFunc(r.escalations, func(e entity.Escalation) bool {
+			return e.PullRequestID == a.PullRequestID && e.ReviewerID == a.ReviewerID && e.AssignedAt.Equal(a.AssignedAt)
+		})
+		if !escalated && len(result) < limit {
+			result = append(result, a)
+		}
+	}
+	return result, nil
+}
+
+func (r *slaRepoStub) ClaimEscalation(ctx context.Context, esc entity.Escalation) (int64, error) {
+	esc.EscalationID = int64(len(r.escalations) + 1)
+	r.escalations = append(r.escalations, esc)
+	return esc.EscalationID, nil
+}
+
+func (r *slaRepoStub) CompleteEscalation(ctx context.Context, id int64, newReviewerID, errText string) error {
+	r.escalations[id-1].NewReviewerID = newReviewerID
+	r.escalations[id-1].Error = errText
+	return nil
+}
+
+func TestSLAWorker(t *testing.T) {
+	ctx := context.Background()
+	repo := newPRRepoStub()
+	for _, u := range []entity.User{
+		{UserID: "author", TeamName: "backend"}, {UserID: "r1", TeamName: "backend"}, {UserID: "r2", TeamName: "backend"},
+		{UserID: "o1", TeamName: "ops"}, {UserID: "w1", TeamName: "web"},
+	} {
+		u.IsActive = true
+		repo.users[u.UserID] = u
+	}
+	for _, id := range []string{"pr1", "pr2", "pr3"} {
+		repo.prs[id] = entity.PullRequest{PullRequestID: id, AuthorID: "author", Status: entity.StatusOpen}
+	}
+	repo.reviewers["pr1"] = []string{"r1"}
+	repo.reviewers["pr2"] = []string{"o1"}
+	repo.reviewers["pr3"] = []string{"w1"}
+	svc := NewService(repo, Options{})
+
+	// Friday morning, 8 business hours on Friday and 9 on Monday, the SLA runs out on Tuesday at 16:00
+	assignedAt := time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)
+	slaRepo := &slaRepoStub{prs: repo, assignments: []Breach{
+		{PullRequestID: "pr1", ReviewerID: "r1", TeamName: "backend", AssignedAt: assignedAt, SLAHours: 24, Policy: entity.SLAReassign},
+		{PullRequestID: "pr2", ReviewerID: "o1", TeamName: "ops", AssignedAt: assignedAt, SLAHours: 24},
+		{PullRequestID: "pr3", ReviewerID: "w1", TeamName: "web", AssignedAt: assignedAt, SLAHours: 24, Policy: entity.SLAAddReviewer},
+	}}
+	now := time.Date(2026, 10, 20, 15, 0, 0, 0, time.UTC)
+	w := NewSLAWorker(svc, slaRepo, SLAOptions{BusinessHours: BusinessHours{Start: 9, End: 18}, Now: func() time.Time { return now }})
+	require.NoError(t, w.Tick(ctx))
+	require.Empty(t, slaRepo.escalations)
+
+	now = time.Date(2026, 10, 20, 17, 0, 0, 0, time.UTC)
+	require.NoError(t, w.Tick(ctx))
+	require.Equal(t, []entity.Escalation{
+		{EscalationID: 1, PullRequestID: "pr1", ReviewerID: "r1", TeamName: "backend", Policy: entity.SLAReassign, AssignedAt: assignedAt, NewReviewerID: "r2", CreatedAt: now},
+		{EscalationID: 2, PullRequestID: "pr2", ReviewerID: "o1", TeamName: "ops", Policy: entity.SLANotify, AssignedAt: assignedAt, CreatedAt: now},
+		{EscalationID: 3, PullRequestID: "pr3", ReviewerID: "w1", TeamName: "web", Policy: entity.SLAAddReviewer, AssignedAt: assignedAt, CreatedAt: now, Error: ErrNoCandidate.Error()},
+	}, slaRepo.escalations)
+	require.Equal(t, []string{"r2"}, repo.reviewers["pr1"])
+	require.Equal(t, []string{"o1"}, repo.reviewers["pr2"])
+	require.Equal(t, []string{"w1"}, repo.reviewers["pr3"])
+	require.Equal(t, "sla", repo.events["pr1"][0].Reason)
+	require.Equal(t, entity.DecisionSLA, repo.decisions["pr1"][0].Trigger)
+
+	// every assignment is escalated once
+	repo.users["w2"] = entity.User{UserID: "w2", TeamName: "web", IsActive: true}
+	require.NoError(t, w.Tick(ctx))
+	require.Len(t, slaRepo.escalations, 3)
+
+	reviewer, err := svc.Escalate(ctx, "pr3", "w1", entity.SLAAddReviewer)
+	require.NoError(t, err)
+	require.Equal(t, "w2", reviewer)
+	require.Equal(t, []string{"w1", "w2"}, repo.reviewers["pr3"])
+	require.Empty(t, repo.decisions["pr3"][0].ReplacedReviewerID)
+
+	_, err = svc.Escalate(ctx, "pr3", "r1", entity.SLAReassign)
+	require.ErrorIs(t, err, ErrNotAssigned)
+	_, err = svc.Escalate(ctx, "pr3", "w1", "page")
+	require.ErrorIs(t, err, ErrInvalidInput)
+}
+
+func TestSLAWorkerPagesPastUnbreached(t *testing.T) {
+	ctx := context.Background()
+	repo := newPRRepoStub()
+	repo.users["author"] = entity.User{UserID: "author", TeamName: "backend", IsActive: true}
+	repo.users["r1"] = entity.User{UserID: "r1", TeamName: "backend", IsActive: true}
+	svc := NewService(repo, Options{})
+
+	// Friday evening assignments are past 4 calendar hours but used none of their business hours
+	now := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+	slaRepo := &slaRepoStub{prs: repo}
+	for i := 0; i < 5; i++ {
+		id := fmt.Sprintf("pr%d", i)
+		repo.prs[id] = entity.PullRequest{PullRequestID: id, AuthorID: "author", Status: entity.StatusOpen}
+		repo.reviewers[id] = []string{"r1"}
+		slaRepo.assignments = append(slaRepo.assignments, Breach{
+			PullRequestID: id, ReviewerID: "r1", TeamName: "backend", SLAHours: 4,
+			AssignedAt: time.Date(2026, 10, 16, 19, i, 0, 0, time.UTC),
+		})
+	}
+	// a shorter SLA is breached, but the assignment is listed after the others
+	repo.prs["late"] = entity.PullRequest{PullRequestID: "late", AuthorID: "author", Status: entity.StatusOpen}
+	repo.reviewers["late"] = []string{"r1"}
+	slaRepo.assignments = append(slaRepo.assignments, Breach{
+		PullRequestID: "late", ReviewerID: "r1", TeamName: "backend", SLAHours: 1,
+		AssignedAt: time.Date(2026, 10, 16, 19, 30, 0, 0, time.UTC),
+	})
+
+	w := NewSLAWorker(svc, slaRepo, SLAOptions{BatchSize: 2, Now: func() time.Time { return now }})
+	require.NoError(t, w.Tick(ctx))
+	require.Len(t, slaRepo.escalations, 1)
+	require.Equal(t, "late", slaRepo.escalations[0].PullRequestID)
+	require.Equal(t, 4, slaRepo.pages)
+}
+
+func TestBusinessHours(t *testing.T) {
+	friday := time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)
+	moscow := time.FixedZone("MSK", 3*60*60)
+	tests := []struct {
+		name       string
+		from, to   time.Time
+		start, end int
+		loc        *time.Location
+		want       time.Duration
+	}{
+		{name: "same day", from: friday, to: friday.Add(3 * time.Hour), start: 9, end: 18, want: 3 * time.Hour},
+		{name: "after hours", from: friday, to: friday.Add(12 * time.Hour), start: 9, end: 18, want: 8 * time.Hour},
+		{name: "weekend skipped", from: friday, to: time.Date(2026, 10, 19, 11, 0, 0, 0, time.UTC), start: 9, end: 18, want: 10 * time.Hour},
+		{name: "assigned at night", from: time.Date(2026, 10, 15, 22, 0, 0, 0, time.UTC), to: friday, start: 9, end: 18, want: time.Hour},
+		{name: "saturday", from: friday, to: time.Date(2026, 10, 17, 20, 0, 0, 0, time.UTC), start: 9, end: 18, want: 8 * time.Hour},
+		{name: "next week", from: friday, to: friday.AddDate(0, 0, 7), start: 9, end: 18, want: 45 * time.Hour},
+		{name: "whole day", from: friday, to: time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC), start: 0, end: 24, want: 23 * time.Hour},
+		{name: "before", from: friday, to: friday.Add(-time.Hour), start: 9, end: 18, want: 0},
+		// 09:00-18:00 in UTC+3 is 06:00-15:00 UTC
+		{name: "time zone", from: time.Date(2026, 10, 16, 5, 0, 0, 0, time.UTC), to: time.Date(2026, 10, 16, 16, 0, 0, 0, time.UTC), start: 9, end: 18, loc: moscow, want: 9 * time.Hour},
+		{name: "local weekend", from: time.Date(2026, 10, 16, 22, 0, 0, 0, time.UTC), to: time.Date(2026, 10, 19, 7, 0, 0, 0, time.UTC), start: 0, end: 24, loc: moscow, want: 10 * time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, BusinessHours{Start: tt.start, End: tt.end, Location: tt.loc}.Between(tt.from, tt.to))
+		})
+	}
+	require.False(t, BusinessHours{}.Valid())
+	require.False(t, BusinessHours{Start: 18, End: 9}.Valid())
+}
@@ -0,0 +1,92 @@
+package pullrequests
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+
+	"avito-internship-task/internal/entity"
+)
+
+const (
+	StrategyRandom      = "random"
+	StrategyLeastLoaded = "least_loaded"
+)
+
+// ReviewerStrategy picks up to count reviewers out of already filtered candidates.
+type ReviewerStrategy interface {
+	Pick(ctx context.Context, candidates []entity.User, count int, rnd *rand.Rand) ([]string, error)
+}
+
+type LoadCounter interface {
+	OpenReviewCounts(ctx context.Context, userIDs []string) (map[string]int, error)
+}
+
+func IsKnownStrategy(name string) bool {
+	switch name {
+	case StrategyRandom, StrategyLeastLoaded:
+		return true
+	}
+	return false
+}
+
+type RandomStrategy struct{}
+
+func (RandomStrategy) Pick(_ context.Context, candidates []entity.User, count int, rnd *rand.Rand) ([]string, error) {
+	if len(candidates) == 0 || count <= 0 {
+		return nil, nil
+	}
+	if len(candidates) <= count {
+		return userIDs(candidates), nil
+	}
+	perm := rnd.Perm(len(candidates))
+	selected := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		selected = append(selected, candidates[perm[i]].UserID)
+	}
+	return selected, nil
+}
+
+// LeastLoadedStrategy prefers candidates with the fewest OPEN reviews, ties are broken randomly.
+type LeastLoadedStrategy struct {
+	loads LoadCounter
+}
+
+func NewLeastLoadedStrategy(loads LoadCounter) *LeastLoadedStrategy {
+	return &LeastLoadedStrategy{loads: loads}
+}
+
+func (s *LeastLoadedStrategy) Pick(ctx context.Context, candidates []entity.User, count int, rnd *rand.Rand) ([]string, error) {
+	if len(candidates) == 0 || count <= 0 {
+		return nil, nil
+	}
+	loads, err := s.loads.OpenReviewCounts(ctx, userIDs(candidates))
+	if err != nil {
+		return nil, err
+	}
+	shuffled := make([]entity.User, 0, len(candidates))
+	for _, i := range rnd.Perm(len(candidates)) {
+		shuffled = append(shuffled, candidates[i])
+	}
+	sort.SliceStable(shuffled, func(i, j int) bool {
+		return loads[shuffled[i].UserID] < loads[shuffled[j].UserID]
+	})
+	if len(shuffled) > count {
+		shuffled = shuffled[:count]
+	}
+	return userIDs(shuffled), nil
+}
+
+type loadMap map[string]int
+
+func (m loadMap) OpenReviewCounts(_ context.Context, _ []string) (map[string]int, error) {
+	return m, nil
+}
+
+func userIDs(users []entity.User) []string {
+	ids := make([]string, 0, len(users))
+	for _, u := range users {
+		ids = append(ids, u.UserID)
+	}
+	return ids
+}
@@ -0,0 +1,193 @@
+package repositories
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"avito-internship-task/internal/entity"
+	"avito-internship-task/internal/httpserver"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) Register(mux *http.ServeMux) {
+	mux.Handle("/repositories/add", httpserver.WithError(h.add))
+	mux.Handle("/repositories/get", httpserver.WithError(h.get))
+	mux.Handle("/repositories/list", httpserver.WithError(h.list))
+	mux.Handle("/repositories/update", httpserver.WithError(h.update))
+	mux.Handle("/repositories/delete", httpserver.WithError(h.delete))
+}
+
+type repositoryRequest struct {
+	RepositoryID   string   `json:"repository_id"`
+	OwnerTeam      string   `json:"owner_team"`
+	SecondaryTeams []string `json:"secondary_teams"`
+}
+
+type deleteRequest struct {
+	RepositoryID string `json:"repository_id"`
+}
+
+type repositoryEnvelope struct {
+	Repository entity.Repository `json:"repository"`
+}
+
+type listResponse struct {
+	Repositories []entity.Repository `json:"repositories"`
+}
+
+const (
+	codeBadRequest = "BAD_REQUEST"
+	codeNotFound   = "NOT_FOUND"
+	codeExists     = "REPOSITORY_EXISTS"
+)
+
+func (h *Handler) add(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		httpserver.RespondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return nil
+	}
+	var req repositoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeRepositoryError(w, http.StatusBadRequest, codeBadRequest, "invalid json")
+		return nil
+	}
+	repo, err := h.service.Create(r.Context(), entity.Repository{
+		RepositoryID:   req.RepositoryID,
+		OwnerTeam:      req.OwnerTeam,
+		SecondaryTeams: req.SecondaryTeams,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidInput):
+			writeRepositoryError(w, http.StatusBadRequest, codeBadRequest, "repository_id and owner_team are required, secondary_teams must not be empty")
+			return nil
+		case errors.Is(err, ErrUnknownTeam):
+			writeRepositoryError(w, http.StatusNotFound, codeNotFound, "team not found")
+			return nil
+		case errors.Is(err, ErrExists):
+			writeRepositoryError(w, http.StatusConflict, codeExists, "repository_id already exists")
+			return nil
+		default:
+			return err
+		}
+	}
+	httpserver.RespondJSON(w, http.StatusCreated, repositoryEnvelope{Repository: repo})
+	return nil
+}
+
+func (h *Handler) get(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodGet {
+		httpserver.RespondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return nil
+	}
+	repo, err := h.service.Get(r.Context(), r.URL.Query().Get("repository_id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidInput):
+			writeRepositoryError(w, http.StatusBadRequest, codeBadRequest, "repository_id is required")
+			return nil
+		case errors.Is(err, ErrNotFound):
+			writeRepositoryError(w, http.StatusNotFound, codeNotFound, "repository not found")
+			return nil
+		default:
+			return err
+		}
+	}
+	httpserver.RespondJSON(w, http.StatusOK, repositoryEnvelope{Repository: repo})
+	return nil
+}
+
+func (h *Handler) list(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodGet {
+		httpserver.RespondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return nil
+	}
+	items, err := h.service.List(r.Context(), r.URL.Query().Get("team_name"))
+	if err != nil {
+		return err
+	}
+	httpserver.RespondJSON(w, http.StatusOK, listResponse{Repositories: items})
+	return nil
+}
+
+func (h *Handler) update(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		httpserver.RespondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return nil
+	}
+	var req repositoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeRepositoryError(w, http.StatusBadRequest, codeBadRequest, "invalid json")
+		return nil
+	}
+	repo, err := h.service.Update(r.Context(), Update{
+		RepositoryID:   req.RepositoryID,
+		OwnerTeam:      req.OwnerTeam,
+		SecondaryTeams: req.SecondaryTeams,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidInput):
+			writeRepositoryError(w, http.StatusBadRequest, codeBadRequest, "repository_id is required, secondary_teams must not be empty")
+			return nil
+		case errors.Is(err, ErrNotFound):
+			writeRepositoryError(w, http.StatusNotFound, codeNotFound, "repository not found")
+			return nil
+		case errors.Is(err, ErrUnknownTeam):
+			writeRepositoryError(w, http.StatusNotFound, codeNotFound, "team not found")
+			return nil
+		default:
+			return err
+		}
+	}
+	httpserver.RespondJSON(w, http.StatusOK, repositoryEnvelope{Repository: repo})
+	return nil
+}
+
+func (h *Handler) delete(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		httpserver.RespondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return nil
+	}
+	var req deleteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeRepositoryError(w, http.StatusBadRequest, codeBadRequest, "invalid json")
+		return nil
+	}
+	if err := h.service.Delete(r.Context(), req.RepositoryID); err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidInput):
+			writeRepositoryError(w, http.StatusBadRequest, codeBadRequest, "repository_id is required")
+			return nil
+		case errors.Is(err, ErrNotFound):
+			writeRepositoryError(w, http.StatusNotFound, codeNotFound, "repository not found")
+			return nil
+		default:
+			return err
+		}
+	}
+	httpserver.RespondJSON(w, http.StatusOK, deleteRequest{RepositoryID: req.RepositoryID})
+	return nil
+}
+
+type errorEnvelope struct {
+	Error struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func writeRepositoryError(w http.ResponseWriter, status int, code, message string) {
+	var e errorEnvelope
+	e.Error.Code = code
+	e.Error.Message = message
+	httpserver.RespondJSON(w, status, e)
+}
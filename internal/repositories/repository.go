@@ -0,0 +1,101 @@
+package repositories
+
+import (
+	"context"
+
+	"avito-internship-task/internal/entity"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Repository struct {
+	db *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) *Repository {
+	return &Repository{db: db}
+}
+
+// MissingTeams returns the names that do not belong to an existing team.
+func (r *Repository) MissingTeams(ctx context.Context, names []string) ([]string, error) {
+	rows, err := r.db.Query(ctx, `
+SELECT n FROM unnest($1::text[]) n
+WHERE NOT EXISTS (SELECT 1 FROM teams t WHERE t.name = n)
+`, names)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
+
+func (r *Repository) Create(ctx context.Context, repo entity.Repository) (entity.Repository, error) {
+	row := r.db.QueryRow(ctx, `
+INSERT INTO repositories (repository_id, owner_team, secondary_teams)
+VALUES ($1, $2, $3)
+RETURNING created_at
+`, repo.RepositoryID, repo.OwnerTeam, repo.SecondaryTeams)
+	if err := row.Scan(&repo.CreatedAt); err != nil {
+		return entity.Repository{}, err
+	}
+	return repo, nil
+}
+
+func (r *Repository) Get(ctx context.Context, id string) (entity.Repository, error) {
+	var repo entity.Repository
+	err := r.db.QueryRow(ctx, `
+SELECT repository_id, owner_team, secondary_teams, created_at
+FROM repositories WHERE repository_id = $1
+`, id).Scan(&repo.RepositoryID, &repo.OwnerTeam, &repo.SecondaryTeams, &repo.CreatedAt)
+	return repo, err
+}
+
+// List returns all repositories, or those a team owns or helps with when team is set.
+func (r *Repository) List(ctx context.Context, team string) ([]entity.Repository, error) {
+	rows, err := r.db.Query(ctx, `
+SELECT repository_id, owner_team, secondary_teams, created_at
+FROM repositories
+WHERE $1 = '' OR owner_team = $1 OR $1 = ANY(secondary_teams)
+ORDER BY repository_id
+`, team)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := make([]entity.Repository, 0)
+	for rows.Next() {
+		var repo entity.Repository
+		if err := rows.Scan(&repo.RepositoryID, &repo.OwnerTeam, &repo.SecondaryTeams, &repo.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, repo)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (r *Repository) Update(ctx context.Context, repo entity.Repository) (entity.Repository, error) {
+	err := r.db.QueryRow(ctx, `
+UPDATE repositories SET owner_team = $2, secondary_teams = $3
+WHERE repository_id = $1
+RETURNING created_at
+`, repo.RepositoryID, repo.OwnerTeam, repo.SecondaryTeams).Scan(&repo.CreatedAt)
+	if err != nil {
+		return entity.Repository{}, err
+	}
+	return repo, nil
+}
+
+// Delete removes a repository, its PRs are kept without a repository.
+func (r *Repository) Delete(ctx context.Context, id string) error {
+	var deleted string
+	return r.db.QueryRow(ctx, `
+DELETE FROM repositories WHERE repository_id = $1
+RETURNING repository_id
+`, id).Scan(&deleted)
+}
+
+func isNotFound(err error) bool {
+	return err != nil && err == pgx.ErrNoRows
+}
@@ -0,0 +1,158 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"avito-internship-task/internal/entity"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var (
+	ErrInvalidInput = errors.New("invalid input")
+	ErrNotFound     = errors.New("not found")
+	ErrExists       = errors.New("repository exists")
+	ErrUnknownTeam  = errors.New("unknown team")
+)
+
+type Repo interface {
+	MissingTeams(ctx context.Context, names []string) ([]string, error)
+	Create(ctx context.Context, repo entity.Repository) (entity.Repository, error)
+	Get(ctx context.Context, id string) (entity.Repository, error)
+	List(ctx context.Context, team string) ([]entity.Repository, error)
+	Update(ctx context.Context, repo entity.Repository) (entity.Repository, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// Update changes the teams of a repository. Empty OwnerTeam and nil SecondaryTeams keep the current value.
+type Update struct {
+	RepositoryID   string
+	OwnerTeam      string
+	SecondaryTeams []string
+}
+
+type Service struct {
+	repo Repo
+}
+
+func NewService(repo Repo) *Service {
+	return &Service{repo: repo}
+}
+
+func (s *Service) Create(ctx context.Context, repo entity.Repository) (entity.Repository, error) {
+	repo.RepositoryID = strings.TrimSpace(repo.RepositoryID)
+	if repo.RepositoryID == "" {
+		return entity.Repository{}, ErrInvalidInput
+	}
+	repo, err := s.validate(ctx, repo)
+	if err != nil {
+		return entity.Repository{}, err
+	}
+	created, err := s.repo.Create(ctx, repo)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return entity.Repository{}, ErrExists
+		}
+		return entity.Repository{}, err
+	}
+	return created, nil
+}
+
+func (s *Service) Get(ctx context.Context, id string) (entity.Repository, error) {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return entity.Repository{}, ErrInvalidInput
+	}
+	repo, err := s.repo.Get(ctx, id)
+	if err != nil {
+		if isNotFound(err) {
+			return entity.Repository{}, ErrNotFound
+		}
+		return entity.Repository{}, err
+	}
+	return repo, nil
+}
+
+func (s *Service) List(ctx context.Context, team string) ([]entity.Repository, error) {
+	return s.repo.List(ctx, strings.TrimSpace(team))
+}
+
+func (s *Service) Update(ctx context.Context, upd Update) (entity.Repository, error) {
+	repo, err := s.Get(ctx, upd.RepositoryID)
+	if err != nil {
+		return entity.Repository{}, err
+	}
+	if owner := strings.TrimSpace(upd.OwnerTeam); owner != "" {
+		repo.OwnerTeam = owner
+	}
+	if upd.SecondaryTeams != nil {
+		repo.SecondaryTeams = upd.SecondaryTeams
+	}
+	repo, err = s.validate(ctx, repo)
+	if err != nil {
+		return entity.Repository{}, err
+	}
+	updated, err := s.repo.Update(ctx, repo)
+	if err != nil {
+		if isNotFound(err) {
+			return entity.Repository{}, ErrNotFound
+		}
+		return entity.Repository{}, err
+	}
+	return updated, nil
+}
+
+func (s *Service) Delete(ctx context.Context, id string) error {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return ErrInvalidInput
+	}
+	if err := s.repo.Delete(ctx, id); err != nil {
+		if isNotFound(err) {
+			return ErrNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// validate normalizes the teams of repo: the owner is required, secondary teams are
+// deduplicated, keep their order and never repeat the owner. All teams must exist.
+func (s *Service) validate(ctx context.Context, repo entity.Repository) (entity.Repository, error) {
+	repo.OwnerTeam = strings.TrimSpace(repo.OwnerTeam)
+	if repo.OwnerTeam == "" {
+		return entity.Repository{}, ErrInvalidInput
+	}
+	secondary := make([]string, 0, len(repo.SecondaryTeams))
+	seen := map[string]struct{}{repo.OwnerTeam: {}}
+	for _, team := range repo.SecondaryTeams {
+		team = strings.TrimSpace(team)
+		if team == "" {
+			return entity.Repository{}, ErrInvalidInput
+		}
+		if _, ok := seen[team]; ok {
+			continue
+		}
+		seen[team] = struct{}{}
+		secondary = append(secondary, team)
+	}
+	repo.SecondaryTeams = secondary
+
+	missing, err := s.repo.MissingTeams(ctx, append([]string{repo.OwnerTeam}, secondary...))
+	if err != nil {
+		return entity.Repository{}, err
+	}
+	if len(missing) > 0 {
+		return entity.Repository{}, ErrUnknownTeam
+	}
+	return repo, nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == "23505"
+	}
+	return false
+}
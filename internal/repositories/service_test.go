@@ -0,0 +1,147 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+
+	"avito-internship-task/internal/entity"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+)
+
+type repositoryRepoStub struct {
+	teams map[string]bool
+	repos map[string]entity.Repository
+}
+
+func (r *repositoryRepoStub) MissingTeams(ctx context.Context, names []string) ([]string, error) {
+	var missing []string
+	for _, n := range names {
+		if !r.teams[n] {
+			missing = append(missing, n)
+		}
+	}
+	return missing, nil
+}
+
+func (r *repositoryRepoStub) Create(ctx context.Context, repo entity.Repository) (entity.Repository, error) {
+	if _, ok := r.repos[repo.RepositoryID]; ok {
+		return entity.Repository{}, &pgconn.PgError{Code: "23505"}
+	}
+	r.repos[repo.RepositoryID] = repo
+	return repo, nil
+}
+
+func (r *repositoryRepoStub) Get(ctx context.Context, id string) (entity.Repository, error) {
+	repo, ok := r.repos[id]
+	if !ok {
+		return entity.Repository{}, pgx.ErrNoRows
+	}
+	return repo, nil
+}
+
+func (r *repositoryRepoStub) List(ctx context.Context, team string) ([]entity.Repository, error) {
+	items := make([]entity.Repository, 0)
+	for _, repo := range r.repos {
+		items = append(items, repo)
+	}
+	return items, nil
+}
+
+func (r *repositoryRepoStub) Update(ctx context.Context, repo entity.Repository) (entity.Repository, error) {
+	if _, ok := r.repos[repo.RepositoryID]; !ok {
+		return entity.Repository{}, pgx.ErrNoRows
+	}
+	r.repos[repo.RepositoryID] = repo
+	return repo, nil
+}
+
+func (r *repositoryRepoStub) Delete(ctx context.Context, id string) error {
+	if _, ok := r.repos[id]; !ok {
+		return pgx.ErrNoRows
+	}
+	delete(r.repos, id)
+	return nil
+}
+
+func TestCreate(t *testing.T) {
+	ctx := context.Background()
+	repo := &repositoryRepoStub{
+		teams: map[string]bool{"platform": true, "sre": true, "web": true},
+		repos: map[string]entity.Repository{},
+	}
+	svc := NewService(repo)
+
+	tests := []struct {
+		name    string
+		input   entity.Repository
+		want    []string
+		wantErr error
+	}{
+		{
+			name:  "ok",
+			input: entity.Repository{RepositoryID: " acme/api ", OwnerTeam: "platform", SecondaryTeams: []string{"sre", " web", "sre", "platform"}},
+			want:  []string{"sre", "web"},
+		},
+		{
+			name:    "duplicate",
+			input:   entity.Repository{RepositoryID: "acme/api", OwnerTeam: "platform"},
+			wantErr: ErrExists,
+		},
+		{
+			name:    "no owner",
+			input:   entity.Repository{RepositoryID: "acme/web"},
+			wantErr: ErrInvalidInput,
+		},
+		{
+			name:    "empty secondary team",
+			input:   entity.Repository{RepositoryID: "acme/web", OwnerTeam: "web", SecondaryTeams: []string{" "}},
+			wantErr: ErrInvalidInput,
+		},
+		{
+			name:    "unknown team",
+			input:   entity.Repository{RepositoryID: "acme/web", OwnerTeam: "web", SecondaryTeams: []string{"mobile"}},
+			wantErr: ErrUnknownTeam,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			created, err := svc.Create(ctx, tt.input)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, "acme/api", created.RepositoryID)
+			require.Equal(t, tt.want, created.SecondaryTeams)
+		})
+	}
+}
+
+func TestUpdateDelete(t *testing.T) {
+	ctx := context.Background()
+	repo := &repositoryRepoStub{
+		teams: map[string]bool{"platform": true, "sre": true},
+		repos: map[string]entity.Repository{
+			"acme/api": {RepositoryID: "acme/api", OwnerTeam: "platform", SecondaryTeams: []string{"sre"}},
+		},
+	}
+	svc := NewService(repo)
+
+	updated, err := svc.Update(ctx, Update{RepositoryID: "acme/api", OwnerTeam: "sre"})
+	require.NoError(t, err)
+	require.Equal(t, "sre", updated.OwnerTeam)
+	require.Empty(t, updated.SecondaryTeams)
+
+	updated, err = svc.Update(ctx, Update{RepositoryID: "acme/api", SecondaryTeams: []string{"platform"}})
+	require.NoError(t, err)
+	require.Equal(t, []string{"platform"}, updated.SecondaryTeams)
+
+	_, err = svc.Update(ctx, Update{RepositoryID: "acme/missing", OwnerTeam: "sre"})
+	require.ErrorIs(t, err, ErrNotFound)
+
+	require.NoError(t, svc.Delete(ctx, "acme/api"))
+	require.ErrorIs(t, svc.Delete(ctx, "acme/api"), ErrNotFound)
+	require.ErrorIs(t, svc.Delete(ctx, " "), ErrInvalidInput)
+}
@@ -22,6 +22,8 @@ func NewHandler(service *Service) *Handler {
 func (h *Handler) Register(mux *http.ServeMux) {
 	mux.Handle("/team/add", httpserver.WithError(h.createTeam))
 	mux.Handle("/team/get", httpserver.WithError(h.getTeam))
+	mux.Handle("/team/settings", httpserver.WithError(h.settings))
+	mux.Handle("/team/deactivate", httpserver.WithError(h.deactivate))
 }
 
 type createTeamRequest struct {
@@ -29,6 +31,35 @@ type createTeamRequest struct {
 	Members  []entity.TeamMember `json:"members"`
 }
 
+// settingsRequest is a partial update: omitted fields keep their value,
+// null clears review_capacity, required_approvals and sla_hours.
+type settingsRequest struct {
+	TeamName          string      `json:"team_name"`
+	ReviewerCount     *int        `json:"reviewer_count"`
+	Strategy          *string     `json:"strategy"`
+	ExcludedUsers     []string    `json:"excluded_users"`
+	ReviewCapacity    NullableInt `json:"review_capacity"`
+	RequiredApprovals NullableInt `json:"required_approvals"`
+	FallbackTeams     []string    `json:"fallback_teams"`
+	SLAHours          NullableInt `json:"sla_hours"`
+	SLAPolicy         *string     `json:"sla_policy"`
+}
+
+type settingsEnvelope struct {
+	Settings entity.TeamSettings `json:"settings"`
+}
+
+type deactivateRequest struct {
+	TeamName string   `json:"team_name"`
+	UserIDs  []string `json:"user_ids"`
+}
+
+type deactivateResponse struct {
+	TeamName      string                `json:"team_name"`
+	Deactivated   []string              `json:"deactivated"`
+	Reassignments []entity.Reassignment `json:"reassignments"`
+}
+
 type teamEnvelope struct {
 	Team TeamResponse `json:"team"`
 }
@@ -110,6 +141,100 @@ func (h *Handler) getTeam(w http.ResponseWriter, r *http.Request) error {
 	return nil
 }
 
+func (h *Handler) settings(w http.ResponseWriter, r *http.Request) error {
+	switch r.Method {
+	case http.MethodGet:
+		return h.getSettings(w, r)
+	case http.MethodPost:
+		return h.updateSettings(w, r)
+	default:
+		httpserver.RespondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return nil
+	}
+}
+
+func (h *Handler) getSettings(w http.ResponseWriter, r *http.Request) error {
+	settings, err := h.service.GetSettings(r.Context(), r.URL.Query().Get("team_name"))
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidInput):
+			writeError(w, http.StatusBadRequest, errorBadRequest, "team_name is required")
+			return nil
+		case errors.Is(err, ErrNotFound):
+			writeError(w, http.StatusNotFound, errorNotFound, "team not found")
+			return nil
+		default:
+			return err
+		}
+	}
+	httpserver.RespondJSON(w, http.StatusOK, settingsEnvelope{Settings: settings})
+	return nil
+}
+
+func (h *Handler) updateSettings(w http.ResponseWriter, r *http.Request) error {
+	var req settingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, errorBadRequest, "invalid json")
+		return nil
+	}
+	settings, err := h.service.UpdateSettings(r.Context(), SettingsUpdate{
+		TeamName:          req.TeamName,
+		ReviewerCount:     req.ReviewerCount,
+		Strategy:          req.Strategy,
+		ExcludedUsers:     req.ExcludedUsers,
+		ReviewCapacity:    req.ReviewCapacity,
+		RequiredApprovals: req.RequiredApprovals,
+		FallbackTeams:     req.FallbackTeams,
+		SLAHours:          req.SLAHours,
+		SLAPolicy:         req.SLAPolicy,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidInput):
+			writeError(w, http.StatusBadRequest, errorBadRequest, "reviewer_count, strategy, excluded_users, review_capacity, required_approvals, fallback_teams, sla_hours or sla_policy are invalid")
+			return nil
+		case errors.Is(err, ErrNotFound):
+			writeError(w, http.StatusNotFound, errorNotFound, "team not found")
+			return nil
+		default:
+			return err
+		}
+	}
+	httpserver.RespondJSON(w, http.StatusOK, settingsEnvelope{Settings: settings})
+	return nil
+}
+
+func (h *Handler) deactivate(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		httpserver.RespondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return nil
+	}
+	var req deactivateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, errorBadRequest, "invalid json")
+		return nil
+	}
+	deactivated, reassignments, err := h.service.Deactivate(r.Context(), req.TeamName, req.UserIDs)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidInput):
+			writeError(w, http.StatusBadRequest, errorBadRequest, "team_name is required")
+			return nil
+		case errors.Is(err, ErrNotFound):
+			writeError(w, http.StatusNotFound, errorNotFound, "team or team member not found")
+			return nil
+		default:
+			return err
+		}
+	}
+	httpserver.RespondJSON(w, http.StatusOK, deactivateResponse{
+		TeamName:      strings.TrimSpace(req.TeamName),
+		Deactivated:   deactivated,
+		Reassignments: reassignments,
+	})
+	return nil
+}
+
 type TeamResponse struct {
 	TeamName string              `json:"team_name"`
 	Members  []entity.TeamMember `json:"members"`
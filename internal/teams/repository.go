@@ -74,6 +74,47 @@ func (r *Repository) Get(ctx context.Context, name string) (entity.Team, error)
 	return entity.Team{TeamName: teamName, Members: members}, nil
 }
 
+func (r *Repository) GetSettings(ctx context.Context, name string) (entity.TeamSettings, error) {
+	row := r.db.QueryRow(ctx, `
+SELECT t.name, COALESCE(s.reviewer_count, $2), COALESCE(s.strategy, ''), COALESCE(s.excluded_users, '{}'),
+       s.review_capacity, s.required_approvals, COALESCE(s.fallback_teams, '{}'), s.sla_hours, COALESCE(s.sla_policy, '')
+FROM teams t
+LEFT JOIN team_settings s ON s.team_name = t.name
+WHERE t.name = $1
+`, name, entity.DefaultReviewerCount)
+	var settings entity.TeamSettings
+	if err := row.Scan(&settings.TeamName, &settings.ReviewerCount, &settings.Strategy, &settings.ExcludedUsers, &settings.ReviewCapacity, &settings.RequiredApprovals, &settings.FallbackTeams,
+		&settings.SLAHours, &settings.SLAPolicy); err != nil {
+		return entity.TeamSettings{}, err
+	}
+	return settings, nil
+}
+
+// UpdateSettings stores the settings given in upd, a team without stored settings
+// gets the defaults for the rest.
+func (r *Repository) UpdateSettings(ctx context.Context, upd SettingsUpdate) (entity.TeamSettings, error) {
+	settings := entity.TeamSettings{TeamName: upd.TeamName}
+	err := r.db.QueryRow(ctx, `
+INSERT INTO team_settings (team_name, reviewer_count, strategy, excluded_users, review_capacity, required_approvals, fallback_teams, sla_hours, sla_policy)
+VALUES ($1, COALESCE($2::int, $13), COALESCE($3::text, ''), COALESCE($4::text[], '{}'), $5::int, $6::int, COALESCE($7::text[], '{}'), $8::int, COALESCE($9::text, ''))
+ON CONFLICT (team_name) DO UPDATE
+SET reviewer_count = COALESCE($2::int, team_settings.reviewer_count),
+    strategy = COALESCE($3::text, team_settings.strategy),
+    excluded_users = COALESCE($4::text[], team_settings.excluded_users),
+    review_capacity = CASE WHEN $10::boolean THEN $5::int ELSE team_settings.review_capacity END,
+    required_approvals = CASE WHEN $11::boolean THEN $6::int ELSE team_settings.required_approvals END,
+    fallback_teams = COALESCE($7::text[], team_settings.fallback_teams),
+    sla_hours = CASE WHEN $12::boolean THEN $8::int ELSE team_settings.sla_hours END,
+    sla_policy = COALESCE($9::text, team_settings.sla_policy),
+    updated_at = NOW()
+RETURNING reviewer_count, strategy, excluded_users, review_capacity, required_approvals, fallback_teams, sla_hours, sla_policy
+`, upd.TeamName, upd.ReviewerCount, upd.Strategy, upd.ExcludedUsers, upd.ReviewCapacity.Value, upd.RequiredApprovals.Value, upd.FallbackTeams, upd.SLAHours.Value, upd.SLAPolicy,
+		upd.ReviewCapacity.Set, upd.RequiredApprovals.Set, upd.SLAHours.Set, entity.DefaultReviewerCount).
+		Scan(&settings.ReviewerCount, &settings.Strategy, &settings.ExcludedUsers, &settings.ReviewCapacity, &settings.RequiredApprovals, &settings.FallbackTeams,
+			&settings.SLAHours, &settings.SLAPolicy)
+	return settings, err
+}
+
 func isNotFound(err error) bool {
 	return err != nil && err == pgx.ErrNoRows
 }
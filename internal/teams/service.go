@@ -2,10 +2,12 @@ package teams
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"strings"
 
 	"avito-internship-task/internal/entity"
+	"avito-internship-task/internal/pullrequests"
 	"github.com/jackc/pgconn"
 )
 
@@ -17,16 +19,54 @@ var (
 )
 
 type Service struct {
-	repo Repo
+	repo        Repo
+	deactivator Deactivator
+}
+
+// Deactivator deactivates team members and moves their open reviews to teammates.
+type Deactivator interface {
+	DeactivateUsers(ctx context.Context, teamName string, userIDs []string) ([]string, []entity.Reassignment, error)
 }
 
 type Repo interface {
 	Create(ctx context.Context, team entity.Team) error
 	Get(ctx context.Context, name string) (entity.Team, error)
+	GetSettings(ctx context.Context, name string) (entity.TeamSettings, error)
+	UpdateSettings(ctx context.Context, upd SettingsUpdate) (entity.TeamSettings, error)
+}
+
+// SettingsUpdate describes a partial settings change, nil fields and unset
+// nullable fields are left untouched. Empty slices clear the lists.
+type SettingsUpdate struct {
+	TeamName          string
+	ReviewerCount     *int
+	Strategy          *string
+	ExcludedUsers     []string
+	ReviewCapacity    NullableInt
+	RequiredApprovals NullableInt
+	FallbackTeams     []string
+	SLAHours          NullableInt
+	SLAPolicy         *string
+}
+
+// NullableInt is an update of a nullable setting: Set tells whether it was given at all,
+// a nil Value with Set clears the setting.
+type NullableInt struct {
+	Set   bool
+	Value *int
+}
+
+func (n *NullableInt) UnmarshalJSON(data []byte) error {
+	n.Set = true
+	n.Value = nil
+	if string(data) == "null" {
+		return nil
+	}
+	return json.Unmarshal(data, &n.Value)
 }
 
-func NewService(repo Repo) *Service {
-	return &Service{repo: repo}
+func NewService(repo Repo, deactivator Deactivator) *Service {
+	return &Service{repo: repo, deactivator: deactivator}
 }
 
 func (s *Service) Create(ctx context.Context, team entity.Team) (entity.Team, error) {
@@ -83,6 +123,124 @@ func (s *Service) Get(ctx context.Context, name string) (entity.Team, error) {
 	return team, nil
 }
 
+func (s *Service) GetSettings(ctx context.Context, name string) (entity.TeamSettings, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return entity.TeamSettings{}, ErrInvalidInput
+	}
+	settings, err := s.repo.GetSettings(ctx, name)
+	if err != nil {
+		if isNotFound(err) {
+			return entity.TeamSettings{}, ErrNotFound
+		}
+		return entity.TeamSettings{}, err
+	}
+	return settings, nil
+}
+
+// UpdateSettings changes the settings given in upd and returns the resulting settings of the team.
+func (s *Service) UpdateSettings(ctx context.Context, upd SettingsUpdate) (entity.TeamSettings, error) {
+	upd.TeamName = strings.TrimSpace(upd.TeamName)
+	if upd.TeamName == "" || isNegative(upd.ReviewerCount) || isNegative(upd.ReviewCapacity.Value) || isNegative(upd.RequiredApprovals.Value) {
+		return entity.TeamSettings{}, ErrInvalidInput
+	}
+	if upd.Strategy != nil {
+		strategy := strings.TrimSpace(*upd.Strategy)
+		if strategy != "" && !pullrequests.IsKnownStrategy(strategy) {
+			return entity.TeamSettings{}, ErrInvalidInput
+		}
+		upd.Strategy = &strategy
+	}
+	if upd.SLAPolicy != nil {
+		policy := strings.TrimSpace(*upd.SLAPolicy)
+		if !entity.IsKnownSLAPolicy(policy) {
+			return entity.TeamSettings{}, ErrInvalidInput
+		}
+		upd.SLAPolicy = &policy
+	}
+	if upd.SLAHours.Value != nil && *upd.SLAHours.Value <= 0 {
+		return entity.TeamSettings{}, ErrInvalidInput
+	}
+	team, err := s.Get(ctx, upd.TeamName)
+	if err != nil {
+		return entity.TeamSettings{}, err
+	}
+	if upd.ExcludedUsers != nil {
+		members := make(map[string]struct{}, len(team.Members))
+		for _, m := range team.Members {
+			members[m.UserID] = struct{}{}
+		}
+		excluded := make([]string, 0, len(upd.ExcludedUsers))
+		seen := make(map[string]struct{})
+		for _, id := range upd.ExcludedUsers {
+			id = strings.TrimSpace(id)
+			if _, ok := members[id]; !ok {
+				return entity.TeamSettings{}, ErrInvalidInput
+			}
+			if _, ok := seen[id]; ok {
+				continue
+			}
+			seen[id] = struct{}{}
+			excluded = append(excluded, id)
+		}
+		upd.ExcludedUsers = excluded
+	}
+	if upd.FallbackTeams != nil {
+		if upd.FallbackTeams, err = s.fallbackTeams(ctx, upd.TeamName, upd.FallbackTeams); err != nil {
+			return entity.TeamSettings{}, err
+		}
+	}
+	return s.repo.UpdateSettings(ctx, upd)
+}
+
+// fallbackTeams validates the ordered fallback list of team: every entry must be
+// an existing team other than team itself, duplicates keep their first position.
+func (s *Service) fallbackTeams(ctx context.Context, team string, names []string) ([]string, error) {
+	fallback := make([]string, 0, len(names))
+	seen := make(map[string]struct{})
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" || name == team {
+			return nil, ErrInvalidInput
+		}
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		if _, err := s.Get(ctx, name); err != nil {
+			if errors.Is(err, ErrNotFound) {
+				return nil, ErrInvalidInput
+			}
+			return nil, err
+		}
+		seen[name] = struct{}{}
+		fallback = append(fallback, name)
+	}
+	return fallback, nil
+}
+
+func (s *Service) Deactivate(ctx context.Context, name string, userIDs []string) ([]string, []entity.Reassignment, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, nil, ErrInvalidInput
+	}
+	deactivated, reassignments, err := s.deactivator.DeactivateUsers(ctx, name, userIDs)
+	if err != nil {
+		switch {
+		case errors.Is(err, pullrequests.ErrInvalidInput):
+			return nil, nil, ErrInvalidInput
+		case errors.Is(err, pullrequests.ErrNotFound):
+			return nil, nil, ErrNotFound
+		default:
+			return nil, nil, err
+		}
+	}
+	return deactivated, reassignments, nil
+}
+
+func isNegative(v *int) bool {
+	return v != nil && *v < 0
+}
+
 func isUniqueViolation(err error) bool {
 	var pgErr *pgconn.PgError
 	if errors.As(err, &pgErr) {
@@ -2,23 +2,28 @@ package teams
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"testing"
 
 	"avito-internship-task/internal/entity"
+	"avito-internship-task/internal/pullrequests"
 	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v5"
 	"github.com/stretchr/testify/require"
 )
 
 type teamRepoStub struct {
 	teams           map[string]entity.Team
 	existingMembers map[string]struct{}
+	settings        map[string]entity.TeamSettings
 }
 
 func newTeamRepoStub() *teamRepoStub {
 	return &teamRepoStub{
 		teams:           make(map[string]entity.Team),
 		existingMembers: make(map[string]struct{}),
+		settings:        make(map[string]entity.TeamSettings),
 	}
 }
 
@@ -46,6 +51,68 @@ func (r *teamRepoStub) Get(ctx context.Context, name string) (entity.Team, error
 	return team, nil
 }
 
+func (r *teamRepoStub) GetSettings(ctx context.Context, name string) (entity.TeamSettings, error) {
+	if _, ok := r.teams[name]; !ok {
+		return entity.TeamSettings{}, pgx.ErrNoRows
+	}
+	if settings, ok := r.settings[name]; ok {
+		return settings, nil
+	}
+	return entity.TeamSettings{TeamName: name, ReviewerCount: entity.DefaultReviewerCount, ExcludedUsers: []string{}}, nil
+}
+
+func (r *teamRepoStub) UpdateSettings(ctx context.Context, upd SettingsUpdate) (entity.TeamSettings, error) {
+	settings, err := r.GetSettings(ctx, upd.TeamName)
+	if err != nil {
+		return entity.TeamSettings{}, err
+	}
+	if upd.ReviewerCount != nil {
+		settings.ReviewerCount = *upd.ReviewerCount
+	}
+	if upd.Strategy != nil {
+		settings.Strategy = *upd.Strategy
+	}
+	if upd.ExcludedUsers != nil {
+		settings.ExcludedUsers = upd.ExcludedUsers
+	}
+	if upd.ReviewCapacity.Set {
+		settings.ReviewCapacity = upd.ReviewCapacity.Value
+	}
+	if upd.RequiredApprovals.Set {
+		settings.RequiredApprovals = upd.RequiredApprovals.Value
+	}
+	if upd.FallbackTeams != nil {
+		settings.FallbackTeams = upd.FallbackTeams
+	}
+	if upd.SLAHours.Set {
+		settings.SLAHours = upd.SLAHours.Value
+	}
+	if upd.SLAPolicy != nil {
+		settings.SLAPolicy = *upd.SLAPolicy
+	}
+	r.settings[upd.TeamName] = settings
+	return settings, nil
+}
+
+type deactivatorStub struct {
+	teams map[string][]string
+}
+
+func (d *deactivatorStub) DeactivateUsers(ctx context.Context, teamName string, userIDs []string) ([]string, []entity.Reassignment, error) {
+	members, ok := d.teams[teamName]
+	if !ok {
+		return nil, nil, pullrequests.ErrNotFound
+	}
+	if len(userIDs) == 0 {
+		userIDs = members
+	}
+	reassignments := make([]entity.Reassignment, 0, len(userIDs))
+	for _, id := range userIDs {
+		reassignments = append(reassignments, entity.Reassignment{PullRequestID: "pr-" + id, OldReviewerID: id})
+	}
+	return userIDs, reassignments, nil
+}
+
 func TestServiceCreate(t *testing.T) {
 	ctx := context.Background()
 	tests := []struct {
@@ -101,7 +168,7 @@ func TestServiceCreate(t *testing.T) {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			svc := NewService(tt.repo)
+			svc := NewService(tt.repo, nil)
 			team, err := svc.Create(ctx, tt.input)
 			if tt.wantErr != nil {
 				require.ErrorIs(t, err, tt.wantErr)
@@ -148,7 +215,7 @@ func TestServiceGet(t *testing.T) {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			svc := NewService(tt.repo)
+			svc := NewService(tt.repo, nil)
 			team, err := svc.Get(ctx, tt.input)
 			if tt.wantErr != nil {
 				require.True(t, errors.Is(err, tt.wantErr))
@@ -159,3 +226,118 @@ func TestServiceGet(t *testing.T) {
 		})
 	}
 }
+
+func TestServiceSettings(t *testing.T) {
+	ctx := context.Background()
+	repo := newTeamRepoStub()
+	repo.teams["backend"] = entity.Team{
+		TeamName: "backend",
+		Members:  []entity.TeamMember{{UserID: "u1"}, {UserID: "u2"}},
+	}
+	repo.teams["frontend"] = entity.Team{TeamName: "frontend"}
+	svc := NewService(repo, nil)
+	negative := -1
+	zero := 0
+	one := 1
+	four := 4
+	strategy := "least_loaded"
+	policy := " reassign "
+	unknown := "round_robin"
+	page := "page"
+
+	settings, err := svc.GetSettings(ctx, "backend")
+	require.NoError(t, err)
+	require.Equal(t, entity.DefaultReviewerCount, settings.ReviewerCount)
+
+	tests := []struct {
+		name    string
+		input   SettingsUpdate
+		wantErr error
+	}{
+		{
+			name: "ok",
+			input: SettingsUpdate{TeamName: "backend", ReviewerCount: &one, Strategy: &strategy, ExcludedUsers: []string{"u2", "u2"},
+				ReviewCapacity: NullableInt{Set: true, Value: &four}, FallbackTeams: []string{"frontend", " frontend"}, SLAPolicy: &policy},
+		},
+		{name: "invalid count", input: SettingsUpdate{TeamName: "backend", ReviewerCount: &negative}, wantErr: ErrInvalidInput},
+		{name: "invalid approvals", input: SettingsUpdate{TeamName: "backend", RequiredApprovals: NullableInt{Set: true, Value: &negative}}, wantErr: ErrInvalidInput},
+		{name: "unknown strategy", input: SettingsUpdate{TeamName: "backend", Strategy: &unknown}, wantErr: ErrInvalidInput},
+		{name: "foreign user", input: SettingsUpdate{TeamName: "backend", ExcludedUsers: []string{"x"}}, wantErr: ErrInvalidInput},
+		{name: "self fallback", input: SettingsUpdate{TeamName: "backend", FallbackTeams: []string{"backend"}}, wantErr: ErrInvalidInput},
+		{name: "unknown fallback", input: SettingsUpdate{TeamName: "backend", FallbackTeams: []string{"mobile"}}, wantErr: ErrInvalidInput},
+		{name: "invalid sla", input: SettingsUpdate{TeamName: "backend", SLAHours: NullableInt{Set: true, Value: &zero}}, wantErr: ErrInvalidInput},
+		{name: "unknown sla policy", input: SettingsUpdate{TeamName: "backend", SLAPolicy: &page}, wantErr: ErrInvalidInput},
+		{name: "not found", input: SettingsUpdate{TeamName: "missing"}, wantErr: ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := svc.UpdateSettings(ctx, tt.input)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, []string{"u2"}, got.ExcludedUsers)
+			require.Equal(t, []string{"frontend"}, got.FallbackTeams)
+			require.Equal(t, entity.SLAReassign, got.SLAPolicy)
+			stored, err := svc.GetSettings(ctx, "backend")
+			require.NoError(t, err)
+			require.Equal(t, got, stored)
+		})
+	}
+
+	// fields left out keep their values, null clears a nullable one
+	got, err := svc.UpdateSettings(ctx, SettingsUpdate{TeamName: "backend", SLAHours: NullableInt{Set: true, Value: &four}})
+	require.NoError(t, err)
+	require.Equal(t, 1, got.ReviewerCount)
+	require.Equal(t, strategy, got.Strategy)
+	require.Equal(t, &four, got.ReviewCapacity)
+	require.Equal(t, []string{"frontend"}, got.FallbackTeams)
+	got, err = svc.UpdateSettings(ctx, SettingsUpdate{TeamName: "backend", ReviewCapacity: NullableInt{Set: true}})
+	require.NoError(t, err)
+	require.Nil(t, got.ReviewCapacity)
+	require.Equal(t, &four, got.SLAHours)
+}
+
+func TestSettingsRequestNulls(t *testing.T) {
+	var req settingsRequest
+	require.NoError(t, json.Unmarshal([]byte(`{"team_name":"backend","review_capacity":null,"sla_hours":8}`), &req))
+	require.Equal(t, NullableInt{Set: true}, req.ReviewCapacity)
+	require.Equal(t, 8, *req.SLAHours.Value)
+	require.False(t, req.RequiredApprovals.Set)
+	require.Nil(t, req.ReviewerCount)
+}
+
+func TestServiceDeactivate(t *testing.T) {
+	ctx := context.Background()
+	svc := NewService(newTeamRepoStub(), &deactivatorStub{teams: map[string][]string{"backend": {"u1", "u2"}}})
+
+	tests := []struct {
+		name    string
+		team    string
+		ids     []string
+		wantLen int
+		wantErr error
+	}{
+		{name: "whole team", team: "backend", wantLen: 2},
+		{name: "subset", team: " backend ", ids: []string{"u1"}, wantLen: 1},
+		{name: "invalid", team: "", wantErr: ErrInvalidInput},
+		{name: "not found", team: "missing", wantErr: ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			deactivated, reassignments, err := svc.Deactivate(ctx, tt.team, tt.ids)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, deactivated, tt.wantLen)
+			require.Len(t, reassignments, tt.wantLen)
+		})
+	}
+}
@@ -3,7 +3,9 @@ package users
 import (
 	"encoding/json"
 	"errors"
+	"io"
 	"net/http"
+	"strconv"
 
 	"avito-internship-task/internal/entity"
 	"avito-internship-task/internal/httpserver"
@@ -20,25 +22,54 @@ func NewHandler(service *Service) *Handler {
 func (h *Handler) Register(mux *http.ServeMux) {
 	mux.Handle("/users/setIsActive", httpserver.WithError(h.setIsActive))
 	mux.Handle("/users/getReview", httpserver.WithError(h.getReview))
+	mux.Handle("/users/setCapacity", httpserver.WithError(h.setCapacity))
+	mux.Handle("/users/notifications", httpserver.WithError(h.getNotifications))
+	mux.Handle("/users/setNotifications", httpserver.WithError(h.setNotifications))
+	mux.Handle("/users/digest", httpserver.WithError(h.digest))
 }
 
 type setActiveRequest struct {
-	UserID   string `json:"user_id"`
-	IsActive bool   `json:"is_active"`
+	UserID          string `json:"user_id"`
+	IsActive        bool   `json:"is_active"`
+	ReassignReviews *bool  `json:"reassign_reviews"`
 }
 
 type userEnvelope struct {
-	User entity.User `json:"user"`
+	User          entity.User           `json:"user"`
+	Reassignments []entity.Reassignment `json:"reassignments,omitempty"`
+}
+
+type setCapacityRequest struct {
+	UserID         string `json:"user_id"`
+	ReviewCapacity *int   `json:"review_capacity"`
+}
+
+type notificationsRequest struct {
+	UserID     string   `json:"user_id"`
+	Email      string   `json:"email"`
+	ChatHandle string   `json:"chat_handle"`
+	Channels   []string `json:"channels"`
+	Events     []string `json:"events"`
+}
+
+type notificationsEnvelope struct {
+	Preferences entity.NotificationPreferences `json:"preferences"`
+}
+
+type digestEnvelope struct {
+	Digest entity.Digest `json:"digest"`
 }
 
 type reviewResponse struct {
 	UserID       string                    `json:"user_id"`
 	PullRequests []entity.PullRequestShort `json:"pull_requests"`
+	entity.ReviewLoad
 }
 
 const (
-	codeBadRequest = "BAD_REQUEST"
-	codeNotFound   = "NOT_FOUND"
+	codeBadRequest    = "BAD_REQUEST"
+	codeNotFound      = "NOT_FOUND"
+	codeNotConfigured = "NOT_CONFIGURED"
 )
 
 func (h *Handler) setIsActive(w http.ResponseWriter, r *http.Request) error {
@@ -51,7 +82,7 @@ func (h *Handler) setIsActive(w http.ResponseWriter, r *http.Request) error {
 		writeUserError(w, http.StatusBadRequest, codeBadRequest, "invalid json")
 		return nil
 	}
-	user, err := h.service.SetIsActive(r.Context(), req.UserID, req.IsActive)
+	user, reassignments, err := h.service.SetIsActive(r.Context(), req.UserID, req.IsActive, req.ReassignReviews)
 	if err != nil {
 		switch {
 		case errors.Is(err, ErrInvalidInput):
@@ -64,7 +95,7 @@ func (h *Handler) setIsActive(w http.ResponseWriter, r *http.Request) error {
 			return err
 		}
 	}
-	httpserver.RespondJSON(w, http.StatusOK, userEnvelope{User: user})
+	httpserver.RespondJSON(w, http.StatusOK, userEnvelope{User: user, Reassignments: reassignments})
 	return nil
 }
 
@@ -74,7 +105,16 @@ func (h *Handler) getReview(w http.ResponseWriter, r *http.Request) error {
 		return nil
 	}
 	userID := r.URL.Query().Get("user_id")
-	prs, err := h.service.GetReview(r.Context(), userID)
+	pendingOnly := false
+	if raw := r.URL.Query().Get("pending_only"); raw != "" {
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			writeUserError(w, http.StatusBadRequest, codeBadRequest, "pending_only must be a boolean")
+			return nil
+		}
+		pendingOnly = parsed
+	}
+	prs, err := h.service.GetReview(r.Context(), userID, pendingOnly)
 	if err != nil {
 		switch {
 		case errors.Is(err, ErrInvalidInput):
@@ -87,14 +127,137 @@ func (h *Handler) getReview(w http.ResponseWriter, r *http.Request) error {
 			return err
 		}
 	}
+	load, err := h.service.GetLoad(r.Context(), userID)
+	if err != nil {
+		return err
+	}
 	resp := reviewResponse{
 		UserID:       userID,
 		PullRequests: prs,
+		ReviewLoad:   load,
 	}
 	httpserver.RespondJSON(w, http.StatusOK, resp)
 	return nil
 }
 
+func (h *Handler) setCapacity(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		httpserver.RespondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return nil
+	}
+	var req setCapacityRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeUserError(w, http.StatusBadRequest, codeBadRequest, "invalid json")
+		return nil
+	}
+	user, err := h.service.SetCapacity(r.Context(), req.UserID, req.ReviewCapacity)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidInput):
+			writeUserError(w, http.StatusBadRequest, codeBadRequest, "user_id is required and review_capacity must not be negative")
+			return nil
+		case errors.Is(err, ErrNotFound):
+			writeUserError(w, http.StatusNotFound, codeNotFound, "user not found")
+			return nil
+		default:
+			return err
+		}
+	}
+	httpserver.RespondJSON(w, http.StatusOK, userEnvelope{User: user})
+	return nil
+}
+
+func (h *Handler) getNotifications(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodGet {
+		httpserver.RespondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return nil
+	}
+	prefs, err := h.service.GetNotificationPreferences(r.Context(), r.URL.Query().Get("user_id"))
+	if err != nil {
+		return writeNotificationsError(w, err)
+	}
+	httpserver.RespondJSON(w, http.StatusOK, notificationsEnvelope{Preferences: prefs})
+	return nil
+}
+
+func (h *Handler) setNotifications(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		httpserver.RespondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return nil
+	}
+	var req notificationsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeUserError(w, http.StatusBadRequest, codeBadRequest, "invalid json")
+		return nil
+	}
+	prefs, err := h.service.SetNotificationPreferences(r.Context(), entity.NotificationPreferences{
+		UserID:     req.UserID,
+		Email:      req.Email,
+		ChatHandle: req.ChatHandle,
+		Channels:   req.Channels,
+		Events:     req.Events,
+	})
+	if err != nil {
+		return writeNotificationsError(w, err)
+	}
+	httpserver.RespondJSON(w, http.StatusOK, notificationsEnvelope{Preferences: prefs})
+	return nil
+}
+
+// digest returns the digest as JSON, or only its rendered form with format=text or format=html.
+func (h *Handler) digest(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodGet {
+		httpserver.RespondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return nil
+	}
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "text" && format != "html" {
+		writeUserError(w, http.StatusBadRequest, codeBadRequest, "format must be json, text or html")
+		return nil
+	}
+	digest, err := h.service.Digest(r.Context(), r.URL.Query().Get("user_id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidInput):
+			writeUserError(w, http.StatusBadRequest, codeBadRequest, "user_id is required")
+			return nil
+		case errors.Is(err, ErrNotFound):
+			writeUserError(w, http.StatusNotFound, codeNotFound, "user not found")
+			return nil
+		case errors.Is(err, ErrNotConfigured):
+			writeUserError(w, http.StatusServiceUnavailable, codeNotConfigured, "digests are not configured")
+			return nil
+		default:
+			return err
+		}
+	}
+	switch format {
+	case "text":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, err = io.WriteString(w, digest.Text)
+		return err
+	case "html":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, err = io.WriteString(w, digest.HTML)
+		return err
+	}
+	httpserver.RespondJSON(w, http.StatusOK, digestEnvelope{Digest: digest})
+	return nil
+}
+
+func writeNotificationsError(w http.ResponseWriter, err error) error {
+	switch {
+	case errors.Is(err, ErrInvalidInput):
+		writeUserError(w, http.StatusBadRequest, codeBadRequest, "user_id is required, channels and events must be known and the email channel needs a valid email")
+		return nil
+	case errors.Is(err, ErrNotFound):
+		writeUserError(w, http.StatusNotFound, codeNotFound, "user not found")
+		return nil
+	default:
+		return err
+	}
+}
+
 type errorEnvelope struct {
 	Error struct {
 		Code    string `json:"code"`
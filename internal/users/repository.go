@@ -19,31 +19,64 @@ func NewRepository(db *pgxpool.Pool) *Repository {
 func (r *Repository) SetIsActive(ctx context.Context, userID string, active bool) (entity.User, error) {
 	row := r.db.QueryRow(ctx, `
 UPDATE users SET is_active = $2 WHERE user_id = $1
-RETURNING user_id, username, team_name, is_active
+RETURNING user_id, username, team_name, is_active, review_capacity
 `, userID, active)
 	var u entity.User
-	if err := row.Scan(&u.UserID, &u.Username, &u.TeamName, &u.IsActive); err != nil {
+	if err := row.Scan(&u.UserID, &u.Username, &u.TeamName, &u.IsActive, &u.ReviewCapacity); err != nil {
 		return entity.User{}, err
 	}
 	return u, nil
 }
 
 func (r *Repository) Get(ctx context.Context, userID string) (entity.User, error) {
-	row := r.db.QueryRow(ctx, `SELECT user_id, username, team_name, is_active FROM users WHERE user_id = $1`, userID)
+	row := r.db.QueryRow(ctx, `SELECT user_id, username, team_name, is_active, review_capacity FROM users WHERE user_id = $1`, userID)
 	var u entity.User
-	if err := row.Scan(&u.UserID, &u.Username, &u.TeamName, &u.IsActive); err != nil {
+	if err := row.Scan(&u.UserID, &u.Username, &u.TeamName, &u.IsActive, &u.ReviewCapacity); err != nil {
 		return entity.User{}, err
 	}
 	return u, nil
 }
 
-func (r *Repository) GetReview(ctx context.Context, userID string) ([]entity.PullRequestShort, error) {
+func (r *Repository) SetCapacity(ctx context.Context, userID string, capacity *int) (entity.User, error) {
+	row := r.db.QueryRow(ctx, `
+UPDATE users SET review_capacity = $2 WHERE user_id = $1
+RETURNING user_id, username, team_name, is_active, review_capacity
+`, userID, capacity)
+	var u entity.User
+	if err := row.Scan(&u.UserID, &u.Username, &u.TeamName, &u.IsActive, &u.ReviewCapacity); err != nil {
+		return entity.User{}, err
+	}
+	return u, nil
+}
+
+func (r *Repository) GetLoad(ctx context.Context, userID string) (entity.ReviewLoad, error) {
+	row := r.db.QueryRow(ctx, `
+SELECT
+    (SELECT COUNT(*)
+     FROM pr_reviewers r
+     JOIN pull_requests pr ON pr.pull_request_id = r.pull_request_id
+     WHERE r.reviewer_id = u.user_id AND pr.status = 'OPEN'),
+    COALESCE(u.review_capacity, s.review_capacity)
+FROM users u
+LEFT JOIN team_settings s ON s.team_name = u.team_name
+WHERE u.user_id = $1
+`, userID)
+	var load entity.ReviewLoad
+	if err := row.Scan(&load.OpenReviews, &load.ReviewCapacity); err != nil {
+		return entity.ReviewLoad{}, err
+	}
+	return load, nil
+}
+
+// GetReview lists PRs assigned to the user, pendingOnly keeps OPEN PRs the user has not reviewed yet.
+func (r *Repository) GetReview(ctx context.Context, userID string, pendingOnly bool) ([]entity.PullRequestShort, error) {
 	rows, err := r.db.Query(ctx, `
 SELECT pr.pull_request_id, pr.pull_request_name, pr.author_id, pr.status
 FROM pr_reviewers r
 JOIN pull_requests pr ON pr.pull_request_id = r.pull_request_id
 WHERE r.reviewer_id = $1
-`, userID)
+  AND (NOT $2 OR (r.review_state = 'PENDING' AND pr.status = 'OPEN'))
+`, userID, pendingOnly)
 	if err != nil {
 		return nil, err
 	}
@@ -63,6 +96,46 @@ WHERE r.reviewer_id = $1
 	return items, nil
 }
 
+// GetNotificationPreferences returns the stored preferences of a user, or the defaults when
+// nothing is stored. It returns pgx.ErrNoRows for unknown users.
+func (r *Repository) GetNotificationPreferences(ctx context.Context, userID string) (entity.NotificationPreferences, error) {
+	row := r.db.QueryRow(ctx, `
+SELECT u.user_id, p.user_id IS NOT NULL, COALESCE(p.email, ''), COALESCE(p.chat_handle, ''),
+       COALESCE(p.channels, '{}'), COALESCE(p.events, '{}')
+FROM users u
+LEFT JOIN notification_preferences p ON p.user_id = u.user_id
+WHERE u.user_id = $1
+`, userID)
+	var (
+		prefs  entity.NotificationPreferences
+		stored bool
+	)
+	if err := row.Scan(&prefs.UserID, &stored, &prefs.Email, &prefs.ChatHandle, &prefs.Channels, &prefs.Events); err != nil {
+		return entity.NotificationPreferences{}, err
+	}
+	if !stored {
+		prefs.Channels = append([]string{}, entity.DefaultNotificationChannels...)
+	}
+	return prefs, nil
+}
+
+func (r *Repository) SetNotificationPreferences(ctx context.Context, prefs entity.NotificationPreferences) (entity.NotificationPreferences, error) {
+	_, err := r.db.Exec(ctx, `
+INSERT INTO notification_preferences (user_id, email, chat_handle, channels, events)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (user_id) DO UPDATE SET
+    email = EXCLUDED.email,
+    chat_handle = EXCLUDED.chat_handle,
+    channels = EXCLUDED.channels,
+    events = EXCLUDED.events,
+    updated_at = NOW()
+`, prefs.UserID, prefs.Email, prefs.ChatHandle, prefs.Channels, prefs.Events)
+	if err != nil {
+		return entity.NotificationPreferences{}, err
+	}
+	return prefs, nil
+}
+
 func isNotFound(err error) bool {
 	return err != nil && err == pgx.ErrNoRows
 }
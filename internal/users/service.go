@@ -3,36 +3,118 @@ package users
 import (
 	"context"
 	"errors"
+	"net/mail"
+	"slices"
 	"strings"
 
 	"avito-internship-task/internal/entity"
+	"avito-internship-task/internal/pullrequests"
 )
 
 var (
-	ErrInvalidInput = errors.New("invalid input")
-	ErrNotFound     = errors.New("not found")
+	ErrInvalidInput  = errors.New("invalid input")
+	ErrNotFound      = errors.New("not found")
+	ErrNotConfigured = errors.New("not configured")
 )
 
 type Service struct {
-	repo Repo
+	repo        Repo
+	deactivator Deactivator
+	opts        Options
+}
+
+type Options struct {
+	// ReassignOnDeactivate is used when a deactivation request does not say whether to reassign reviews.
+	ReassignOnDeactivate bool
+	// Digests builds review digests, without it they are not available.
+	Digests DigestBuilder
+}
+
+// DigestBuilder builds the review digest of a user as of now.
+type DigestBuilder interface {
+	Build(ctx context.Context, userID string) (entity.Digest, error)
+}
+
+// Deactivator deactivates team members and moves their open reviews to teammates.
+type Deactivator interface {
+	DeactivateUsers(ctx context.Context, teamName string, userIDs []string) ([]string, []entity.Reassignment, error)
 }
 
 type Repo interface {
 	SetIsActive(ctx context.Context, userID string, active bool) (entity.User, error)
 	Get(ctx context.Context, userID string) (entity.User, error)
-	GetReview(ctx context.Context, userID string) ([]entity.PullRequestShort, error)
+	GetReview(ctx context.Context, userID string, pendingOnly bool) ([]entity.PullRequestShort, error)
+	SetCapacity(ctx context.Context, userID string, capacity *int) (entity.User, error)
+	GetLoad(ctx context.Context, userID string) (entity.ReviewLoad, error)
+	GetNotificationPreferences(ctx context.Context, userID string) (entity.NotificationPreferences, error)
+	SetNotificationPreferences(ctx context.Context, prefs entity.NotificationPreferences) (entity.NotificationPreferences, error)
 }
 
-func NewService(repo Repo) *Service {
-	return &Service{repo: repo}
+func NewService(repo Repo, deactivator Deactivator, opts Options) *Service {
+	return &Service{repo: repo, deactivator: deactivator, opts: opts}
 }
 
-func (s *Service) SetIsActive(ctx context.Context, userID string, active bool) (entity.User, error) {
+// SetIsActive updates the activity flag. When a user is deactivated and reassign
+// (or the configured default if it is nil) is true, their OPEN reviews are reassigned.
+func (s *Service) SetIsActive(ctx context.Context, userID string, active bool, reassign *bool) (entity.User, []entity.Reassignment, error) {
 	userID = strings.TrimSpace(userID)
 	if userID == "" {
+		return entity.User{}, nil, ErrInvalidInput
+	}
+	shouldReassign := s.opts.ReassignOnDeactivate
+	if reassign != nil {
+		shouldReassign = *reassign
+	}
+	if active || !shouldReassign {
+		user, err := s.repo.SetIsActive(ctx, userID, active)
+		if err != nil {
+			if isNotFound(err) {
+				return entity.User{}, nil, ErrNotFound
+			}
+			return entity.User{}, nil, err
+		}
+		return user, nil, nil
+	}
+
+	user, err := s.repo.Get(ctx, userID)
+	if err != nil {
+		if isNotFound(err) {
+			return entity.User{}, nil, ErrNotFound
+		}
+		return entity.User{}, nil, err
+	}
+	_, reassignments, err := s.deactivator.DeactivateUsers(ctx, user.TeamName, []string{userID})
+	if err != nil {
+		if errors.Is(err, pullrequests.ErrNotFound) {
+			return entity.User{}, nil, ErrNotFound
+		}
+		return entity.User{}, nil, err
+	}
+	user.IsActive = false
+	return user, reassignments, nil
+}
+
+func (s *Service) GetReview(ctx context.Context, userID string, pendingOnly bool) ([]entity.PullRequestShort, error) {
+	userID = strings.TrimSpace(userID)
+	if userID == "" {
+		return nil, ErrInvalidInput
+	}
+	if _, err := s.repo.Get(ctx, userID); err != nil {
+		if isNotFound(err) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return s.repo.GetReview(ctx, userID, pendingOnly)
+}
+
+// SetCapacity sets the limit of concurrent OPEN reviews, nil resets it to the team default.
+func (s *Service) SetCapacity(ctx context.Context, userID string, capacity *int) (entity.User, error) {
+	userID = strings.TrimSpace(userID)
+	if userID == "" || (capacity != nil && *capacity < 0) {
 		return entity.User{}, ErrInvalidInput
 	}
-	user, err := s.repo.SetIsActive(ctx, userID, active)
+	user, err := s.repo.SetCapacity(ctx, userID, capacity)
 	if err != nil {
 		if isNotFound(err) {
 			return entity.User{}, ErrNotFound
@@ -42,16 +124,98 @@ func (s *Service) SetIsActive(ctx context.Context, userID string, active bool) (
 	return user, nil
 }
 
-func (s *Service) GetReview(ctx context.Context, userID string) ([]entity.PullRequestShort, error) {
+func (s *Service) GetLoad(ctx context.Context, userID string) (entity.ReviewLoad, error) {
 	userID = strings.TrimSpace(userID)
 	if userID == "" {
-		return nil, ErrInvalidInput
+		return entity.ReviewLoad{}, ErrInvalidInput
+	}
+	load, err := s.repo.GetLoad(ctx, userID)
+	if err != nil {
+		if isNotFound(err) {
+			return entity.ReviewLoad{}, ErrNotFound
+		}
+		return entity.ReviewLoad{}, err
+	}
+	return load, nil
+}
+
+// Digest returns the review digest a user would get right now.
+func (s *Service) Digest(ctx context.Context, userID string) (entity.Digest, error) {
+	userID = strings.TrimSpace(userID)
+	if userID == "" {
+		return entity.Digest{}, ErrInvalidInput
+	}
+	if s.opts.Digests == nil {
+		return entity.Digest{}, ErrNotConfigured
 	}
 	if _, err := s.repo.Get(ctx, userID); err != nil {
 		if isNotFound(err) {
-			return nil, ErrNotFound
+			return entity.Digest{}, ErrNotFound
+		}
+		return entity.Digest{}, err
+	}
+	return s.opts.Digests.Build(ctx, userID)
+}
+
+func (s *Service) GetNotificationPreferences(ctx context.Context, userID string) (entity.NotificationPreferences, error) {
+	userID = strings.TrimSpace(userID)
+	if userID == "" {
+		return entity.NotificationPreferences{}, ErrInvalidInput
+	}
+	prefs, err := s.repo.GetNotificationPreferences(ctx, userID)
+	if err != nil {
+		if isNotFound(err) {
+			return entity.NotificationPreferences{}, ErrNotFound
+		}
+		return entity.NotificationPreferences{}, err
+	}
+	return prefs, nil
+}
+
+// SetNotificationPreferences replaces the preferences of a user. Channels and events must be
+// known, the email channel needs a valid address; no channels turns notifications off.
+func (s *Service) SetNotificationPreferences(ctx context.Context, prefs entity.NotificationPreferences) (entity.NotificationPreferences, error) {
+	prefs.UserID = strings.TrimSpace(prefs.UserID)
+	prefs.Email = strings.TrimSpace(prefs.Email)
+	prefs.ChatHandle = strings.TrimPrefix(strings.TrimSpace(prefs.ChatHandle), "@")
+	if prefs.UserID == "" {
+		return entity.NotificationPreferences{}, ErrInvalidInput
+	}
+	var ok bool
+	if prefs.Channels, ok = knownValues(prefs.Channels, entity.NotificationChannels); !ok {
+		return entity.NotificationPreferences{}, ErrInvalidInput
+	}
+	if prefs.Events, ok = knownValues(prefs.Events, entity.NotificationEvents); !ok {
+		return entity.NotificationPreferences{}, ErrInvalidInput
+	}
+	if prefs.Email != "" {
+		addr, err := mail.ParseAddress(prefs.Email)
+		if err != nil || addr.Address != prefs.Email {
+			return entity.NotificationPreferences{}, ErrInvalidInput
+		}
+	} else if slices.Contains(prefs.Channels, entity.ChannelEmail) {
+		return entity.NotificationPreferences{}, ErrInvalidInput
+	}
+	if _, err := s.repo.Get(ctx, prefs.UserID); err != nil {
+		if isNotFound(err) {
+			return entity.NotificationPreferences{}, ErrNotFound
+		}
+		return entity.NotificationPreferences{}, err
+	}
+	return s.repo.SetNotificationPreferences(ctx, prefs)
+}
+
+// knownValues trims and dedupes values, ok is false when one of them is not in known.
+func knownValues(values, known []string) ([]string, bool) {
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if !slices.Contains(known, v) {
+			return nil, false
+		}
+		if !slices.Contains(result, v) {
+			result = append(result, v)
 		}
-		return nil, err
 	}
-	return s.repo.GetReview(ctx, userID)
+	return result, true
 }
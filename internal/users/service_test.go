@@ -13,12 +13,14 @@ import (
 type userRepoStub struct {
 	users  map[string]entity.User
 	review map[string][]entity.PullRequestShort
+	prefs  map[string]entity.NotificationPreferences
 }
 
 func newUserRepoStub() *userRepoStub {
 	return &userRepoStub{
 		users:  make(map[string]entity.User),
 		review: make(map[string][]entity.PullRequestShort),
+		prefs:  make(map[string]entity.NotificationPreferences),
 	}
 }
 
@@ -40,15 +42,76 @@ func (r *userRepoStub) Get(ctx context.Context, userID string) (entity.User, err
 	return u, nil
 }
 
-func (r *userRepoStub) GetReview(ctx context.Context, userID string) ([]entity.PullRequestShort, error) {
-	return r.review[userID], nil
+func (r *userRepoStub) SetCapacity(ctx context.Context, userID string, capacity *int) (entity.User, error) {
+	u, ok := r.users[userID]
+	if !ok {
+		return entity.User{}, pgx.ErrNoRows
+	}
+	u.ReviewCapacity = capacity
+	r.users[userID] = u
+	return u, nil
+}
+
+func (r *userRepoStub) GetLoad(ctx context.Context, userID string) (entity.ReviewLoad, error) {
+	u, ok := r.users[userID]
+	if !ok {
+		return entity.ReviewLoad{}, pgx.ErrNoRows
+	}
+	return entity.ReviewLoad{OpenReviews: len(r.review[userID]), ReviewCapacity: u.ReviewCapacity}, nil
+}
+
+func (r *userRepoStub) GetReview(ctx context.Context, userID string, pendingOnly bool) ([]entity.PullRequestShort, error) {
+	if !pendingOnly {
+		return r.review[userID], nil
+	}
+	items := make([]entity.PullRequestShort, 0)
+	for _, pr := range r.review[userID] {
+		if pr.Status == "OPEN" {
+			items = append(items, pr)
+		}
+	}
+	return items, nil
+}
+
+func (r *userRepoStub) GetNotificationPreferences(ctx context.Context, userID string) (entity.NotificationPreferences, error) {
+	if _, ok := r.users[userID]; !ok {
+		return entity.NotificationPreferences{}, pgx.ErrNoRows
+	}
+	if prefs, ok := r.prefs[userID]; ok {
+		return prefs, nil
+	}
+	return entity.NotificationPreferences{UserID: userID, Channels: entity.DefaultNotificationChannels, Events: []string{}}, nil
+}
+
+func (r *userRepoStub) SetNotificationPreferences(ctx context.Context, prefs entity.NotificationPreferences) (entity.NotificationPreferences, error) {
+	r.prefs[prefs.UserID] = prefs
+	return prefs, nil
+}
+
+type deactivatorStub struct {
+	repo  *userRepoStub
+	calls int
+}
+
+func (d *deactivatorStub) DeactivateUsers(ctx context.Context, teamName string, userIDs []string) ([]string, []entity.Reassignment, error) {
+	d.calls++
+	reassignments := make([]entity.Reassignment, 0)
+	for _, id := range userIDs {
+		if _, err := d.repo.SetIsActive(ctx, id, false); err != nil {
+			return nil, nil, err
+		}
+		for _, pr := range d.repo.review[id] {
+			reassignments = append(reassignments, entity.Reassignment{PullRequestID: pr.PullRequestID, OldReviewerID: id, NewReviewerID: "other"})
+		}
+	}
+	return userIDs, reassignments, nil
 }
 
 func TestSetIsActive(t *testing.T) {
 	ctx := context.Background()
 	repo := newUserRepoStub()
 	repo.users["u1"] = entity.User{UserID: "u1", Username: "Alice", TeamName: "backend", IsActive: true}
-	svc := NewService(repo)
+	svc := NewService(repo, &deactivatorStub{repo: repo}, Options{})
 
 	tests := []struct {
 		name    string
@@ -65,7 +128,7 @@ func TestSetIsActive(t *testing.T) {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			user, err := svc.SetIsActive(ctx, tt.userID, tt.active)
+			user, _, err := svc.SetIsActive(ctx, tt.userID, tt.active, nil)
 			if tt.wantErr != nil {
 				require.True(t, errors.Is(err, tt.wantErr))
 				return
@@ -80,16 +143,18 @@ func TestGetReview(t *testing.T) {
 	ctx := context.Background()
 	repo := newUserRepoStub()
 	repo.users["u1"] = entity.User{UserID: "u1"}
-	repo.review["u1"] = []entity.PullRequestShort{{PullRequestID: "pr1"}}
-	svc := NewService(repo)
+	repo.review["u1"] = []entity.PullRequestShort{{PullRequestID: "pr1", Status: "OPEN"}, {PullRequestID: "pr2", Status: "MERGED"}}
+	svc := NewService(repo, nil, Options{})
 
 	tests := []struct {
 		name    string
 		userID  string
+		pending bool
 		wantLen int
 		wantErr error
 	}{
-		{name: "ok", userID: "u1", wantLen: 1},
+		{name: "ok", userID: "u1", wantLen: 2},
+		{name: "pending only", userID: "u1", pending: true, wantLen: 1},
 		{name: "invalid", userID: "", wantErr: ErrInvalidInput},
 		{name: "not found", userID: "missing", wantErr: ErrNotFound},
 	}
@@ -98,7 +163,7 @@ func TestGetReview(t *testing.T) {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			items, err := svc.GetReview(ctx, tt.userID)
+			items, err := svc.GetReview(ctx, tt.userID, tt.pending)
 			if tt.wantErr != nil {
 				require.True(t, errors.Is(err, tt.wantErr))
 				return
@@ -108,3 +173,160 @@ func TestGetReview(t *testing.T) {
 		})
 	}
 }
+
+func TestSetIsActiveReassign(t *testing.T) {
+	ctx := context.Background()
+	yes, no := true, false
+
+	tests := []struct {
+		name      string
+		defaultOn bool
+		reassign  *bool
+		wantCalls int
+	}{
+		{name: "explicit", reassign: &yes, wantCalls: 1},
+		{name: "default on", defaultOn: true, wantCalls: 1},
+		{name: "default overridden", defaultOn: true, reassign: &no, wantCalls: 0},
+		{name: "default off", wantCalls: 0},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			repo := newUserRepoStub()
+			repo.users["u1"] = entity.User{UserID: "u1", TeamName: "backend", IsActive: true}
+			repo.review["u1"] = []entity.PullRequestShort{{PullRequestID: "pr1"}, {PullRequestID: "pr2"}}
+			deactivator := &deactivatorStub{repo: repo}
+			svc := NewService(repo, deactivator, Options{ReassignOnDeactivate: tt.defaultOn})
+
+			user, reassignments, err := svc.SetIsActive(ctx, "u1", false, tt.reassign)
+			require.NoError(t, err)
+			require.False(t, user.IsActive)
+			require.False(t, repo.users["u1"].IsActive)
+			require.Equal(t, tt.wantCalls, deactivator.calls)
+			require.Len(t, reassignments, 2*tt.wantCalls)
+		})
+	}
+
+	_, _, err := NewService(newUserRepoStub(), nil, Options{}).SetIsActive(ctx, "missing", false, &yes)
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestSetCapacity(t *testing.T) {
+	ctx := context.Background()
+	repo := newUserRepoStub()
+	repo.users["u1"] = entity.User{UserID: "u1"}
+	repo.review["u1"] = []entity.PullRequestShort{{PullRequestID: "pr1"}}
+	svc := NewService(repo, nil, Options{})
+	three, negative := 3, -1
+
+	tests := []struct {
+		name     string
+		userID   string
+		capacity *int
+		wantErr  error
+	}{
+		{name: "ok", userID: "u1", capacity: &three},
+		{name: "reset", userID: "u1"},
+		{name: "negative", userID: "u1", capacity: &negative, wantErr: ErrInvalidInput},
+		{name: "invalid", userID: " ", capacity: &three, wantErr: ErrInvalidInput},
+		{name: "not found", userID: "missing", capacity: &three, wantErr: ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, err := svc.SetCapacity(ctx, tt.userID, tt.capacity)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.capacity, user.ReviewCapacity)
+			load, err := svc.GetLoad(ctx, tt.userID)
+			require.NoError(t, err)
+			require.Equal(t, 1, load.OpenReviews)
+			require.Equal(t, tt.capacity, load.ReviewCapacity)
+		})
+	}
+}
+
+func TestNotificationPreferences(t *testing.T) {
+	ctx := context.Background()
+	repo := newUserRepoStub()
+	repo.users["u1"] = entity.User{UserID: "u1"}
+	svc := NewService(repo, nil, Options{})
+
+	prefs, err := svc.GetNotificationPreferences(ctx, "u1")
+	require.NoError(t, err)
+	require.Equal(t, entity.DefaultNotificationChannels, prefs.Channels)
+
+	tests := []struct {
+		name    string
+		input   entity.NotificationPreferences
+		want    entity.NotificationPreferences
+		wantErr error
+	}{
+		{
+			name: "ok",
+			input: entity.NotificationPreferences{
+				UserID: " u1 ", Email: " alice@example.com ", ChatHandle: "@alice",
+				Channels: []string{"email", " chat", "email"}, Events: []string{"assigned"},
+			},
+			want: entity.NotificationPreferences{
+				UserID: "u1", Email: "alice@example.com", ChatHandle: "alice",
+				Channels: []string{"email", "chat"}, Events: []string{"assigned"},
+			},
+		},
+		{
+			name:  "all off",
+			input: entity.NotificationPreferences{UserID: "u1"},
+			want:  entity.NotificationPreferences{UserID: "u1", Channels: []string{}, Events: []string{}},
+		},
+		{name: "unknown channel", input: entity.NotificationPreferences{UserID: "u1", Channels: []string{"sms"}}, wantErr: ErrInvalidInput},
+		{name: "unknown event", input: entity.NotificationPreferences{UserID: "u1", Events: []string{"closed"}}, wantErr: ErrInvalidInput},
+		{name: "email without address", input: entity.NotificationPreferences{UserID: "u1", Channels: []string{"email"}}, wantErr: ErrInvalidInput},
+		{name: "invalid email", input: entity.NotificationPreferences{UserID: "u1", Email: "Alice <alice@example.com>"}, wantErr: ErrInvalidInput},
+		{name: "invalid", input: entity.NotificationPreferences{UserID: " "}, wantErr: ErrInvalidInput},
+		{name: "not found", input: entity.NotificationPreferences{UserID: "missing"}, wantErr: ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := svc.SetNotificationPreferences(ctx, tt.input)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+			stored, err := svc.GetNotificationPreferences(ctx, "u1")
+			require.NoError(t, err)
+			require.Equal(t, tt.want, stored)
+		})
+	}
+}
+
+type digestBuilderStub struct{}
+
+func (digestBuilderStub) Build(ctx context.Context, userID string) (entity.Digest, error) {
+	return entity.Digest{UserID: userID, Text: "digest of " + userID}, nil
+}
+
+func TestDigest(t *testing.T) {
+	ctx := context.Background()
+	repo := newUserRepoStub()
+	repo.users["u1"] = entity.User{UserID: "u1"}
+	svc := NewService(repo, nil, Options{Digests: digestBuilderStub{}})
+
+	digest, err := svc.Digest(ctx, " u1 ")
+	require.NoError(t, err)
+	require.Equal(t, "digest of u1", digest.Text)
+
+	_, err = svc.Digest(ctx, "missing")
+	require.ErrorIs(t, err, ErrNotFound)
+	_, err = svc.Digest(ctx, "")
+	require.ErrorIs(t, err, ErrInvalidInput)
+	_, err = NewService(repo, nil, Options{}).Digest(ctx, "u1")
+	require.ErrorIs(t, err, ErrNotConfigured)
+}
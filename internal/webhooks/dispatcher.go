@@ -0,0 +1,183 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"avito-internship-task/internal/entity"
+)
+
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// DispatchRepo is the storage side of the dispatcher.
+type DispatchRepo interface {
+	FanOut(ctx context.Context, limit int) (int, error)
+	ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]Delivery, error)
+	RecordAttempt(ctx context.Context, res AttemptResult) error
+}
+
+type DispatcherOptions struct {
+	Interval    time.Duration
+	Timeout     time.Duration
+	MaxAttempts int
+	BatchSize   int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+}
+
+// Dispatcher moves outbox messages to webhook deliveries and sends them,
+// retrying failures with exponential backoff until MaxAttempts is reached.
+type Dispatcher struct {
+	repo   DispatchRepo
+	client *http.Client
+	opts   DispatcherOptions
+	now    func() time.Time
+}
+
+func NewDispatcher(repo DispatchRepo, opts DispatcherOptions) *Dispatcher {
+	if opts.Interval <= 0 {
+		opts.Interval = time.Second
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Second
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 8
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+	if opts.BaseBackoff <= 0 {
+		opts.BaseBackoff = time.Second
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = time.Hour
+	}
+	return &Dispatcher{
+		repo:   repo,
+		client: &http.Client{Timeout: opts.Timeout},
+		opts:   opts,
+		now:    func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// Run processes the outbox every Interval until ctx is cancelled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.opts.Interval)
+	defer ticker.Stop()
+	for {
+		if err := d.Tick(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("webhooks: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Tick runs one dispatch round: fan out new outbox messages and send up to BatchSize due deliveries.
+// Deliveries are claimed one at a time, so each lease only has to cover a single request.
+func (d *Dispatcher) Tick(ctx context.Context) error {
+	if _, err := d.repo.FanOut(ctx, d.opts.BatchSize); err != nil {
+		return fmt.Errorf("fan out: %w", err)
+	}
+	for i := 0; i < d.opts.BatchSize; i++ {
+		// the lease outlives a request timeout so a slow receiver is not hit twice
+		deliveries, err := d.repo.ClaimDue(ctx, d.now(), 2*d.opts.Timeout, 1)
+		if err != nil {
+			return fmt.Errorf("claim deliveries: %w", err)
+		}
+		if len(deliveries) == 0 {
+			return nil
+		}
+		delivery := deliveries[0]
+		res := d.send(ctx, delivery)
+		if err := ctx.Err(); err != nil {
+			// shutting down: the attempt did not finish, the lease expires and it is retried
+			return err
+		}
+		if err := d.repo.RecordAttempt(ctx, res); err != nil {
+			return fmt.Errorf("record delivery %d: %w", delivery.DeliveryID, err)
+		}
+	}
+	return nil
+}
+
+func (d *Dispatcher) send(ctx context.Context, delivery Delivery) AttemptResult {
+	res := AttemptResult{DeliveryID: delivery.DeliveryID, Attempts: delivery.Attempts + 1}
+
+	statusCode, err := d.post(ctx, delivery)
+	res.StatusCode = statusCode
+	now := d.now()
+	if err == nil {
+		res.Status = entity.DeliveryDelivered
+		res.NextAttemptAt = now
+		res.DeliveredAt = &now
+		return res
+	}
+	res.Error = err.Error()
+	if res.Attempts >= d.opts.MaxAttempts {
+		res.Status = entity.DeliveryFailed
+		res.NextAttemptAt = now
+		return res
+	}
+	res.Status = entity.DeliveryPending
+	res.NextAttemptAt = now.Add(d.backoff(res.Attempts))
+	return res
+}
+
+func (d *Dispatcher) post(ctx context.Context, delivery Delivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(delivery.DeliveryID, 10))
+	req.Header.Set(HeaderSignature, Sign(delivery.Secret, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff returns the delay before the next attempt: BaseBackoff doubled per failed attempt, capped at MaxBackoff.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.opts.BaseBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= d.opts.MaxBackoff {
+			return d.opts.MaxBackoff
+		}
+	}
+	return delay
+}
+
+// Sign returns the X-Webhook-Signature value for body: "sha256=" followed by the hex HMAC-SHA256.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
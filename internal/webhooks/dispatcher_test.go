@@ -0,0 +1,130 @@
+package webhooks
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"avito-internship-task/internal/entity"
+	"github.com/stretchr/testify/require"
+)
+
+type dispatchRepoStub struct {
+	deliveries []Delivery
+	results    []AttemptResult
+	claims     int
+}
+
+func (r *dispatchRepoStub) FanOut(ctx context.Context, limit int) (int, error) {
+	return 0, nil
+}
+
+func (r *dispatchRepoStub) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]Delivery, error) {
+	r.claims++
+	claimed := r.deliveries[:min(limit, len(r.deliveries))]
+	r.deliveries = r.deliveries[len(claimed):]
+	return claimed, nil
+}
+
+func (r *dispatchRepoStub) RecordAttempt(ctx context.Context, res AttemptResult) error {
+	r.results = append(r.results, res)
+	return nil
+}
+
+func TestDispatcherDelivers(t *testing.T) {
+	payload := []byte(`{"event":"pr.created","pull_request_id":"pr1"}`)
+	var gotBody []byte
+	var gotSignature, gotEvent string
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = io.ReadAll(r.Body)
+		gotSignature = r.Header.Get(HeaderSignature)
+		gotEvent = r.Header.Get(HeaderEvent)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	repo := &dispatchRepoStub{deliveries: []Delivery{{
+		DeliveryID: 1, WebhookID: 1, URL: receiver.URL, Secret: "s3cret",
+		EventType: entity.WebhookPRCreated, Payload: payload,
+	}}}
+	d := NewDispatcher(repo, DispatcherOptions{})
+	require.NoError(t, d.Tick(context.Background()))
+
+	require.Equal(t, payload, gotBody)
+	require.Equal(t, entity.WebhookPRCreated, gotEvent)
+	require.Equal(t, Sign("s3cret", payload), gotSignature)
+	require.Len(t, repo.results, 1)
+	require.Equal(t, entity.DeliveryDelivered, repo.results[0].Status)
+	require.Equal(t, 1, repo.results[0].Attempts)
+	require.Equal(t, http.StatusNoContent, repo.results[0].StatusCode)
+	require.NotNil(t, repo.results[0].DeliveredAt)
+}
+
+func TestDispatcherRetries(t *testing.T) {
+	var calls atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer receiver.Close()
+
+	now := time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC)
+	repo := &dispatchRepoStub{}
+	d := NewDispatcher(repo, DispatcherOptions{MaxAttempts: 3, BaseBackoff: time.Second, MaxBackoff: 3 * time.Second})
+	d.now = func() time.Time { return now }
+
+	wantDelays := []time.Duration{time.Second, 2 * time.Second}
+	for attempt := 0; attempt < 3; attempt++ {
+		repo.deliveries = []Delivery{{DeliveryID: 7, URL: receiver.URL, Secret: "s", Payload: []byte(`{}`), Attempts: attempt}}
+		require.NoError(t, d.Tick(context.Background()))
+		res := repo.results[len(repo.results)-1]
+		require.Equal(t, attempt+1, res.Attempts)
+		require.Equal(t, http.StatusInternalServerError, res.StatusCode)
+		require.NotEmpty(t, res.Error)
+		if attempt < 2 {
+			require.Equal(t, entity.DeliveryPending, res.Status)
+			require.Equal(t, now.Add(wantDelays[attempt]), res.NextAttemptAt)
+		} else {
+			require.Equal(t, entity.DeliveryFailed, res.Status)
+		}
+	}
+	require.EqualValues(t, 3, calls.Load())
+	require.Equal(t, 3*time.Second, d.backoff(10))
+}
+
+func TestDispatcherClaimsOneAtATime(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer receiver.Close()
+
+	repo := &dispatchRepoStub{}
+	for id := int64(1); id <= 3; id++ {
+		repo.deliveries = append(repo.deliveries, Delivery{DeliveryID: id, URL: receiver.URL, Payload: []byte(`{}`)})
+	}
+	d := NewDispatcher(repo, DispatcherOptions{BatchSize: 2})
+	require.NoError(t, d.Tick(context.Background()))
+	require.Len(t, repo.results, 2)
+	require.Equal(t, 2, repo.claims)
+	require.Len(t, repo.deliveries, 1)
+}
+
+func TestDispatcherStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	release := make(chan struct{})
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cancel()
+		<-release
+	}))
+	defer receiver.Close()
+	defer close(release)
+
+	repo := &dispatchRepoStub{deliveries: []Delivery{{DeliveryID: 1, URL: receiver.URL, Payload: []byte(`{}`)}}}
+	d := NewDispatcher(repo, DispatcherOptions{})
+	require.ErrorIs(t, d.Tick(ctx), context.Canceled)
+	require.Empty(t, repo.results)
+}
@@ -0,0 +1,204 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"avito-internship-task/internal/entity"
+	"avito-internship-task/internal/httpserver"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) Register(mux *http.ServeMux) {
+	mux.Handle("/webhooks/add", httpserver.WithError(h.add))
+	mux.Handle("/webhooks/list", httpserver.WithError(h.list))
+	mux.Handle("/webhooks/update", httpserver.WithError(h.update))
+	mux.Handle("/webhooks/delete", httpserver.WithError(h.delete))
+	mux.Handle("/webhooks/deliveries", httpserver.WithError(h.deliveries))
+}
+
+type addRequest struct {
+	URL        string   `json:"url"`
+	Secret     string   `json:"secret"`
+	EventTypes []string `json:"event_types"`
+}
+
+type updateRequest struct {
+	WebhookID  int64    `json:"webhook_id"`
+	URL        string   `json:"url"`
+	Secret     string   `json:"secret"`
+	EventTypes []string `json:"event_types"`
+	IsActive   *bool    `json:"is_active"`
+}
+
+type deleteRequest struct {
+	WebhookID int64 `json:"webhook_id"`
+}
+
+type webhookEnvelope struct {
+	Webhook entity.Webhook `json:"webhook"`
+}
+
+type listResponse struct {
+	Webhooks []entity.Webhook `json:"webhooks"`
+}
+
+type deliveriesResponse struct {
+	WebhookID  int64                    `json:"webhook_id"`
+	Deliveries []entity.WebhookDelivery `json:"deliveries"`
+}
+
+const (
+	codeBadRequest = "BAD_REQUEST"
+	codeNotFound   = "NOT_FOUND"
+)
+
+const invalidWebhookMsg = "url must be an absolute http(s) URL, event_types must be a non-empty subset of pr.created, reviewer.assigned, reviewer.reassigned, reviewer.removed, pr.merged, review.sla_breached"
+
+func (h *Handler) add(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		httpserver.RespondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return nil
+	}
+	var req addRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeWebhookError(w, http.StatusBadRequest, codeBadRequest, "invalid json")
+		return nil
+	}
+	wh, err := h.service.Create(r.Context(), entity.Webhook{
+		URL:        req.URL,
+		Secret:     req.Secret,
+		EventTypes: req.EventTypes,
+	})
+	if err != nil {
+		if errors.Is(err, ErrInvalidInput) {
+			writeWebhookError(w, http.StatusBadRequest, codeBadRequest, invalidWebhookMsg)
+			return nil
+		}
+		return err
+	}
+	httpserver.RespondJSON(w, http.StatusCreated, webhookEnvelope{Webhook: wh})
+	return nil
+}
+
+func (h *Handler) list(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodGet {
+		httpserver.RespondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return nil
+	}
+	items, err := h.service.List(r.Context())
+	if err != nil {
+		return err
+	}
+	httpserver.RespondJSON(w, http.StatusOK, listResponse{Webhooks: items})
+	return nil
+}
+
+func (h *Handler) update(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		httpserver.RespondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return nil
+	}
+	var req updateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeWebhookError(w, http.StatusBadRequest, codeBadRequest, "invalid json")
+		return nil
+	}
+	wh, err := h.service.Update(r.Context(), Update{
+		WebhookID:  req.WebhookID,
+		URL:        req.URL,
+		Secret:     req.Secret,
+		EventTypes: req.EventTypes,
+		IsActive:   req.IsActive,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidInput):
+			writeWebhookError(w, http.StatusBadRequest, codeBadRequest, "webhook_id is required; "+invalidWebhookMsg)
+			return nil
+		case errors.Is(err, ErrNotFound):
+			writeWebhookError(w, http.StatusNotFound, codeNotFound, "webhook not found")
+			return nil
+		default:
+			return err
+		}
+	}
+	httpserver.RespondJSON(w, http.StatusOK, webhookEnvelope{Webhook: wh})
+	return nil
+}
+
+func (h *Handler) delete(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		httpserver.RespondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return nil
+	}
+	var req deleteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeWebhookError(w, http.StatusBadRequest, codeBadRequest, "invalid json")
+		return nil
+	}
+	if err := h.service.Delete(r.Context(), req.WebhookID); err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidInput):
+			writeWebhookError(w, http.StatusBadRequest, codeBadRequest, "webhook_id is required")
+			return nil
+		case errors.Is(err, ErrNotFound):
+			writeWebhookError(w, http.StatusNotFound, codeNotFound, "webhook not found")
+			return nil
+		default:
+			return err
+		}
+	}
+	httpserver.RespondJSON(w, http.StatusOK, map[string]int64{"webhook_id": req.WebhookID})
+	return nil
+}
+
+func (h *Handler) deliveries(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodGet {
+		httpserver.RespondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return nil
+	}
+	id, err := strconv.ParseInt(r.URL.Query().Get("webhook_id"), 10, 64)
+	if err != nil {
+		writeWebhookError(w, http.StatusBadRequest, codeBadRequest, "webhook_id must be a number")
+		return nil
+	}
+	items, err := h.service.Deliveries(r.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidInput):
+			writeWebhookError(w, http.StatusBadRequest, codeBadRequest, "webhook_id is required")
+			return nil
+		case errors.Is(err, ErrNotFound):
+			writeWebhookError(w, http.StatusNotFound, codeNotFound, "webhook not found")
+			return nil
+		default:
+			return err
+		}
+	}
+	httpserver.RespondJSON(w, http.StatusOK, deliveriesResponse{WebhookID: id, Deliveries: items})
+	return nil
+}
+
+type errorEnvelope struct {
+	Error struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func writeWebhookError(w http.ResponseWriter, status int, code, message string) {
+	var e errorEnvelope
+	e.Error.Code = code
+	e.Error.Message = message
+	httpserver.RespondJSON(w, status, e)
+}
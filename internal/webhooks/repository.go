@@ -0,0 +1,235 @@
+package webhooks
+
+import (
+	"context"
+	"time"
+
+	"avito-internship-task/internal/entity"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Delivery is a claimed webhook delivery together with everything needed to send it.
+type Delivery struct {
+	DeliveryID int64
+	WebhookID  int64
+	URL        string
+	Secret     string
+	EventType  string
+	Payload    []byte
+	Attempts   int
+}
+
+// AttemptResult is the outcome of a single delivery attempt.
+type AttemptResult struct {
+	DeliveryID    int64
+	Attempts      int
+	Status        string
+	StatusCode    int
+	Error         string
+	NextAttemptAt time.Time
+	DeliveredAt   *time.Time
+}
+
+type Repository struct {
+	db *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) *Repository {
+	return &Repository{db: db}
+}
+
+func (r *Repository) Create(ctx context.Context, wh entity.Webhook) (entity.Webhook, error) {
+	row := r.db.QueryRow(ctx, `
+INSERT INTO webhooks (url, secret, event_types, is_active)
+VALUES ($1, $2, $3, $4)
+RETURNING webhook_id, created_at
+`, wh.URL, wh.Secret, wh.EventTypes, wh.IsActive)
+	if err := row.Scan(&wh.WebhookID, &wh.CreatedAt); err != nil {
+		return entity.Webhook{}, err
+	}
+	return wh, nil
+}
+
+func (r *Repository) Get(ctx context.Context, id int64) (entity.Webhook, error) {
+	var wh entity.Webhook
+	err := r.db.QueryRow(ctx, `
+SELECT webhook_id, url, event_types, is_active, created_at
+FROM webhooks WHERE webhook_id = $1
+`, id).Scan(&wh.WebhookID, &wh.URL, &wh.EventTypes, &wh.IsActive, &wh.CreatedAt)
+	return wh, err
+}
+
+func (r *Repository) List(ctx context.Context) ([]entity.Webhook, error) {
+	rows, err := r.db.Query(ctx, `
+SELECT webhook_id, url, event_types, is_active, created_at
+FROM webhooks ORDER BY webhook_id
+`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := make([]entity.Webhook, 0)
+	for rows.Next() {
+		var wh entity.Webhook
+		if err := rows.Scan(&wh.WebhookID, &wh.URL, &wh.EventTypes, &wh.IsActive, &wh.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, wh)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// Update changes only the fields that are set in upd.
+func (r *Repository) Update(ctx context.Context, upd Update) (entity.Webhook, error) {
+	var wh entity.Webhook
+	err := r.db.QueryRow(ctx, `
+UPDATE webhooks
+SET url = COALESCE(NULLIF($2, ''), url),
+    secret = COALESCE(NULLIF($3, ''), secret),
+    event_types = COALESCE($4, event_types),
+    is_active = COALESCE($5, is_active)
+WHERE webhook_id = $1
+RETURNING webhook_id, url, event_types, is_active, created_at
+`, upd.WebhookID, upd.URL, upd.Secret, upd.EventTypes, upd.IsActive).
+		Scan(&wh.WebhookID, &wh.URL, &wh.EventTypes, &wh.IsActive, &wh.CreatedAt)
+	return wh, err
+}
+
+func (r *Repository) Delete(ctx context.Context, id int64) error {
+	var deleted int64
+	return r.db.QueryRow(ctx, `DELETE FROM webhooks WHERE webhook_id = $1 RETURNING webhook_id`, id).Scan(&deleted)
+}
+
+func (r *Repository) Deliveries(ctx context.Context, webhookID int64, limit int) ([]entity.WebhookDelivery, error) {
+	rows, err := r.db.Query(ctx, `
+SELECT delivery_id, webhook_id, event_type, status, attempts, last_status_code,
+       COALESCE(last_error, ''), next_attempt_at, created_at, delivered_at
+FROM webhook_deliveries WHERE webhook_id = $1
+ORDER BY delivery_id DESC
+LIMIT $2
+`, webhookID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := make([]entity.WebhookDelivery, 0)
+	for rows.Next() {
+		var d entity.WebhookDelivery
+		if err := rows.Scan(&d.DeliveryID, &d.WebhookID, &d.EventType, &d.Status, &d.Attempts, &d.LastStatusCode,
+			&d.LastError, &d.NextAttemptAt, &d.CreatedAt, &d.DeliveredAt); err != nil {
+			return nil, err
+		}
+		items = append(items, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// FanOut turns undispatched outbox messages into deliveries for every matching active webhook.
+// It returns the number of outbox messages processed.
+func (r *Repository) FanOut(ctx context.Context, limit int) (int, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `
+SELECT outbox_id FROM outbox
+WHERE dispatched_at IS NULL
+ORDER BY outbox_id
+LIMIT $1
+FOR UPDATE SKIP LOCKED
+`, limit)
+	if err != nil {
+		return 0, err
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+	if err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	if _, err := tx.Exec(ctx, `
+INSERT INTO webhook_deliveries (webhook_id, outbox_id, event_type)
+SELECT w.webhook_id, o.outbox_id, o.event_type
+FROM outbox o
+JOIN webhooks w ON w.is_active AND o.event_type = ANY(w.event_types)
+WHERE o.outbox_id = ANY($1)
+ON CONFLICT (webhook_id, outbox_id) DO NOTHING
+`, ids); err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec(ctx, `UPDATE outbox SET dispatched_at = NOW() WHERE outbox_id = ANY($1)`, ids); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return len(ids), nil
+}
+
+// ClaimDue picks pending deliveries that are due at now and leases them until now+lease,
+// so concurrent dispatchers do not send the same delivery twice.
+func (r *Repository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]Delivery, error) {
+	rows, err := r.db.Query(ctx, `
+UPDATE webhook_deliveries d
+SET next_attempt_at = $2
+FROM webhooks w, outbox o
+WHERE d.delivery_id IN (
+    SELECT x.delivery_id FROM webhook_deliveries x
+    JOIN webhooks xw ON xw.webhook_id = x.webhook_id AND xw.is_active
+    WHERE x.status = 'PENDING' AND x.next_attempt_at <= $1
+    ORDER BY x.next_attempt_at
+    LIMIT $3
+    FOR UPDATE OF x SKIP LOCKED
+)
+  AND w.webhook_id = d.webhook_id AND o.outbox_id = d.outbox_id
+RETURNING d.delivery_id, d.webhook_id, w.url, w.secret, d.event_type, o.payload::text, d.attempts
+`, now, now.Add(lease), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := make([]Delivery, 0)
+	for rows.Next() {
+		var d Delivery
+		var payload string
+		if err := rows.Scan(&d.DeliveryID, &d.WebhookID, &d.URL, &d.Secret, &d.EventType, &payload, &d.Attempts); err != nil {
+			return nil, err
+		}
+		d.Payload = []byte(payload)
+		items = append(items, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (r *Repository) RecordAttempt(ctx context.Context, res AttemptResult) error {
+	var statusCode *int
+	if res.StatusCode != 0 {
+		statusCode = &res.StatusCode
+	}
+	_, err := r.db.Exec(ctx, `
+UPDATE webhook_deliveries
+SET attempts = $2, status = $3, last_status_code = $4, last_error = NULLIF($5, ''),
+    next_attempt_at = $6, delivered_at = $7
+WHERE delivery_id = $1
+`, res.DeliveryID, res.Attempts, res.Status, statusCode, res.Error, res.NextAttemptAt, res.DeliveredAt)
+	return err
+}
+
+func isNotFound(err error) bool {
+	return err != nil && err == pgx.ErrNoRows
+}
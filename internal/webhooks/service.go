@@ -0,0 +1,157 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"slices"
+	"strings"
+
+	"avito-internship-task/internal/entity"
+)
+
+var (
+	ErrInvalidInput = errors.New("invalid input")
+	ErrNotFound     = errors.New("not found")
+)
+
+const deliveriesLimit = 100
+
+// Update describes a partial webhook change, zero fields are left untouched.
+type Update struct {
+	WebhookID  int64
+	URL        string
+	Secret     string
+	EventTypes []string
+	IsActive   *bool
+}
+
+type Service struct {
+	repo Repo
+}
+
+type Repo interface {
+	Create(ctx context.Context, wh entity.Webhook) (entity.Webhook, error)
+	Get(ctx context.Context, id int64) (entity.Webhook, error)
+	List(ctx context.Context) ([]entity.Webhook, error)
+	Update(ctx context.Context, upd Update) (entity.Webhook, error)
+	Delete(ctx context.Context, id int64) error
+	Deliveries(ctx context.Context, webhookID int64, limit int) ([]entity.WebhookDelivery, error)
+}
+
+func NewService(repo Repo) *Service {
+	return &Service{repo: repo}
+}
+
+// Create registers a webhook. A secret is generated when none is given,
+// the response is the only place where it is returned.
+func (s *Service) Create(ctx context.Context, wh entity.Webhook) (entity.Webhook, error) {
+	wh.URL = strings.TrimSpace(wh.URL)
+	wh.Secret = strings.TrimSpace(wh.Secret)
+	if !validURL(wh.URL) {
+		return entity.Webhook{}, ErrInvalidInput
+	}
+	types, ok := normalizeEventTypes(wh.EventTypes)
+	if !ok {
+		return entity.Webhook{}, ErrInvalidInput
+	}
+	wh.EventTypes = types
+	if wh.Secret == "" {
+		secret, err := newSecret()
+		if err != nil {
+			return entity.Webhook{}, err
+		}
+		wh.Secret = secret
+	}
+	wh.IsActive = true
+	return s.repo.Create(ctx, wh)
+}
+
+func (s *Service) List(ctx context.Context) ([]entity.Webhook, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *Service) Update(ctx context.Context, upd Update) (entity.Webhook, error) {
+	upd.URL = strings.TrimSpace(upd.URL)
+	upd.Secret = strings.TrimSpace(upd.Secret)
+	if upd.WebhookID <= 0 {
+		return entity.Webhook{}, ErrInvalidInput
+	}
+	if upd.URL != "" && !validURL(upd.URL) {
+		return entity.Webhook{}, ErrInvalidInput
+	}
+	if upd.EventTypes != nil {
+		types, ok := normalizeEventTypes(upd.EventTypes)
+		if !ok {
+			return entity.Webhook{}, ErrInvalidInput
+		}
+		upd.EventTypes = types
+	}
+	wh, err := s.repo.Update(ctx, upd)
+	if err != nil {
+		if isNotFound(err) {
+			return entity.Webhook{}, ErrNotFound
+		}
+		return entity.Webhook{}, err
+	}
+	return wh, nil
+}
+
+func (s *Service) Delete(ctx context.Context, id int64) error {
+	if id <= 0 {
+		return ErrInvalidInput
+	}
+	if err := s.repo.Delete(ctx, id); err != nil {
+		if isNotFound(err) {
+			return ErrNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// Deliveries returns the latest delivery log entries of a webhook, newest first.
+func (s *Service) Deliveries(ctx context.Context, webhookID int64) ([]entity.WebhookDelivery, error) {
+	if webhookID <= 0 {
+		return nil, ErrInvalidInput
+	}
+	if _, err := s.repo.Get(ctx, webhookID); err != nil {
+		if isNotFound(err) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return s.repo.Deliveries(ctx, webhookID, deliveriesLimit)
+}
+
+func validURL(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+func normalizeEventTypes(types []string) ([]string, bool) {
+	result := make([]string, 0, len(types))
+	for _, t := range types {
+		t = strings.TrimSpace(t)
+		if !slices.Contains(entity.WebhookEventTypes, t) {
+			return nil, false
+		}
+		if !slices.Contains(result, t) {
+			result = append(result, t)
+		}
+	}
+	return result, len(result) > 0
+}
+
+func newSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
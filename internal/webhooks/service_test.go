@@ -0,0 +1,155 @@
+package webhooks
+
+import (
+	"context"
+	"testing"
+
+	"avito-internship-task/internal/entity"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+)
+
+type webhookRepoStub struct {
+	webhooks map[int64]entity.Webhook
+	nextID   int64
+}
+
+func newWebhookRepoStub() *webhookRepoStub {
+	return &webhookRepoStub{webhooks: make(map[int64]entity.Webhook)}
+}
+
+func (r *webhookRepoStub) Create(ctx context.Context, wh entity.Webhook) (entity.Webhook, error) {
+	r.nextID++
+	wh.WebhookID = r.nextID
+	r.webhooks[wh.WebhookID] = wh
+	return wh, nil
+}
+
+func (r *webhookRepoStub) Get(ctx context.Context, id int64) (entity.Webhook, error) {
+	wh, ok := r.webhooks[id]
+	if !ok {
+		return entity.Webhook{}, pgx.ErrNoRows
+	}
+	return wh, nil
+}
+
+func (r *webhookRepoStub) List(ctx context.Context) ([]entity.Webhook, error) {
+	items := make([]entity.Webhook, 0, len(r.webhooks))
+	for _, wh := range r.webhooks {
+		items = append(items, wh)
+	}
+	return items, nil
+}
+
+func (r *webhookRepoStub) Update(ctx context.Context, upd Update) (entity.Webhook, error) {
+	wh, ok := r.webhooks[upd.WebhookID]
+	if !ok {
+		return entity.Webhook{}, pgx.ErrNoRows
+	}
+	if upd.URL != "" {
+		wh.URL = upd.URL
+	}
+	if upd.Secret != "" {
+		wh.Secret = upd.Secret
+	}
+	if upd.EventTypes != nil {
+		wh.EventTypes = upd.EventTypes
+	}
+	if upd.IsActive != nil {
+		wh.IsActive = *upd.IsActive
+	}
+	r.webhooks[wh.WebhookID] = wh
+	return wh, nil
+}
+
+func (r *webhookRepoStub) Delete(ctx context.Context, id int64) error {
+	if _, ok := r.webhooks[id]; !ok {
+		return pgx.ErrNoRows
+	}
+	delete(r.webhooks, id)
+	return nil
+}
+
+func (r *webhookRepoStub) Deliveries(ctx context.Context, webhookID int64, limit int) ([]entity.WebhookDelivery, error) {
+	return []entity.WebhookDelivery{}, nil
+}
+
+func TestCreate(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name    string
+		webhook entity.Webhook
+		want    []string
+		wantErr error
+	}{
+		{
+			name:    "ok",
+			webhook: entity.Webhook{URL: " https://bot.example.com/hook ", EventTypes: []string{"pr.created", "pr.merged", "pr.created"}},
+			want:    []string{"pr.created", "pr.merged"},
+		},
+		{
+			name:    "relative url",
+			webhook: entity.Webhook{URL: "/hook", EventTypes: []string{"pr.created"}},
+			wantErr: ErrInvalidInput,
+		},
+		{
+			name:    "unsupported scheme",
+			webhook: entity.Webhook{URL: "ftp://example.com", EventTypes: []string{"pr.created"}},
+			wantErr: ErrInvalidInput,
+		},
+		{
+			name:    "no events",
+			webhook: entity.Webhook{URL: "https://example.com"},
+			wantErr: ErrInvalidInput,
+		},
+		{
+			name:    "unknown event",
+			webhook: entity.Webhook{URL: "https://example.com", EventTypes: []string{"pr.deleted"}},
+			wantErr: ErrInvalidInput,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewService(newWebhookRepoStub())
+			wh, err := svc.Create(ctx, tt.webhook)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, "https://bot.example.com/hook", wh.URL)
+			require.Equal(t, tt.want, wh.EventTypes)
+			require.True(t, wh.IsActive)
+			require.Len(t, wh.Secret, 64)
+		})
+	}
+}
+
+func TestUpdateDelete(t *testing.T) {
+	ctx := context.Background()
+	repo := newWebhookRepoStub()
+	svc := NewService(repo)
+	wh, err := svc.Create(ctx, entity.Webhook{URL: "https://example.com", Secret: "s3cret", EventTypes: []string{"pr.created"}})
+	require.NoError(t, err)
+	require.Equal(t, "s3cret", wh.Secret)
+
+	inactive := false
+	updated, err := svc.Update(ctx, Update{WebhookID: wh.WebhookID, EventTypes: []string{"reviewer.assigned"}, IsActive: &inactive})
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com", updated.URL)
+	require.Equal(t, []string{"reviewer.assigned"}, updated.EventTypes)
+	require.False(t, updated.IsActive)
+
+	_, err = svc.Update(ctx, Update{WebhookID: wh.WebhookID, EventTypes: []string{}})
+	require.ErrorIs(t, err, ErrInvalidInput)
+	_, err = svc.Update(ctx, Update{WebhookID: 42, URL: "https://example.com"})
+	require.ErrorIs(t, err, ErrNotFound)
+
+	_, err = svc.Deliveries(ctx, 42)
+	require.ErrorIs(t, err, ErrNotFound)
+
+	require.NoError(t, svc.Delete(ctx, wh.WebhookID))
+	require.ErrorIs(t, svc.Delete(ctx, wh.WebhookID), ErrNotFound)
+	require.ErrorIs(t, svc.Delete(ctx, 0), ErrInvalidInput)
+}